@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 
@@ -15,16 +17,25 @@ import (
 	"github.com/jimdaga/first-sip/internal/apikeys"
 	"github.com/jimdaga/first-sip/internal/auth"
 	"github.com/jimdaga/first-sip/internal/briefings"
+	"github.com/jimdaga/first-sip/internal/channels"
 	"github.com/jimdaga/first-sip/internal/config"
 	"github.com/jimdaga/first-sip/internal/dashboard"
 	"github.com/jimdaga/first-sip/internal/database"
+	"github.com/jimdaga/first-sip/internal/digest"
+	"github.com/jimdaga/first-sip/internal/feeds"
 	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/pluginbundle"
+	"github.com/jimdaga/first-sip/internal/pluginctl"
 	"github.com/jimdaga/first-sip/internal/plugins"
+	_ "github.com/jimdaga/first-sip/internal/plugins/builtin" // compiled-in plugins self-register
+	"github.com/jimdaga/first-sip/internal/pluginwatch"
 	"github.com/jimdaga/first-sip/internal/settings"
 	"github.com/jimdaga/first-sip/internal/streams"
 	"github.com/jimdaga/first-sip/internal/templates"
 	"github.com/jimdaga/first-sip/internal/tiers"
+	"github.com/jimdaga/first-sip/internal/userwebhooks"
 	"github.com/jimdaga/first-sip/internal/webhook"
+	"github.com/jimdaga/first-sip/internal/webpush"
 	"github.com/jimdaga/first-sip/internal/worker"
 	"gorm.io/gorm"
 )
@@ -38,8 +49,24 @@ func render(c *gin.Context, component templ.Component) {
 func main() {
 	// Parse command-line flags
 	workerMode := flag.Bool("worker", false, "Run in worker mode")
+	migrateBriefings := flag.Bool("migrate-briefings", false, "Convert legacy briefings into daily-briefing plugin runs and exit")
+	generateVAPIDKeys := flag.Bool("generate-vapid-keys", false, "Print a new Web Push (VAPID) key pair and exit")
 	flag.Parse()
 
+	// `plugin validate|lint|new` is a plugin authoring tool; it needs no config.
+	if flag.Arg(0) == "plugin" {
+		os.Exit(pluginctl.Run(flag.Args()[1:], os.Stdout, os.Stderr))
+	}
+
+	if *generateVAPIDKeys {
+		keys, err := webpush.GenerateVAPIDKeys()
+		if err != nil {
+			log.Fatalf("Failed to generate VAPID keys: %v", err)
+		}
+		fmt.Printf("VAPID_PUBLIC_KEY=%s\nVAPID_PRIVATE_KEY=%s\n", keys.Public, keys.Private)
+		return
+	}
+
 	// Load configuration
 	cfg := config.Load()
 
@@ -52,12 +79,13 @@ func main() {
 
 	// Create webhook client
 	webhookClient := webhook.NewClient(cfg.N8NWebhookURL, cfg.N8NWebhookSecret, cfg.N8NStubMode)
+	webhookClient.SetLegacySecretHeader(cfg.N8NLegacySecretHeader)
 
 	// Initialize streams Publisher for Go -> CrewAI communication
 	var publisher *streams.Publisher
 	if cfg.RedisURL != "" {
 		var err error
-		publisher, err = streams.NewPublisher(cfg.RedisURL)
+		publisher, err = streams.NewPublisher(cfg.RedisURL, cfg.PluginQueueMaxDepth)
 		if err != nil {
 			log.Printf("Warning: streams publisher failed to initialize: %v", err)
 		} else {
@@ -119,6 +147,64 @@ func main() {
 		}
 	}
 
+	// Initialize plugin executors, selected per plugin by the manifest runtime.
+	// crewai-stream is only available when the streams publisher is configured.
+	executors := plugins.NewDefaultExecutors(pluginRegistry)
+	if publisher != nil {
+		executors.Register(plugins.RuntimeCrewAIStream, streams.NewCrewAIExecutor(publisher))
+	}
+	executors.Register(plugins.RuntimeN8N, webhook.NewN8NExecutor(webhookClient))
+
+	// Fan terminal plugin runs out to user webhooks, chat channels and Web Push
+	// (deliveries run on the worker).
+	pushEnabled := cfg.VAPIDPublicKey != "" && cfg.VAPIDPrivateKey != ""
+	if db != nil && cfg.RedisURL != "" {
+		plugins.AddRunObserver(worker.RunWebhookObserver(db))
+		plugins.AddRunObserver(worker.RunChannelObserver(db))
+		if pushEnabled {
+			plugins.AddRunObserver(worker.RunPushObserver(db))
+		}
+	}
+
+	// One-off data migration: legacy briefings -> plugin runs
+	if *migrateBriefings {
+		if db == nil {
+			log.Fatal("-migrate-briefings requires DATABASE_URL")
+		}
+		migrated, err := briefings.MigrateToPluginRuns(db, models.LegacyBriefingPluginName)
+		if err != nil {
+			log.Fatalf("Briefing migration failed: %v", err)
+		}
+		log.Printf("Briefing migration complete: %d plugin run(s) created", migrated)
+		return
+	}
+
+	// Hot-reload PLUGIN_DIR: manifest edits are revalidated and swapped into
+	// pluginRegistry in place; other replicas reload via a Redis event. The
+	// watcher also reloads after an admin installs a plugin bundle.
+	var pluginWatcher *pluginwatch.Watcher
+	if pluginRegistry != nil {
+		w, err := pluginwatch.New(cfg.RedisURL, db, pluginRegistry, cfg.PluginDir)
+		if err != nil {
+			log.Printf("Warning: plugin directory watcher failed to start: %v", err)
+		} else {
+			pluginWatcher = w
+		}
+	}
+	if pluginWatcher != nil && cfg.PluginHotReload {
+		stopPluginWatch, err := pluginWatcher.Watch()
+		if err != nil {
+			log.Printf("Warning: plugin directory watcher failed to start: %v", err)
+		} else {
+			defer stopPluginWatch()
+		}
+	}
+
+	trustedKeys, err := pluginbundle.ParseTrustedKeys(cfg.PluginTrustedKeys)
+	if err != nil {
+		log.Printf("Warning: %v; plugin bundle installs disabled", err)
+	}
+
 	// Mode branching: run as worker or web server
 	if *workerMode {
 		log.Println("Starting in WORKER mode")
@@ -130,14 +216,14 @@ func main() {
 		defer stopScheduler()
 
 		// Start Redis Streams result consumer for CrewAI responses
-		stopResultConsumer, err := streams.StartResultConsumer(cfg.RedisURL, db)
+		stopResultConsumer, err := streams.StartResultConsumer(cfg.RedisURL, db, pluginRegistry)
 		if err != nil {
 			log.Printf("Warning: result consumer failed to start: %v", err)
 		} else {
 			defer stopResultConsumer()
 		}
 
-		if err := worker.Run(cfg, db, webhookClient, publisher); err != nil {
+		if err := worker.Run(cfg, db, webhookClient, executors); err != nil {
 			log.Fatalf("Worker failed: %v", err)
 		}
 		return
@@ -150,7 +236,7 @@ func main() {
 	if cfg.Env == "development" && cfg.RedisURL != "" {
 		log.Println("Starting embedded worker for development")
 		var err error
-		stopWorker, err = worker.Start(cfg, db, webhookClient, publisher)
+		stopWorker, err = worker.Start(cfg, db, webhookClient, executors)
 		if err != nil {
 			log.Fatalf("Failed to start embedded worker: %v", err)
 		}
@@ -164,7 +250,7 @@ func main() {
 
 		// Start embedded result consumer for CrewAI responses
 		log.Println("Starting embedded result consumer for development")
-		stopResultConsumer, err = streams.StartResultConsumer(cfg.RedisURL, db)
+		stopResultConsumer, err = streams.StartResultConsumer(cfg.RedisURL, db, pluginRegistry)
 		if err != nil {
 			log.Printf("Warning: result consumer failed to start: %v", err)
 		}
@@ -217,6 +303,15 @@ func main() {
 		}
 		render(c, templates.LoginPage(errorMsg))
 	})
+	r.GET("/unsubscribe/:token", digest.UnsubscribePageHandler(db))
+	r.POST("/unsubscribe/:token", digest.UnsubscribeHandler(db))
+
+	// Private feeds, authenticated by the token in the path (feed readers have no session).
+	r.GET("/feeds/:token/atom.xml", feeds.FeedHandler(db, cfg.AppBaseURL, cfg.HistoryLookbackDays, feeds.FormatAtom))
+	r.GET("/feeds/:token/feed.json", feeds.FeedHandler(db, cfg.AppBaseURL, cfg.HistoryLookbackDays, feeds.FormatJSON))
+	r.GET("/feeds/:token/plugins/:pluginName/atom.xml", feeds.FeedHandler(db, cfg.AppBaseURL, cfg.HistoryLookbackDays, feeds.FormatAtom))
+	r.GET("/feeds/:token/plugins/:pluginName/feed.json", feeds.FeedHandler(db, cfg.AppBaseURL, cfg.HistoryLookbackDays, feeds.FormatJSON))
+
 	r.GET("/auth/google", auth.HandleLogin)
 	r.GET("/auth/google/callback", auth.HandleCallback(db))
 
@@ -224,34 +319,42 @@ func main() {
 	protected := r.Group("/")
 	protected.Use(auth.RequireAuth())
 	{
-		protected.GET("/dashboard", dashboard.DashboardHandler(db))
-		protected.GET("/api/tiles/:pluginID", dashboard.TileStatusHandler(db))
+		protected.GET("/dashboard", dashboard.DashboardHandler(db, publisher, cfg.PluginUnavailableGraceDays))
+		protected.GET("/api/tiles/:pluginID", dashboard.TileStatusHandler(db, cfg.PluginUnavailableGraceDays))
 		protected.POST("/api/tiles/order", dashboard.UpdateTileOrderHandler(db))
 		protected.POST("/api/user/timezone", dashboard.UpdateTimezoneHandler(db))
 		protected.GET("/logout", auth.HandleLogout)
 
 		// Briefing API routes
-		protected.POST("/api/briefings", briefings.CreateBriefingHandler(db))
+		if cfg.LegacyBriefingsEnabled {
+			protected.POST("/api/briefings", briefings.CreateBriefingHandler(db))
+		} else {
+			protected.POST("/api/briefings", briefings.RetiredBriefingHandler())
+		}
 		protected.GET("/api/briefings/:id/status", briefings.GetBriefingStatusHandler(db))
 		protected.POST("/api/briefings/:id/read", briefings.MarkBriefingReadHandler(db))
 
 		// History routes
-		protected.GET("/history", briefings.GetHistoryHandler(db))
-		protected.GET("/api/history", briefings.GetHistoryPageHandler(db))
+		protected.GET("/history", briefings.GetHistoryHandler(db, cfg.HistoryLookbackDays))
+		protected.GET("/api/history", briefings.GetHistoryPageHandler(db, cfg.HistoryLookbackDays))
+		protected.GET("/api/history/search", briefings.SearchHistoryHandler(db, cfg.HistoryLookbackDays))
 		protected.POST("/api/history/briefings/:id/read", briefings.MarkHistoryBriefingReadHandler(db))
 
 		// Plugin detail route (dedicated full-page view per plugin)
-		protected.GET("/plugins/:pluginName", dashboard.PluginDetailHandler(db))
+		protected.GET("/plugins/:pluginName", dashboard.PluginDetailHandler(db, cfg.HistoryLookbackDays, cfg.PluginUnavailableGraceDays))
 
 		// Settings routes
 		protected.GET("/settings", settings.SettingsHubPageHandler(db))
 		protected.GET("/settings/plugins", settings.PluginSettingsPageHandler(db, cfg.PluginDir, tierService))
-		protected.GET("/settings/account", settings.AccountSettingsPageHandler(db))
+		protected.GET("/settings/account", settings.AccountSettingsPageHandler(db, cfg.VAPIDPublicKey))
 		protected.POST("/api/settings/:pluginID/toggle", settings.TogglePluginHandler(db, cfg.PluginDir, tierService))
 		protected.POST("/api/settings/:pluginID/save", settings.SaveSettingsHandler(db, cfg.PluginDir, tierService))
 		protected.POST("/api/settings/:pluginID/validate-field", settings.ValidateFieldHandler(db, cfg.PluginDir))
 		protected.POST("/api/settings/:pluginID/run-now", settings.RunNowHandler(db))
+		protected.POST("/api/settings/:pluginID/secrets", settings.SaveSecretsHandler(db, cfg.PluginDir))
+		protected.POST("/api/settings/:pluginID/secrets/:name/delete", settings.DeleteSecretHandler(db, cfg.PluginDir))
 		protected.POST("/api/user/settings/timezone", settings.SaveTimezoneHandler(db))
+		protected.POST("/api/user/settings/email-digest", settings.SaveEmailDigestHandler(db))
 
 		// API Key management routes
 		protected.GET("/settings/api-keys", apikeys.PageHandler(db))
@@ -259,6 +362,28 @@ func main() {
 		protected.POST("/api/user/api-keys/:id/delete", apikeys.DeleteKeyHandler(db))
 		protected.POST("/api/user/llm-preference", apikeys.SaveLLMPreferenceHandler(db))
 
+		// Outbound user webhook routes
+		protected.GET("/settings/webhooks", userwebhooks.PageHandler(db))
+		protected.POST("/api/user/webhooks", userwebhooks.CreateHandler(db))
+		protected.POST("/api/user/webhooks/:id/delete", userwebhooks.DeleteHandler(db))
+		protected.POST("/api/user/webhooks/:id/test", userwebhooks.TestHandler(db, userwebhooks.NewSender(db, cfg.UserWebhooksAllowPrivateNetworks)))
+
+		// Chat delivery channel routes
+		protected.GET("/settings/channels", channels.PageHandler(db))
+		protected.POST("/api/user/channels", channels.CreateHandler(db))
+		protected.POST("/api/user/channels/:id/delete", channels.DeleteHandler(db))
+		protected.POST("/api/user/channels/:id/test", channels.TestHandler(db, channels.NewSender(cfg.UserWebhooksAllowPrivateNetworks), cfg.AppBaseURL))
+
+		// Private feed settings routes
+		protected.GET("/settings/feeds", feeds.PageHandler(db, cfg.AppBaseURL, cfg.HistoryLookbackDays))
+		protected.POST("/api/user/feeds/rotate", feeds.RotateHandler(db, cfg.AppBaseURL, cfg.HistoryLookbackDays))
+
+		// Web Push subscription routes
+		if pushEnabled {
+			protected.POST("/api/user/push/subscriptions", webpush.SubscribeHandler(db))
+			protected.POST("/api/user/push/subscriptions/delete", webpush.UnsubscribeHandler(db))
+		}
+
 		// Pro coming soon routes
 		protected.GET("/pro", func(c *gin.Context) {
 			var sidebarPlugins []templates.SidebarPlugin
@@ -273,6 +398,12 @@ func main() {
 			render(c, templates.ProComingSoonPage(sidebarPlugins))
 		})
 		protected.POST("/api/pro/notify", settings.ProNotifyHandler())
+
+		// Admin: install signed plugin bundles into PLUGIN_DIR
+		if pluginWatcher != nil && len(trustedKeys) > 0 {
+			admin := protected.Group("/api/admin", auth.RequireAdmin(db))
+			admin.POST("/plugins/install", pluginbundle.InstallHandler(trustedKeys, cfg.PluginDir, cfg.PluginBundleMaxBytes, pluginWatcher.Reload))
+		}
 	}
 
 	// Create HTTP server for graceful shutdown
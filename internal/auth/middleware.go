@@ -5,6 +5,8 @@ import (
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/models"
+	"gorm.io/gorm"
 )
 
 // RequireAuth is a middleware that ensures the user is authenticated
@@ -35,3 +37,18 @@ func RequireAuth() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireAdmin is a middleware, chained after RequireAuth, that allows only
+// users whose role is "admin". Other users get 403.
+func RequireAdmin(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email, _ := c.Get("user_email")
+		emailStr, _ := email.(string)
+		var user models.User
+		if emailStr == "" || db.Where("email = ?", emailStr).First(&user).Error != nil || user.Role != "admin" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			return
+		}
+		c.Next()
+	}
+}
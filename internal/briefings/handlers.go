@@ -1,13 +1,16 @@
 package briefings
 
 import (
+	"log/slog"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/history"
 	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/search"
+	"github.com/jimdaga/first-sip/internal/searchvm"
 	"github.com/jimdaga/first-sip/internal/templates"
 	"github.com/jimdaga/first-sip/internal/worker"
 	"gorm.io/gorm"
@@ -71,6 +74,17 @@ func CreateBriefingHandler(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// RetiredBriefingHandler replaces CreateBriefingHandler once the legacy
+// briefing path is switched off (LEGACY_BRIEFINGS_ENABLED=false). Briefings are
+// then produced by the daily-briefing plugin.
+func RetiredBriefingHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 200 rather than 410 so HTMX swaps the notice into the briefing area.
+		c.Header("Content-Type", "text/html")
+		c.String(http.StatusOK, `<div class="alert alert-info">Briefings are now generated by the Daily Briefing plugin. Enable it in <a href="/settings/plugins">Plugin settings</a>.</div>`)
+	}
+}
+
 // GetBriefingStatusHandler returns the current status of a briefing
 func GetBriefingStatusHandler(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -122,8 +136,9 @@ func MarkBriefingReadHandler(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// GetHistoryHandler renders the full history page
-func GetHistoryHandler(db *gorm.DB) gin.HandlerFunc {
+// GetHistoryHandler renders the full history page: a unified timeline of
+// plugin runs and legacy briefings over the selected lookback (?days=).
+func GetHistoryHandler(db *gorm.DB, lookbackDays int) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user email from context (set by auth middleware)
 		userEmail, exists := c.Get("user_email")
@@ -140,25 +155,18 @@ func GetHistoryHandler(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Query briefings from last 30 days, completed and failed only
-		thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
-		var briefings []models.Briefing
-		if err := db.Where("user_id = ? AND created_at >= ?", user.ID, thirtyDaysAgo).
-			Where("status IN ?", []string{models.BriefingStatusCompleted, models.BriefingStatusFailed}).
-			Order("created_at DESC").
-			Limit(11). // Fetch pageSize+1 to detect hasMore
-			Find(&briefings).Error; err != nil {
+		q := history.Query{
+			UserID:       user.ID,
+			LookbackDays: history.ParseLookback(c.Query("days"), lookbackDays),
+		}
+		timeline, err := history.Load(db, q, userLocation(&user), time.Now(), "/api/history", "", lookbackDays)
+		if err != nil {
+			slog.Error("history: failed to load timeline", "user_id", user.ID, "error", err)
 			c.Header("Content-Type", "text/html")
-			c.String(http.StatusInternalServerError, `<div class="content-error">Failed to load briefings</div>`)
+			c.String(http.StatusInternalServerError, `<div class="content-error">Failed to load history</div>`)
 			return
 		}
 
-		// Detect if there are more results
-		hasMore := len(briefings) > 10
-		if hasMore {
-			briefings = briefings[:10] // Trim to pageSize
-		}
-
 		// Get user name from context for page header
 		name, _ := c.Get("user_name")
 		nameStr := ""
@@ -171,12 +179,15 @@ func GetHistoryHandler(db *gorm.DB) gin.HandlerFunc {
 
 		// Render full history page
 		c.Header("Content-Type", "text/html")
-		templates.HistoryPage(nameStr, briefings, 0, hasMore, sidebarPlugins).Render(c.Request.Context(), c.Writer)
+		filters := searchvm.Filters{PluginOptions: search.PluginOptions(db, user.ID)}
+		templates.HistoryPage(nameStr, timeline, filters, sidebarPlugins).Render(c.Request.Context(), c.Writer)
 	}
 }
 
-// GetHistoryPageHandler renders paginated history results (HTMX fragment)
-func GetHistoryPageHandler(db *gorm.DB) gin.HandlerFunc {
+// GetHistoryPageHandler renders one page of the timeline (HTMX fragment).
+// Query parameters: cursor (from the previous page), days, plugin (per-plugin
+// history tab) and prev_day (for date grouping across pages).
+func GetHistoryPageHandler(db *gorm.DB, lookbackDays int) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user email from context (set by auth middleware)
 		userEmail, exists := c.Get("user_email")
@@ -193,47 +204,109 @@ func GetHistoryPageHandler(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Parse page query parameter
-		page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
-		if page < 0 {
-			page = 0
+		cursor, err := history.DecodeCursor(c.Query("cursor"))
+		if err != nil {
+			c.Status(http.StatusBadRequest)
+			return
 		}
 
-		// Query briefings from last 30 days with pagination
-		thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
-		var briefings []models.Briefing
-		if err := db.Where("user_id = ? AND created_at >= ?", user.ID, thirtyDaysAgo).
-			Where("status IN ?", []string{models.BriefingStatusCompleted, models.BriefingStatusFailed}).
-			Order("created_at DESC").
-			Offset(page * 10).
-			Limit(11). // Fetch pageSize+1 to detect hasMore
-			Find(&briefings).Error; err != nil {
+		q := history.Query{
+			UserID:       user.ID,
+			PluginName:   c.Query("plugin"),
+			LookbackDays: history.ParseLookback(c.Query("days"), lookbackDays),
+			Cursor:       cursor,
+		}
+		timeline, err := history.Load(db, q, userLocation(&user), time.Now(), "/api/history", c.Query("prev_day"), lookbackDays)
+		if err != nil {
+			slog.Error("history: failed to load timeline", "user_id", user.ID, "error", err)
 			c.Header("Content-Type", "text/html")
-			c.String(http.StatusInternalServerError, `<div class="content-error">Failed to load briefings</div>`)
+			c.String(http.StatusInternalServerError, `<div class="content-error">Failed to load history</div>`)
 			return
 		}
 
-		// Detect if there are more results
-		hasMore := len(briefings) > 10
-		if hasMore {
-			briefings = briefings[:10] // Trim to pageSize
+		// Render history list fragment
+		c.Header("Content-Type", "text/html")
+		templates.HistoryList(timeline, cursor == nil).Render(c.Request.Context(), c.Writer)
+	}
+}
+
+// SearchHistoryHandler renders full-text search results over plugin runs and
+// briefings (HTMX fragment for #history-results). With no query or filters it
+// falls back to the default timeline.
+func SearchHistoryHandler(db *gorm.DB, lookbackDays int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail, exists := c.Get("user_email")
+		if !exists {
+			c.Status(http.StatusUnauthorized)
+			return
 		}
 
-		// Render history list fragment
+		var user models.User
+		if err := db.Where("email = ?", userEmail.(string)).First(&user).Error; err != nil {
+			c.Header("Content-Type", "text/html")
+			c.String(http.StatusInternalServerError, `<div class="content-error">Failed to lookup user</div>`)
+			return
+		}
+
+		loc := userLocation(&user)
+
 		c.Header("Content-Type", "text/html")
-		templates.HistoryList(briefings, page, hasMore).Render(c.Request.Context(), c.Writer)
+		query := c.Request.URL.Query()
+		params, filters, err := search.ParseParams(query, loc)
+		if err != nil {
+			templates.HistorySearchResults(nil, true, "", "Check your search filters and try again.").Render(c.Request.Context(), c.Writer)
+			return
+		}
+
+		if !filters.Active() {
+			q := history.Query{UserID: user.ID, LookbackDays: lookbackDays}
+			timeline, err := history.Load(db, q, loc, time.Now(), "/api/history", "", lookbackDays)
+			if err != nil {
+				c.String(http.StatusInternalServerError, `<div class="content-error">Failed to load history</div>`)
+				return
+			}
+			templates.HistoryList(timeline, true).Render(c.Request.Context(), c.Writer)
+			return
+		}
+
+		results, hasMore, err := search.Search(db, user.ID, params, loc)
+		if err != nil {
+			slog.Error("history search failed", "user_id", user.ID, "error", err)
+			templates.HistorySearchResults(nil, true, "", "Search failed. Please try again.").Render(c.Request.Context(), c.Writer)
+			return
+		}
+
+		nextURL := ""
+		if hasMore {
+			nextURL = search.NextURL("/api/history/search", query, params)
+		}
+		templates.HistorySearchResults(results, params.Page == 0, nextURL, "").Render(c.Request.Context(), c.Writer)
 	}
 }
 
 // MarkHistoryBriefingReadHandler marks a briefing as read and returns updated history card HTML
 func MarkHistoryBriefingReadHandler(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Get user email from context (set by auth middleware)
+		userEmail, exists := c.Get("user_email")
+		if !exists {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		if err := db.Where("email = ?", userEmail.(string)).First(&user).Error; err != nil {
+			c.Header("Content-Type", "text/html")
+			c.String(http.StatusInternalServerError, `<div class="content-error">Failed to lookup user</div>`)
+			return
+		}
+
 		// Parse briefing ID from URL parameter
 		briefingID := c.Param("id")
 
-		// Query briefing
+		// Query briefing (scoped to the user)
 		var briefing models.Briefing
-		if err := db.First(&briefing, briefingID).Error; err != nil {
+		if err := db.Where("user_id = ?", user.ID).First(&briefing, briefingID).Error; err != nil {
 			c.Header("Content-Type", "text/html")
 			c.String(http.StatusNotFound, `<div class="content-error">Briefing not found</div>`)
 			return
@@ -251,8 +324,17 @@ func MarkHistoryBriefingReadHandler(db *gorm.DB) gin.HandlerFunc {
 			briefing.ReadAt = &now
 		}
 
-		// Return updated history briefing card HTML
+		// Return updated history card HTML
 		c.Header("Content-Type", "text/html")
-		templates.HistoryBriefingCard(briefing).Render(c.Request.Context(), c.Writer)
+		templates.HistoryEntryCard(history.BriefingEntry(&briefing, userLocation(&user))).Render(c.Request.Context(), c.Writer)
+	}
+}
+
+// userLocation returns the user's timezone, falling back to UTC.
+func userLocation(user *models.User) *time.Location {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC
 	}
+	return loc
 }
@@ -0,0 +1,104 @@
+package briefings
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/webhook"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrateBatchSize is the number of briefings converted per transaction.
+const migrateBatchSize = 500
+
+// MigrateToPluginRuns converts completed and failed Briefing rows into
+// PluginRun rows on the named plugin, preserving their timestamps. Briefing content is converted to the plugin output shape with
+// webhook.ToPluginOutput. Briefings that are still pending or processing are
+// left for the legacy worker to finish.
+//
+// The migration is idempotent: each run's plugin_run_id is derived from the
+// briefing ID and existing rows are skipped. Returns the number of runs created.
+func MigrateToPluginRuns(db *gorm.DB, pluginName string) (int64, error) {
+	var plugin plugins.Plugin
+	if err := db.Where("name = ?", pluginName).First(&plugin).Error; err != nil {
+		return 0, fmt.Errorf("briefings: look up plugin %s: %w", pluginName, err)
+	}
+
+	var migrated int64
+	var briefings []models.Briefing
+	result := db.Where("status IN ?", []string{models.BriefingStatusCompleted, models.BriefingStatusFailed}).
+		Order("id").
+		FindInBatches(&briefings, migrateBatchSize, func(tx *gorm.DB, batch int) error {
+			runs := make([]plugins.PluginRun, 0, len(briefings))
+			for _, b := range briefings {
+				runs = append(runs, briefingToRun(b, plugin.ID))
+			}
+			res := tx.Omit(clause.Associations).
+				Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "plugin_run_id"}}, DoNothing: true}).
+				Create(&runs)
+			if res.Error != nil {
+				return res.Error
+			}
+			migrated += res.RowsAffected
+			slog.Info("Migrated briefing batch", "batch", batch, "created", res.RowsAffected, "briefings", len(runs))
+			return nil
+		})
+	if result.Error != nil {
+		return migrated, fmt.Errorf("briefings: migrate to plugin runs: %w", result.Error)
+	}
+	return migrated, nil
+}
+
+// LegacyRunID is the plugin_run_id a briefing is migrated to.
+func LegacyRunID(briefingID uint) string {
+	return fmt.Sprintf("%s%d", models.LegacyBriefingRunIDPrefix, briefingID)
+}
+
+// briefingToRun builds the PluginRun equivalent of a finished briefing.
+// Content that cannot be converted yields a failed run rather than aborting.
+func briefingToRun(b models.Briefing, pluginID uint) plugins.PluginRun {
+	input, _ := json.Marshal(map[string]interface{}{"_legacy_briefing_id": b.ID})
+
+	completedAt := b.UpdatedAt
+	if b.GeneratedAt != nil {
+		completedAt = *b.GeneratedAt
+	}
+	startedAt := b.CreatedAt
+
+	run := plugins.PluginRun{
+		PluginRunID:  LegacyRunID(b.ID),
+		UserID:       b.UserID,
+		PluginID:     pluginID,
+		Status:       plugins.PluginRunStatusFailed,
+		Input:        datatypes.JSON(input),
+		ErrorMessage: b.ErrorMessage,
+		StartedAt:    &startedAt,
+		CompletedAt:  &completedAt,
+	}
+	run.CreatedAt = b.CreatedAt
+	run.UpdatedAt = time.Now()
+
+	if b.Status != models.BriefingStatusCompleted {
+		return run
+	}
+
+	var content webhook.BriefingContent
+	if err := json.Unmarshal(b.Content, &content); err != nil {
+		run.ErrorMessage = "legacy briefing content could not be parsed"
+		return run
+	}
+	output, err := webhook.ToPluginOutput(&content)
+	if err != nil {
+		run.ErrorMessage = "legacy briefing content could not be converted"
+		return run
+	}
+	run.Status = plugins.PluginRunStatusCompleted
+	run.Output = datatypes.JSON(output)
+	return run
+}
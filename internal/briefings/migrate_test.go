@@ -0,0 +1,118 @@
+package briefings
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/datatypes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Briefing{}, &plugins.Plugin{}, &plugins.PluginRun{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMigrateToPluginRuns(t *testing.T) {
+	db := openTestDB(t)
+	plugin := plugins.Plugin{Name: models.LegacyBriefingPluginName, Version: "1.0.0"}
+	if err := db.Create(&plugin).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2026, 1, 5, 6, 0, 0, 0, time.UTC)
+	generated := created.Add(2 * time.Minute)
+	content := `{"news":[{"title":"<script>alert(1)</script>","summary":"A & B"}],"weather":{},"work":{}}`
+	briefings := []models.Briefing{
+		{UserID: 1, Status: models.BriefingStatusCompleted, Content: datatypes.JSON(content), GeneratedAt: &generated},
+		{UserID: 1, Status: models.BriefingStatusFailed, ErrorMessage: "n8n timed out"},
+		{UserID: 2, Status: models.BriefingStatusCompleted, Content: datatypes.JSON(`{"news": "oops"`)},
+		{UserID: 2, Status: models.BriefingStatusCompleted},
+		{UserID: 2, Status: models.BriefingStatusPending},
+	}
+	for i := range briefings {
+		briefings[i].CreatedAt = created
+		if err := db.Create(&briefings[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := MigrateToPluginRuns(db, plugin.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Fatalf("migrated %d, want 4 (pending briefings are left alone)", n)
+	}
+
+	run := func(b models.Briefing) plugins.PluginRun {
+		t.Helper()
+		var r plugins.PluginRun
+		if err := db.Where("plugin_run_id = ?", LegacyRunID(b.ID)).First(&r).Error; err != nil {
+			t.Fatalf("briefing %d: %v", b.ID, err)
+		}
+		return r
+	}
+
+	ok := run(briefings[0])
+	if ok.Status != plugins.PluginRunStatusCompleted || ok.UserID != 1 || ok.PluginID != plugin.ID {
+		t.Errorf("completed briefing run = %+v", ok)
+	}
+	if !ok.StartedAt.Equal(created) || !ok.CompletedAt.Equal(generated) {
+		t.Errorf("timestamps = %v to %v, want %v to %v", ok.StartedAt, ok.CompletedAt, created, generated)
+	}
+	var out struct {
+		Summary  string `json:"summary"`
+		Sections []struct {
+			Title, Content string
+		} `json:"sections"`
+	}
+	if err := json.Unmarshal(ok.Output, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Summary != "1 headline" || len(out.Sections) != 1 {
+		t.Fatalf("output = %+v", out)
+	}
+	if c := out.Sections[0].Content; strings.Contains(c, "<script>") || !strings.Contains(c, "&lt;script&gt;") || !strings.Contains(c, "A &amp; B") {
+		t.Errorf("news section not escaped: %s", c)
+	}
+
+	if r := run(briefings[1]); r.Status != plugins.PluginRunStatusFailed || r.ErrorMessage != "n8n timed out" {
+		t.Errorf("failed briefing run = %s (%s)", r.Status, r.ErrorMessage)
+	}
+	for _, b := range briefings[2:4] {
+		if r := run(b); r.Status != plugins.PluginRunStatusFailed || r.ErrorMessage != "legacy briefing content could not be parsed" {
+			t.Errorf("briefing %d with bad content: run = %s (%s)", b.ID, r.Status, r.ErrorMessage)
+		}
+	}
+
+	// Re-running skips briefings that were already migrated.
+	if n, err := MigrateToPluginRuns(db, plugin.Name); err != nil || n != 0 {
+		t.Errorf("second migration = %d, %v; want 0", n, err)
+	}
+	var count int64
+	db.Model(&plugins.PluginRun{}).Count(&count)
+	if count != 4 {
+		t.Errorf("plugin runs = %d after re-running, want 4", count)
+	}
+
+	if _, err := MigrateToPluginRuns(db, "missing-plugin"); err == nil {
+		t.Error("expected an error for an unknown plugin")
+	}
+}
@@ -0,0 +1,171 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/models"
+)
+
+var testPost = Post{
+	Title:   "☕ Daily Briefing",
+	Summary: "Rain & wind <today>",
+	Sections: []dashboard.OutputSection{
+		{Title: "News", Content: `<ul><li><a href="https://example.com/a?x=1&amp;y=2">Story &amp; more</a></li><li><b>Bold</b> item</li></ul>`},
+	},
+	URL: "https://first-sip.example/plugins/daily-briefing",
+}
+
+// stub records the last request body and replies with status.
+func stub(t *testing.T, status int) (*httptest.Server, *[]byte) {
+	t.Helper()
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &body
+}
+
+func TestDeliverSlack(t *testing.T) {
+	srv, body := stub(t, http.StatusOK)
+	sender := NewSenderWithClient(srv.Client())
+	ch := &models.NotificationChannel{Kind: models.ChannelKindSlack, WebhookURL: srv.URL}
+
+	if err := sender.Deliver(context.Background(), ch, testPost); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	var msg struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type string `json:"type"`
+			Text struct {
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(*body, &msg); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if msg.Blocks[0].Type != "header" || msg.Blocks[0].Text.Text != testPost.Title {
+		t.Errorf("first block = %+v, want header", msg.Blocks[0])
+	}
+	if got := msg.Blocks[1].Text.Text; got != "Rain &amp; wind &lt;today&gt;" {
+		t.Errorf("summary = %q, want Slack-escaped text", got)
+	}
+	section := msg.Blocks[2].Text.Text
+	for _, want := range []string{"*News*", "<https://example.com/a?x=1&y=2|Story &amp; more>", "• *Bold* item"} {
+		if !strings.Contains(section, want) {
+			t.Errorf("section %q missing %q", section, want)
+		}
+	}
+}
+
+func TestDeliverDiscord(t *testing.T) {
+	srv, body := stub(t, http.StatusNoContent)
+	sender := NewSenderWithClient(srv.Client())
+	ch := &models.NotificationChannel{Kind: models.ChannelKindDiscord, WebhookURL: srv.URL}
+
+	if err := sender.Deliver(context.Background(), ch, testPost); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	var msg struct {
+		Embeds []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+			Fields      []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"fields"`
+		} `json:"embeds"`
+	}
+	if err := json.Unmarshal(*body, &msg); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	e := msg.Embeds[0]
+	if e.Title != testPost.Title || e.URL != testPost.URL || e.Description != testPost.Summary {
+		t.Errorf("embed = %+v", e)
+	}
+	if len(e.Fields) != 1 || e.Fields[0].Name != "News" {
+		t.Fatalf("fields = %+v", e.Fields)
+	}
+	if want := "[Story & more](https://example.com/a?x=1&y=2)"; !strings.Contains(e.Fields[0].Value, want) {
+		t.Errorf("field value %q missing %q", e.Fields[0].Value, want)
+	}
+}
+
+func TestDeliverMatrix(t *testing.T) {
+	srv, body := stub(t, http.StatusOK)
+	sender := NewSenderWithClient(srv.Client())
+	ch := &models.NotificationChannel{Kind: models.ChannelKindMatrix, WebhookURL: srv.URL}
+
+	if err := sender.Deliver(context.Background(), ch, testPost); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	var msg map[string]string
+	if err := json.Unmarshal(*body, &msg); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !strings.Contains(msg["html"], "<strong>Rain &amp; wind &lt;today&gt;</strong>") {
+		t.Errorf("html summary not escaped: %q", msg["html"])
+	}
+	if !strings.Contains(msg["text"], "Rain & wind <today>") {
+		t.Errorf("text = %q", msg["text"])
+	}
+}
+
+func TestDeliverClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusNotFound, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusBadGateway, false},
+	}
+	for _, tt := range tests {
+		srv, _ := stub(t, tt.status)
+		sender := NewSenderWithClient(srv.Client())
+		ch := &models.NotificationChannel{Kind: models.ChannelKindSlack, WebhookURL: srv.URL}
+
+		err := sender.Deliver(context.Background(), ch, testPost)
+		if err == nil {
+			t.Fatalf("status %d: expected error", tt.status)
+		}
+		if got := errors.Is(err, ErrPermanent); got != tt.permanent {
+			t.Errorf("status %d: permanent = %v, want %v", tt.status, got, tt.permanent)
+		}
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		kind, url string
+		ok        bool
+	}{
+		{models.ChannelKindSlack, "https://hooks.slack.com/services/T/B/X", true},
+		{models.ChannelKindSlack, "https://evil.example/services/T/B/X", false},
+		{models.ChannelKindDiscord, "https://discord.com/api/webhooks/1/abc", true},
+		{models.ChannelKindDiscord, "https://discord.com/channels/1", false},
+		{models.ChannelKindMatrix, "https://hookshot.example.org/webhook/abc", true},
+		{models.ChannelKindMatrix, "http://hookshot.example.org/webhook/abc", false},
+		{"irc", "https://example.com", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateWebhookURL(tt.kind, tt.url); (err == nil) != tt.ok {
+			t.Errorf("ValidateWebhookURL(%q, %q) = %v, want ok=%v", tt.kind, tt.url, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Package channels posts plugin output to chat platforms (Slack, Discord and
+// Matrix) through per-user incoming webhooks.
+package channels
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/models"
+)
+
+// Post is the platform-neutral message built from one PluginRunOutput.
+// Section content is plugin HTML, the same as rendered on the dashboard.
+type Post struct {
+	Title    string // e.g. "☕ Daily Briefing"
+	Summary  string
+	Sections []dashboard.OutputSection
+	URL      string // link back to the plugin detail page
+}
+
+// Formatter renders a Post as the JSON body expected by a platform's
+// incoming webhook.
+type Formatter func(Post) ([]byte, error)
+
+// formatters maps each channel kind to its formatter.
+var formatters = map[string]Formatter{
+	models.ChannelKindSlack:   FormatSlack,
+	models.ChannelKindDiscord: FormatDiscord,
+	models.ChannelKindMatrix:  FormatMatrix,
+}
+
+// Platform limits, from each platform's API documentation.
+const (
+	slackMaxBlocks      = 50
+	slackMaxSectionText = 3000
+	slackMaxHeaderText  = 150
+
+	discordMaxTitle       = 256
+	discordMaxDescription = 4096
+	discordMaxFields      = 25
+	discordMaxFieldName   = 256
+	discordMaxFieldValue  = 1024
+	discordMaxEmbedTotal  = 6000
+)
+
+// FormatSlack renders a Block Kit message.
+func FormatSlack(p Post) ([]byte, error) {
+	type text struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	type block struct {
+		Type     string `json:"type"`
+		Text     *text  `json:"text,omitempty"`
+		Elements []text `json:"elements,omitempty"`
+	}
+
+	blocks := []block{{Type: "header", Text: &text{Type: "plain_text", Text: limit(p.Title, slackMaxHeaderText)}}}
+	if p.Summary != "" {
+		blocks = append(blocks, block{Type: "section", Text: &text{Type: "mrkdwn", Text: limit(slackEscape(p.Summary), slackMaxSectionText)}})
+	}
+	for _, s := range p.Sections {
+		if len(blocks) >= slackMaxBlocks-1 {
+			break
+		}
+		body := toMarkdown(s.Content, slackLink, "*", true)
+		if s.Title != "" && s.Title != "Briefing" {
+			body = "*" + slackEscape(s.Title) + "*\n" + body
+		}
+		if strings.TrimSpace(body) == "" {
+			continue
+		}
+		blocks = append(blocks, block{Type: "section", Text: &text{Type: "mrkdwn", Text: limit(body, slackMaxSectionText)}})
+	}
+	if p.URL != "" {
+		blocks = append(blocks, block{Type: "context", Elements: []text{{Type: "mrkdwn", Text: slackLink("View in First Sip", p.URL)}}})
+	}
+
+	fallback := p.Title
+	if p.Summary != "" {
+		fallback += ": " + p.Summary
+	}
+	return json.Marshal(map[string]interface{}{
+		"text":   slackEscape(fallback),
+		"blocks": blocks,
+	})
+}
+
+// FormatDiscord renders a single embed.
+func FormatDiscord(p Post) ([]byte, error) {
+	type field struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	type embed struct {
+		Title       string  `json:"title"`
+		URL         string  `json:"url,omitempty"`
+		Description string  `json:"description,omitempty"`
+		Fields      []field `json:"fields,omitempty"`
+	}
+
+	e := embed{
+		Title:       limit(p.Title, discordMaxTitle),
+		URL:         p.URL,
+		Description: limit(p.Summary, discordMaxDescription),
+	}
+	total := len(e.Title) + len(e.Description)
+	for _, s := range p.Sections {
+		if len(e.Fields) >= discordMaxFields {
+			break
+		}
+		name := s.Title
+		if name == "" {
+			name = "​" // Discord requires a non-empty field name
+		}
+		f := field{
+			Name:  limit(name, discordMaxFieldName),
+			Value: limit(toMarkdown(s.Content, markdownLink, "**", false), discordMaxFieldValue),
+		}
+		if f.Value == "" {
+			continue
+		}
+		if total+len(f.Name)+len(f.Value) > discordMaxEmbedTotal {
+			break
+		}
+		total += len(f.Name) + len(f.Value)
+		e.Fields = append(e.Fields, f)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"username": "First Sip",
+		"embeds":   []embed{e},
+	})
+}
+
+// FormatMatrix renders a matrix-hookshot generic webhook payload. Hookshot
+// posts "html" as the formatted body and "text" as the plain-text fallback.
+func FormatMatrix(p Post) ([]byte, error) {
+	var plain, rich strings.Builder
+
+	plain.WriteString(p.Title + "\n")
+	rich.WriteString("<h4>" + html.EscapeString(p.Title) + "</h4>")
+	if p.Summary != "" {
+		plain.WriteString(p.Summary + "\n")
+		rich.WriteString("<p><strong>" + html.EscapeString(p.Summary) + "</strong></p>")
+	}
+	for _, s := range p.Sections {
+		if s.Title != "" && s.Title != "Briefing" {
+			plain.WriteString("\n" + s.Title + "\n")
+			rich.WriteString("<h5>" + html.EscapeString(s.Title) + "</h5>")
+		}
+		plain.WriteString(toMarkdown(s.Content, markdownLink, "**", false) + "\n")
+		rich.WriteString(s.Content) // plugin HTML is already escaped; clients sanitise further
+	}
+	if p.URL != "" {
+		plain.WriteString("\n" + p.URL + "\n")
+		rich.WriteString(`<p><a href="` + html.EscapeString(p.URL) + `">View in First Sip</a></p>`)
+	}
+
+	return json.Marshal(map[string]string{
+		"username": "First Sip",
+		"text":     plain.String(),
+		"html":     rich.String(),
+	})
+}
+
+var (
+	anchorPattern = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*["']([^"']+)["'][^>]*>(.*?)</a>`)
+	boldPattern   = regexp.MustCompile(`(?is)<(b|strong)>(.*?)</(b|strong)>`)
+	italicPattern = regexp.MustCompile(`(?is)<(i|em)>(.*?)</(i|em)>`)
+	listItemOpen  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	blockClose    = regexp.MustCompile(`(?i)<\s*(br|/p|/li|/h[1-6]|/div|/ul|/ol)\s*/?>`)
+	anyTag        = regexp.MustCompile(`<[^>]*>`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+	linkMarker    = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// toMarkdown converts plugin section HTML to chat markdown. link formats an
+// anchor; bold is the platform's bold delimiter; escapeSlack applies Slack's
+// &, <, > escaping to the text (but not to generated links).
+func toMarkdown(s string, link func(text, href string) string, bold string, escapeSlack bool) string {
+	// Pull links out first so their syntax survives tag stripping and escaping.
+	var links []string
+	s = anchorPattern.ReplaceAllStringFunc(s, func(m string) string {
+		parts := anchorPattern.FindStringSubmatch(m)
+		href := html.UnescapeString(parts[1])
+		label := html.UnescapeString(anyTag.ReplaceAllString(parts[2], ""))
+		if escapeSlack {
+			label = slackEscape(label)
+		}
+		links = append(links, link(label, href))
+		return fmt.Sprintf("\x00%d\x00", len(links)-1)
+	})
+
+	s = boldPattern.ReplaceAllString(s, bold+"$2"+bold)
+	s = italicPattern.ReplaceAllString(s, "_${2}_")
+	s = listItemOpen.ReplaceAllString(s, "• ")
+	s = blockClose.ReplaceAllString(s, "\n")
+	s = anyTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	if escapeSlack {
+		s = slackEscape(s)
+	}
+	s = linkMarker.ReplaceAllStringFunc(s, func(m string) string {
+		var i int
+		fmt.Sscanf(strings.Trim(m, "\x00"), "%d", &i)
+		if i < len(links) {
+			return links[i]
+		}
+		return ""
+	})
+	s = blankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+// slackEscape escapes the three characters Slack treats as control sequences.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func slackLink(label, href string) string {
+	return "<" + strings.NewReplacer("|", "%7C", ">", "%3E").Replace(href) + "|" + label + ">"
+}
+
+func markdownLink(label, href string) string {
+	return "[" + label + "](" + strings.NewReplacer(")", "%29", " ", "%20").Replace(href) + ")"
+}
+
+// limit truncates s to at most n runes, marking the cut with an ellipsis.
+func limit(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
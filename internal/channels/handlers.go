@@ -0,0 +1,148 @@
+package channels
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/templates"
+	"gorm.io/gorm"
+)
+
+// render is a package-local helper for rendering Templ components in Gin handlers.
+// Duplicated from settings/handlers.go to avoid import cycles.
+func render(c *gin.Context, component templ.Component) {
+	c.Header("Content-Type", "text/html")
+	component.Render(c.Request.Context(), c.Writer)
+}
+
+// getAuthUser extracts the authenticated user from the Gin context and fetches
+// the full User record from the database.
+// Duplicated from settings/handlers.go to avoid import cycles.
+func getAuthUser(c *gin.Context, db *gorm.DB) (*models.User, error) {
+	emailVal, exists := c.Get("user_email")
+	if !exists {
+		return nil, fmt.Errorf("user_email not found in context")
+	}
+	emailStr, ok := emailVal.(string)
+	if !ok || emailStr == "" {
+		return nil, fmt.Errorf("user_email is empty")
+	}
+	var user models.User
+	if err := db.Where("email = ?", emailStr).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return &user, nil
+}
+
+// parseChannelID reads the :id route parameter.
+func parseChannelID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// PageHandler returns a Gin handler for GET /settings/channels.
+func PageHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+
+		vm := BuildViewModel(db, user.ID)
+		sidebarPlugins := dashboard.GetSidebarPlugins(db, user.ID)
+		render(c, templates.ChannelsSettingsPage(vm, sidebarPlugins))
+	}
+}
+
+// CreateHandler returns a Gin handler for POST /api/user/channels.
+// Registers a channel and returns the refreshed #channels-section fragment.
+func CreateHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		if err := CreateChannel(db, user.ID, c.PostForm("kind"), c.PostForm("name"), c.PostForm("webhook_url")); err != nil {
+			slog.Warn("channels: failed to create channel", "user_id", user.ID, "error", err)
+			vm := BuildViewModel(db, user.ID)
+			vm.Notice = err.Error()
+			vm.NoticeIsError = true
+			render(c, templates.ChannelsSection(vm))
+			return
+		}
+
+		vm := BuildViewModel(db, user.ID)
+		vm.Notice = "Channel added. Choose which plugins post to it under Settings → Plugins."
+		render(c, templates.ChannelsSection(vm))
+	}
+}
+
+// DeleteHandler returns a Gin handler for POST /api/user/channels/:id/delete.
+func DeleteHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		channelID, ok := parseChannelID(c)
+		if !ok {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if err := DeleteChannel(db, user.ID, channelID); err != nil {
+			slog.Error("channels: failed to delete channel", "user_id", user.ID, "channel_id", channelID, "error", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		render(c, templates.ChannelsSection(BuildViewModel(db, user.ID)))
+	}
+}
+
+// TestHandler returns a Gin handler for POST /api/user/channels/:id/test.
+// Posts a test message synchronously so the result can be shown immediately.
+func TestHandler(db *gorm.DB, sender *Sender, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		channelID, ok := parseChannelID(c)
+		if !ok {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		ch, err := GetChannel(db, user.ID, channelID)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		deliverErr := sender.Deliver(c.Request.Context(), ch, TestPost(baseURL))
+
+		vm := BuildViewModel(db, user.ID)
+		if deliverErr != nil {
+			vm.Notice = "Test message failed: " + deliverErr.Error()
+			vm.NoticeIsError = true
+		} else {
+			vm.Notice = "Test message sent."
+		}
+		render(c, templates.ChannelsSection(vm))
+	}
+}
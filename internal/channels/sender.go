@@ -0,0 +1,77 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/userwebhooks"
+)
+
+// deliveryTimeout bounds a single post to a chat webhook.
+const deliveryTimeout = 10 * time.Second
+
+// ErrPermanent wraps delivery failures that will not succeed on retry
+// (e.g. a revoked webhook returning 404, or a blocked destination).
+var ErrPermanent = errors.New("channels: permanent delivery failure")
+
+// Sender posts formatted messages to notification channels.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a Sender that uses the SSRF-safe client shared with user
+// webhooks. allowPrivateNetworks permits local destinations (development and
+// tests against a local HTTP stub).
+func NewSender(allowPrivateNetworks bool) *Sender {
+	return NewSenderWithClient(userwebhooks.NewSafeClient(allowPrivateNetworks, deliveryTimeout))
+}
+
+// NewSenderWithClient creates a Sender with a caller-supplied HTTP client.
+func NewSenderWithClient(client *http.Client) *Sender {
+	return &Sender{client: client}
+}
+
+// Deliver formats post for ch's platform and POSTs it to the channel webhook.
+// Returns an error wrapping ErrPermanent when retrying is pointless.
+func (s *Sender) Deliver(ctx context.Context, ch *models.NotificationChannel, post Post) error {
+	format, ok := formatters[ch.Kind]
+	if !ok {
+		return fmt.Errorf("%w: unknown channel kind %q", ErrPermanent, ch.Kind)
+	}
+	body, err := format(post)
+	if err != nil {
+		return fmt.Errorf("%w: format message: %v", ErrPermanent, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ch.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "First-Sip-Channels/1.0")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if errors.Is(err, userwebhooks.ErrBlockedAddress) {
+			return fmt.Errorf("%w: %v", ErrPermanent, err)
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("%s returned status %d: %s", ch.Kind, resp.StatusCode, respBody)
+	default:
+		return fmt.Errorf("%w: %s returned status %d: %s", ErrPermanent, ch.Kind, resp.StatusCode, respBody)
+	}
+}
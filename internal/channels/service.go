@@ -0,0 +1,201 @@
+package channels
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxChannelsPerUser caps how many channels a user can register.
+const MaxChannelsPerUser = 10
+
+// ValidateWebhookURL checks that rawURL is an https incoming-webhook URL for
+// kind. Slack and Discord URLs must point at the platform's webhook host;
+// Matrix (hookshot) is self-hosted, so any https URL is accepted.
+func ValidateWebhookURL(kind, rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" || u.Scheme != "https" {
+		return errors.New("enter a valid https webhook URL")
+	}
+	switch kind {
+	case models.ChannelKindSlack:
+		if u.Hostname() != "hooks.slack.com" {
+			return errors.New("Slack webhook URLs start with https://hooks.slack.com/")
+		}
+	case models.ChannelKindDiscord:
+		host := u.Hostname()
+		if (host != "discord.com" && host != "discordapp.com") || !strings.HasPrefix(u.Path, "/api/webhooks/") {
+			return errors.New("Discord webhook URLs start with https://discord.com/api/webhooks/")
+		}
+	case models.ChannelKindMatrix:
+	default:
+		return fmt.Errorf("unknown channel type %q", kind)
+	}
+	return nil
+}
+
+// CreateChannel validates and stores a new channel for userID.
+func CreateChannel(db *gorm.DB, userID uint, kind, name, rawURL string) error {
+	if err := ValidateWebhookURL(kind, rawURL); err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if len(name) > 100 {
+		return errors.New("name must be 100 characters or fewer")
+	}
+
+	var count int64
+	if err := db.Model(&models.NotificationChannel{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return fmt.Errorf("channels: count channels: %w", err)
+	}
+	if count >= MaxChannelsPerUser {
+		return fmt.Errorf("you can register at most %d channels", MaxChannelsPerUser)
+	}
+
+	ch := models.NotificationChannel{
+		UserID:     userID,
+		Kind:       kind,
+		Name:       name,
+		WebhookURL: strings.TrimSpace(rawURL),
+	}
+	if err := db.Create(&ch).Error; err != nil {
+		return fmt.Errorf("channels: create channel: %w", err)
+	}
+	return nil
+}
+
+// DeleteChannel soft-deletes a channel and its plugin routes.
+func DeleteChannel(db *gorm.DB, userID, channelID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND notification_channel_id = ?", userID, channelID).
+			Delete(&models.NotificationChannelPlugin{}).Error; err != nil {
+			return fmt.Errorf("channels: delete routes: %w", err)
+		}
+		if err := tx.Where("id = ? AND user_id = ?", channelID, userID).
+			Delete(&models.NotificationChannel{}).Error; err != nil {
+			return fmt.Errorf("channels: delete channel: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetChannel returns a channel by ID. userID scopes the lookup to the owning
+// user; pass 0 for internal lookups (e.g. the delivery worker).
+func GetChannel(db *gorm.DB, userID, channelID uint) (*models.NotificationChannel, error) {
+	q := db.Where("id = ?", channelID)
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	var ch models.NotificationChannel
+	if err := q.First(&ch).Error; err != nil {
+		return nil, fmt.Errorf("channels: fetch channel: %w", err)
+	}
+	return &ch, nil
+}
+
+// ListChannels returns all channels for a user, oldest first.
+func ListChannels(db *gorm.DB, userID uint) ([]models.NotificationChannel, error) {
+	var chs []models.NotificationChannel
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&chs).Error; err != nil {
+		return nil, fmt.Errorf("channels: list channels: %w", err)
+	}
+	return chs, nil
+}
+
+// PluginChannelIDs returns the IDs of the channels receiving pluginID's output.
+func PluginChannelIDs(db *gorm.DB, userID, pluginID uint) ([]uint, error) {
+	var ids []uint
+	if err := db.Model(&models.NotificationChannelPlugin{}).
+		Where("user_id = ? AND plugin_id = ?", userID, pluginID).
+		Pluck("notification_channel_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("channels: list plugin routes: %w", err)
+	}
+	return ids, nil
+}
+
+// ChannelsForPlugin returns the channels receiving pluginID's output.
+func ChannelsForPlugin(db *gorm.DB, userID, pluginID uint) ([]models.NotificationChannel, error) {
+	var chs []models.NotificationChannel
+	if err := db.Where("user_id = ? AND id IN (?)", userID,
+		db.Model(&models.NotificationChannelPlugin{}).
+			Select("notification_channel_id").
+			Where("user_id = ? AND plugin_id = ?", userID, pluginID),
+	).Find(&chs).Error; err != nil {
+		return nil, fmt.Errorf("channels: channels for plugin: %w", err)
+	}
+	return chs, nil
+}
+
+// SetPluginChannels replaces the set of channels receiving pluginID's output.
+// IDs not owned by userID are ignored.
+func SetPluginChannels(db *gorm.DB, userID, pluginID uint, channelIDs []uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var owned []uint
+		if len(channelIDs) > 0 {
+			if err := tx.Model(&models.NotificationChannel{}).
+				Where("user_id = ? AND id IN ?", userID, channelIDs).
+				Pluck("id", &owned).Error; err != nil {
+				return fmt.Errorf("channels: verify channels: %w", err)
+			}
+		}
+
+		del := tx.Where("user_id = ? AND plugin_id = ?", userID, pluginID)
+		if len(owned) > 0 {
+			del = del.Where("notification_channel_id NOT IN ?", owned)
+		}
+		if err := del.Delete(&models.NotificationChannelPlugin{}).Error; err != nil {
+			return fmt.Errorf("channels: clear routes: %w", err)
+		}
+
+		for _, id := range owned {
+			route := models.NotificationChannelPlugin{UserID: userID, NotificationChannelID: id, PluginID: pluginID}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&route).Error; err != nil {
+				return fmt.Errorf("channels: save route: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// PostFromRun builds a Post from a completed run. run.Plugin must be loaded.
+func PostFromRun(run *plugins.PluginRun, baseURL string) (Post, error) {
+	var out dashboard.PluginRunOutput
+	if err := json.Unmarshal(run.Output, &out); err != nil {
+		return Post{}, fmt.Errorf("channels: parse run output: %w", err)
+	}
+	title := plugins.HumanizeName(run.Plugin.Name)
+	if run.Plugin.Icon != "" {
+		title = run.Plugin.Icon + " " + title
+	}
+	post := Post{
+		Title:   title,
+		Summary: out.Summary,
+		URL:     strings.TrimRight(baseURL, "/") + "/plugins/" + run.Plugin.Name,
+	}
+	// Typed blocks (output v2) are flattened into the section HTML, which the
+	// formatters convert per platform.
+	for _, s := range out.Sections {
+		post.Sections = append(post.Sections, dashboard.OutputSection{Title: s.Title, Content: dashboard.SectionHTML(s)})
+	}
+	if len(post.Sections) == 0 && out.Content != "" {
+		post.Sections = []dashboard.OutputSection{{Content: out.Content}}
+	}
+	return post, nil
+}
+
+// TestPost is the message sent by the "Send test message" button.
+func TestPost(baseURL string) Post {
+	return Post{
+		Title:   "First Sip",
+		Summary: "This is a test message. Plugin results you route to this channel will appear here.",
+		URL:     strings.TrimRight(baseURL, "/") + "/dashboard",
+	}
+}
@@ -0,0 +1,72 @@
+package channels
+
+import (
+	"net/url"
+
+	"github.com/jimdaga/first-sip/internal/channelsvm"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/gorm"
+)
+
+// kindOptions describes each channel kind for the add form.
+var kindOptions = map[string]channelsvm.KindOption{
+	models.ChannelKindSlack:   {Kind: models.ChannelKindSlack, Label: "Slack", Placeholder: "https://hooks.slack.com/services/…"},
+	models.ChannelKindDiscord: {Kind: models.ChannelKindDiscord, Label: "Discord", Placeholder: "https://discord.com/api/webhooks/…"},
+	models.ChannelKindMatrix:  {Kind: models.ChannelKindMatrix, Label: "Matrix (hookshot)", Placeholder: "https://hookshot.example.org/webhook/…"},
+}
+
+// BuildViewModel constructs a ChannelsPageViewModel for the given user.
+func BuildViewModel(db *gorm.DB, userID uint) channelsvm.ChannelsPageViewModel {
+	chs, _ := ListChannels(db, userID)
+
+	vm := channelsvm.ChannelsPageViewModel{
+		Channels:    make([]channelsvm.ChannelViewModel, 0, len(chs)),
+		AtLimit:     len(chs) >= MaxChannelsPerUser,
+		MaxChannels: MaxChannelsPerUser,
+	}
+	for _, k := range models.ChannelKinds {
+		vm.KindOptions = append(vm.KindOptions, kindOptions[k])
+	}
+
+	type routeRow struct {
+		ChannelID  uint
+		PluginName string
+	}
+	var routes []routeRow
+	db.Raw(`
+		SELECT ncp.notification_channel_id AS channel_id, p.name AS plugin_name
+		FROM notification_channel_plugins ncp
+		JOIN plugins p ON p.id = ncp.plugin_id AND p.deleted_at IS NULL
+		WHERE ncp.user_id = ?
+		ORDER BY p.name ASC
+	`, userID).Scan(&routes)
+	pluginsByChannel := make(map[uint][]string)
+	for _, r := range routes {
+		pluginsByChannel[r.ChannelID] = append(pluginsByChannel[r.ChannelID], plugins.HumanizeName(r.PluginName))
+	}
+
+	for _, ch := range chs {
+		vm.Channels = append(vm.Channels, channelsvm.ChannelViewModel{
+			ID:        ch.ID,
+			Name:      ch.Name,
+			KindLabel: kindOptions[ch.Kind].Label,
+			MaskedURL: maskURL(ch.WebhookURL),
+			Plugins:   pluginsByChannel[ch.ID],
+		})
+	}
+	return vm
+}
+
+// maskURL reduces a webhook URL to scheme://host/… for display.
+func maskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	masked := u.Scheme + "://" + u.Host
+	if u.Path != "" && u.Path != "/" {
+		masked += "/…"
+	}
+	return masked
+}
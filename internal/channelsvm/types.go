@@ -0,0 +1,33 @@
+// Package channelsvm contains view model types for the chat channels settings page.
+// It is a leaf package (no internal imports) so that the templates package can
+// import it without creating an import cycle with the channels package.
+package channelsvm
+
+// ChannelViewModel is the display model for a registered channel.
+// The webhook URL is masked to scheme + host — the full URL is the credential.
+type ChannelViewModel struct {
+	ID        uint
+	Name      string
+	KindLabel string
+	MaskedURL string
+	Plugins   []string // display names of plugins routed to this channel
+}
+
+// KindOption is a selectable channel platform.
+type KindOption struct {
+	Kind        string
+	Label       string
+	Placeholder string
+}
+
+// ChannelsPageViewModel is the view model passed to the ChannelsSettingsPage template.
+type ChannelsPageViewModel struct {
+	Channels    []ChannelViewModel
+	KindOptions []KindOption
+	AtLimit     bool
+	MaxChannels int
+
+	// Notice is a one-off status message (e.g. test message result).
+	Notice        string
+	NoticeIsError bool
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 )
 
 // Config holds application configuration loaded from environment variables
@@ -22,6 +23,61 @@ type Config struct {
 	Env                string
 	Port               string
 	PluginDir          string
+
+	// N8NLegacySecretHeader keeps sending the shared secret in the deprecated
+	// X-N8N-SECRET header next to the request signature, for n8n workflows
+	// that have not moved to verifying signatures yet.
+	N8NLegacySecretHeader bool
+
+	// PluginHotReload watches PluginDir and reloads plugin manifests on change.
+	PluginHotReload bool
+
+	// PluginUnavailableGraceDays is how long schedules for a plugin removed
+	// from PluginDir are kept before they are paused.
+	PluginUnavailableGraceDays int
+
+	// PluginTrustedKeys lists publisher=base64-ed25519-public-key pairs whose
+	// signed bundles admins may install. The install API is off when empty.
+	PluginTrustedKeys string
+
+	// PluginBundleMaxBytes caps the size of an uploaded plugin bundle, both
+	// compressed and unpacked.
+	PluginBundleMaxBytes int64
+
+	// LegacyBriefingsEnabled keeps the n8n briefing:generate path (POST /api/briefings)
+	// active. Set LEGACY_BRIEFINGS_ENABLED=false once the daily-briefing plugin
+	// has reached parity to retire it.
+	LegacyBriefingsEnabled bool
+
+	// PluginQueueMaxDepth is the number of queued plugin requests at which the
+	// sidecar is considered saturated and new plugin:execute tasks are deferred.
+	PluginQueueMaxDepth int64
+
+	// HistoryLookbackDays is the default period shown on the history timeline
+	// and included in private feeds.
+	HistoryLookbackDays int
+
+	// UserWebhooksAllowPrivateNetworks permits user webhook deliveries to
+	// loopback and private addresses. Only enable for local development.
+	UserWebhooksAllowPrivateNetworks bool
+
+	// AppBaseURL is the externally reachable base URL used for links in emails.
+	AppBaseURL string
+
+	// Email digest transport. SMTP is used when SMTPHost is set; otherwise, when
+	// EmailSinkDir is set, messages are written there as .eml files (local dev/tests).
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	EmailFrom    string
+	EmailSinkDir string
+
+	// Web Push (VAPID) key pair, base64url-encoded; generate with
+	// -generate-vapid-keys. Push notifications are disabled when unset.
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	VAPIDSubject    string
 }
 
 // Load reads configuration from environment variables
@@ -42,6 +98,31 @@ func Load() *Config {
 		Env:                getEnvWithDefault("ENV", "development"),
 		Port:               getEnvWithDefault("PORT", "8080"),
 		PluginDir:          getEnvWithDefault("PLUGIN_DIR", "./plugins"),
+		PluginHotReload:    getEnvBoolWithDefault("PLUGIN_HOT_RELOAD", true),
+
+		PluginUnavailableGraceDays: int(getEnvInt64WithDefault("PLUGIN_UNAVAILABLE_GRACE_DAYS", 7)),
+
+		PluginTrustedKeys:    os.Getenv("PLUGIN_TRUSTED_KEYS"),
+		PluginBundleMaxBytes: getEnvInt64WithDefault("PLUGIN_BUNDLE_MAX_BYTES", 20<<20),
+
+		LegacyBriefingsEnabled: getEnvBoolWithDefault("LEGACY_BRIEFINGS_ENABLED", true),
+		N8NLegacySecretHeader:  getEnvBoolWithDefault("N8N_LEGACY_SECRET_HEADER", true),
+		PluginQueueMaxDepth:    getEnvInt64WithDefault("PLUGIN_QUEUE_MAX_DEPTH", 1000),
+		HistoryLookbackDays:    int(getEnvInt64WithDefault("HISTORY_LOOKBACK_DAYS", 30)),
+
+		UserWebhooksAllowPrivateNetworks: getEnvBoolWithDefault("USER_WEBHOOKS_ALLOW_PRIVATE_NETWORKS", false),
+
+		AppBaseURL:   getEnvWithDefault("APP_BASE_URL", "http://localhost:8080"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     getEnvWithDefault("SMTP_PORT", "587"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		EmailFrom:    getEnvWithDefault("EMAIL_FROM", "First Sip <briefing@localhost>"),
+		EmailSinkDir: os.Getenv("EMAIL_SINK_DIR"),
+
+		VAPIDPublicKey:  os.Getenv("VAPID_PUBLIC_KEY"),
+		VAPIDPrivateKey: os.Getenv("VAPID_PRIVATE_KEY"),
+		VAPIDSubject:    getEnvWithDefault("VAPID_SUBJECT", "mailto:admin@localhost"),
 	}
 
 	// Warn if using default session secret (insecure for production)
@@ -74,6 +155,21 @@ func Load() *Config {
 		log.Println("WARNING: REDIS_URL not set. Background job features will be unavailable.")
 	}
 
+	if cfg.PluginUnavailableGraceDays < 0 || cfg.PluginUnavailableGraceDays > 365 {
+		log.Printf("WARNING: PLUGIN_UNAVAILABLE_GRACE_DAYS=%d is out of range (0-365), using 7", cfg.PluginUnavailableGraceDays)
+		cfg.PluginUnavailableGraceDays = 7
+	}
+
+	if cfg.PluginBundleMaxBytes < 1<<10 || cfg.PluginBundleMaxBytes > 1<<30 {
+		log.Printf("WARNING: PLUGIN_BUNDLE_MAX_BYTES=%d is out of range (1KiB-1GiB), using 20MiB", cfg.PluginBundleMaxBytes)
+		cfg.PluginBundleMaxBytes = 20 << 20
+	}
+
+	if cfg.HistoryLookbackDays < 1 || cfg.HistoryLookbackDays > 3650 {
+		log.Printf("WARNING: HISTORY_LOOKBACK_DAYS=%d is out of range (1-3650), using 30", cfg.HistoryLookbackDays)
+		cfg.HistoryLookbackDays = 30
+	}
+
 	// Force JSON logging in production
 	if cfg.Env == "production" && cfg.LogFormat == "text" {
 		cfg.LogFormat = "json"
@@ -89,6 +185,27 @@ func getEnvWithDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvInt64WithDefault(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("WARNING: %s=%q is not a valid integer, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvBoolWithDefault(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value == "true" || value == "1"
+}
+
 func parseStubMode(value string) bool {
 	return value == "true" || value == "1"
 }
@@ -0,0 +1,139 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/rundiff"
+	"gorm.io/gorm"
+)
+
+// previousSuccessfulRunRow is an intermediate type for scanning the
+// second-latest completed run per plugin.
+type previousSuccessfulRunRow struct {
+	PluginID uint
+	Output   []byte
+}
+
+// outputSections parses plugin_runs.output into diffable sections. Legacy
+// single-content outputs become one untitled section.
+func outputSections(output []byte) []rundiff.Section {
+	if len(output) == 0 {
+		return nil
+	}
+	var out PluginRunOutput
+	if err := json.Unmarshal(output, &out); err != nil {
+		return nil
+	}
+	if len(out.Sections) == 0 {
+		if out.Content == "" {
+			return nil
+		}
+		return []rundiff.Section{{Content: out.Content}}
+	}
+	sections := make([]rundiff.Section, len(out.Sections))
+	for i, s := range out.Sections {
+		sections[i] = rundiff.Section{Title: s.Title, Content: s.Text()}
+	}
+	return sections
+}
+
+// compareOutputs diffs two plugin_runs.output documents section by section.
+func compareOutputs(prev, curr []byte) rundiff.Diff {
+	return rundiff.Compare(outputSections(prev), outputSections(curr))
+}
+
+// previousSuccessfulOutputs returns, per plugin, the output of the completed
+// run before the latest completed one. Plugins with fewer than two completed
+// runs are absent from the map.
+func previousSuccessfulOutputs(db *gorm.DB, userID uint, pluginIDs []uint) (map[uint][]byte, error) {
+	if len(pluginIDs) == 0 {
+		return map[uint][]byte{}, nil
+	}
+	var rows []previousSuccessfulRunRow
+	err := db.Raw(`
+		SELECT plugin_id, output
+		FROM (
+			SELECT
+				plugin_id,
+				output,
+				ROW_NUMBER() OVER (PARTITION BY plugin_id ORDER BY created_at DESC) AS rn
+			FROM plugin_runs
+			WHERE user_id = ?
+			  AND plugin_id IN ?
+			  AND status = 'completed'
+			  AND deleted_at IS NULL
+		) ranked
+		WHERE rn = 2
+	`, userID, pluginIDs).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("dashboard: query previous successful runs: %w", err)
+	}
+	prev := make(map[uint][]byte, len(rows))
+	for _, r := range rows {
+		prev[r.PluginID] = r.Output
+	}
+	return prev, nil
+}
+
+// applyWhatsNew fills the tile's what's-new fields when the mode is on and
+// the latest run completed. latest is that run's output.
+func applyWhatsNew(tile *TileViewModel, latest []byte, prev map[uint][]byte) {
+	if !tile.WhatsNewOnly || tile.LatestRunStatus != plugins.PluginRunStatusCompleted {
+		return
+	}
+	prevOutput, ok := prev[tile.PluginID]
+	if !ok {
+		return
+	}
+	tile.HasPreviousRun = true
+	tile.WhatsNew = compareOutputs(prevOutput, latest).OnlyNew()
+}
+
+// loadRunComparison diffs a completed run against the completed run before
+// it. runID selects the run; empty means the latest completed run. Returns
+// nil when the plugin has no completed run (or runID does not match one).
+func loadRunComparison(db *gorm.DB, userID, pluginID uint, runID string, loc *time.Location) (*rundiff.Comparison, error) {
+	q := db.Where("user_id = ? AND plugin_id = ? AND status = ?", userID, pluginID, plugins.PluginRunStatusCompleted)
+	if runID != "" {
+		q = q.Where("plugin_run_id = ?", runID)
+	}
+	var curr []plugins.PluginRun
+	if err := q.Order("created_at DESC").Limit(1).Find(&curr).Error; err != nil {
+		return nil, fmt.Errorf("dashboard: query run for comparison: %w", err)
+	}
+	if len(curr) == 0 {
+		return nil, nil
+	}
+
+	var prev []plugins.PluginRun
+	if err := db.Where("user_id = ? AND plugin_id = ? AND status = ? AND created_at < ?",
+		userID, pluginID, plugins.PluginRunStatusCompleted, curr[0].CreatedAt).
+		Order("created_at DESC").Limit(1).Find(&prev).Error; err != nil {
+		return nil, fmt.Errorf("dashboard: query previous run for comparison: %w", err)
+	}
+
+	cmp := &rundiff.Comparison{
+		CurrentRunID: curr[0].PluginRunID,
+		CurrentLabel: runLabel(curr[0], loc),
+	}
+	var prevOutput []byte
+	if len(prev) > 0 {
+		cmp.PreviousRunID = prev[0].PluginRunID
+		cmp.PreviousLabel = runLabel(prev[0], loc)
+		prevOutput = prev[0].Output
+	}
+	cmp.Diff = compareOutputs(prevOutput, curr[0].Output)
+	return cmp, nil
+}
+
+// runLabel formats when a run completed, in the user's timezone.
+func runLabel(run plugins.PluginRun, loc *time.Location) string {
+	completed := run.CreatedAt
+	if run.CompletedAt != nil {
+		completed = *run.CompletedAt
+	}
+	return completed.In(loc).Format("January 2, 2006 at 3:04 PM")
+}
@@ -0,0 +1,27 @@
+package dashboard
+
+import "github.com/jimdaga/first-sip/internal/plugins"
+
+// FixtureTile builds the tile getDashboardTiles would for run as the user's
+// only run, leaving out timestamps so snapshots are stable. It is exported
+// for fixtures_test.go, which is an external test package because plugintest
+// imports the worker and, through it, this package.
+func FixtureTile(meta *plugins.PluginMetadata, run *plugins.PluginRun) TileViewModel {
+	tile := TileViewModel{
+		PluginID:        run.PluginID,
+		PluginName:      meta.Name,
+		DisplayName:     plugins.HumanizeName(meta.Name),
+		PluginIcon:      meta.Icon,
+		TileSize:        meta.TileSize,
+		Enabled:         true,
+		LatestRunStatus: run.Status,
+		BriefingSummary: extractSummary(run.Output),
+		BriefingContent: extractContent(run.Output),
+		HasContent:      run.Status == plugins.PluginRunStatusCompleted,
+		HasError:        isFailedRunStatus(run.Status),
+	}
+	if tile.TileSize == "" {
+		tile.TileSize = "1x1"
+	}
+	return tile
+}
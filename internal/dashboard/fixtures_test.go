@@ -0,0 +1,119 @@
+package dashboard_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/plugintest"
+	"github.com/jimdaga/first-sip/internal/templates"
+)
+
+var update = flag.Bool("update", false, "rewrite plugin fixture snapshots")
+
+// pluginsDir is the repository's plugin directory, relative to this package.
+const pluginsDir = "../../plugins"
+
+// TestPluginFixtures replays every plugin's recorded results (see package
+// plugintest) and compares the rendered tile and Latest tab with the
+// snapshots next to the fixtures. Run with -update after an intended change.
+func TestPluginFixtures(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join(pluginsDir, "*", "plugin.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, manifest := range dirs {
+		dir := filepath.Dir(manifest)
+		fixtures, err := plugintest.LoadFixtures(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fixtures) == 0 {
+			continue
+		}
+		meta, err := plugins.LoadPluginMetadata(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, f := range fixtures {
+			t.Run(meta.Name+"/"+f.Name, func(t *testing.T) {
+				db, err := plugintest.OpenDB()
+				if err != nil {
+					t.Fatal(err)
+				}
+				run, req, err := plugintest.Run(db, meta, 1, f)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if req.PluginName != meta.Name || req.PluginRunID != run.PluginRunID {
+					t.Errorf("request = %s/%s, want %s/%s", req.PluginName, req.PluginRunID, meta.Name, run.PluginRunID)
+				}
+				for k, v := range f.Settings {
+					if got, ok := req.Settings[k]; !ok || fmt.Sprint(got) != fmt.Sprint(v) {
+						t.Errorf("request setting %s = %v, want %v", k, got, v)
+					}
+				}
+				for _, secret := range meta.DeclaredSecrets() {
+					if got := req.Settings["_"+secret+"_api_key"]; got != plugintest.SecretValue {
+						t.Errorf("request %s key = %v, want the user's key injected", secret, got)
+					}
+				}
+				if run.Status != f.Status {
+					t.Errorf("run status = %q (%s), want %q", run.Status, run.ErrorMessage, f.Status)
+				}
+
+				tile := dashboard.FixtureTile(meta, run)
+				checkSnapshot(t, filepath.Join(dir, plugintest.SnapshotsDir, f.Name+".tile.html"), templates.TileCard(tile))
+				checkSnapshot(t, filepath.Join(dir, plugintest.SnapshotsDir, f.Name+".detail.html"), templates.PluginDetailLatest(tile))
+
+				// Another user with the same settings is served from this run.
+				if meta.SharesResults() && run.Status == plugins.PluginRunStatusCompleted {
+					shared, sharedReq, err := plugintest.Run(db, meta, 2, f)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if sharedReq.PluginRunID != "" || shared.SharedFromRunID != run.PluginRunID ||
+						shared.Status != run.Status || string(shared.Output) != string(run.Output) {
+						t.Errorf("second user's run = %s from %q (executed: %v), want completed from %s",
+							shared.Status, shared.SharedFromRunID, sharedReq.PluginRunID != "", run.PluginRunID)
+					}
+				}
+			})
+		}
+	}
+}
+
+func checkSnapshot(t *testing.T, path string, c templ.Component) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := c.Render(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.Bytes()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match the rendered HTML (run with -update if the change is intended)\ngot:\n%s", path, got)
+	}
+}
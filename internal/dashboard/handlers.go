@@ -1,15 +1,20 @@
 package dashboard
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/a-h/templ"
 	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/history"
+	"github.com/jimdaga/first-sip/internal/historyvm"
 	"github.com/jimdaga/first-sip/internal/models"
 	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/streams"
 	"github.com/jimdaga/first-sip/internal/templates"
 	"gorm.io/gorm"
 )
@@ -48,11 +53,30 @@ func formatDashboardDate(timezone string) string {
 	return time.Now().In(loc).Format("Monday, January 2, 2006")
 }
 
+// runsDelayed reports whether the plugin request queue is saturated, meaning new
+// runs are being deferred. A nil publisher or a failed depth check reports false
+// so the banner never shows spuriously. Saturated reuses a reading from the last
+// few seconds, so rendering the dashboard does not query Redis every time.
+func runsDelayed(ctx context.Context, publisher *streams.Publisher) bool {
+	if publisher == nil {
+		return false
+	}
+	saturated, _, err := publisher.Saturated(ctx)
+	if err != nil {
+		slog.Warn("dashboard: failed to check plugin request queue depth", "error", err)
+		return false
+	}
+	return saturated
+}
+
 // DashboardHandler returns a Gin handler for GET /dashboard.
 // It fetches enabled plugin tiles for the authenticated user and renders the
 // tile-based dashboard page with a time-aware greeting and current date.
-func DashboardHandler(db *gorm.DB) gin.HandlerFunc {
+// When the plugin request queue is saturated, a banner tells the user runs are delayed.
+func DashboardHandler(db *gorm.DB, publisher *streams.Publisher, unavailableGraceDays int) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		delayed := runsDelayed(c.Request.Context(), publisher)
+
 		user, err := getAuthUser(c, db)
 		if err != nil {
 			// Fall back gracefully if DB lookup fails.
@@ -63,30 +87,30 @@ func DashboardHandler(db *gorm.DB) gin.HandlerFunc {
 			}
 			greeting := timeAwareGreeting(nameStr, "UTC")
 			date := formatDashboardDate("UTC")
-			render(c, templates.DashboardPage(greeting, date, []TileViewModel{}, false, nil))
+			render(c, templates.DashboardPage(greeting, date, []TileViewModel{}, false, delayed, nil))
 			return
 		}
 
-		tiles, err := getDashboardTiles(db, user.ID)
+		tiles, err := getDashboardTiles(db, user.ID, unavailableGraceDays)
 		if err != nil {
 			// On query error, render with no tiles rather than a 500 page.
 			sidebarPlugins := GetSidebarPlugins(db, user.ID)
 			greeting := timeAwareGreeting(user.Name, user.Timezone)
 			date := formatDashboardDate(user.Timezone)
-			render(c, templates.DashboardPage(greeting, date, []TileViewModel{}, false, sidebarPlugins))
+			render(c, templates.DashboardPage(greeting, date, []TileViewModel{}, false, delayed, sidebarPlugins))
 			return
 		}
 
 		sidebarPlugins := GetSidebarPlugins(db, user.ID)
 		greeting := timeAwareGreeting(user.Name, user.Timezone)
 		date := formatDashboardDate(user.Timezone)
-		render(c, templates.DashboardPage(greeting, date, tiles, len(tiles) > 0, sidebarPlugins))
+		render(c, templates.DashboardPage(greeting, date, tiles, len(tiles) > 0, delayed, sidebarPlugins))
 	}
 }
 
 // TileStatusHandler returns a Gin handler for GET /api/tiles/:pluginID.
 // Used by HTMX polling to refresh a single tile's HTML fragment (outerHTML swap).
-func TileStatusHandler(db *gorm.DB) gin.HandlerFunc {
+func TileStatusHandler(db *gorm.DB, unavailableGraceDays int) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, err := getAuthUser(c, db)
 		if err != nil {
@@ -101,7 +125,7 @@ func TileStatusHandler(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		tile, err := GetSingleTile(db, user.ID, uint(pluginIDParsed))
+		tile, err := GetSingleTile(db, user.ID, uint(pluginIDParsed), unavailableGraceDays)
 		if err != nil || tile == nil {
 			c.Status(http.StatusInternalServerError)
 			return
@@ -145,8 +169,10 @@ func UpdateTimezoneHandler(db *gorm.DB) gin.HandlerFunc {
 }
 
 // PluginDetailHandler returns a Gin handler for GET /plugins/:pluginName.
-// It renders the full-page plugin detail view with the latest briefing content.
-func PluginDetailHandler(db *gorm.DB) gin.HandlerFunc {
+// It renders the full-page plugin detail view with the latest briefing content,
+// a specific past run (?run=), the changes since the previous run
+// (?tab=changes, optionally with ?run=), or the plugin's history (?tab=history).
+func PluginDetailHandler(db *gorm.DB, lookbackDays, unavailableGraceDays int) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, err := getAuthUser(c, db)
 		if err != nil {
@@ -165,14 +191,61 @@ func PluginDetailHandler(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		tile, err := GetSingleTile(db, user.ID, pluginRow.ID)
+		tile, err := GetSingleTile(db, user.ID, pluginRow.ID, unavailableGraceDays)
 		if err != nil || tile == nil {
 			c.Redirect(http.StatusFound, "/dashboard")
 			return
 		}
 
+		loc, err := time.LoadLocation(user.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		tab := c.Query("tab")
+
+		// Changes tab: diff a run (default: the latest completed) against the
+		// completed run before it.
+		if tab == "changes" {
+			comparison, err := loadRunComparison(db, user.ID, pluginRow.ID, c.Query("run"), loc)
+			if err != nil {
+				slog.Error("dashboard: failed to compare runs", "plugin", pluginName, "user_id", user.ID, "error", err)
+			}
+			sidebarPlugins := GetSidebarPlugins(db, user.ID)
+			render(c, templates.PluginDetailPage(*tile, historyvm.Timeline{}, nil, comparison, tab, sidebarPlugins))
+			return
+		}
+
+		// A past run picked from the History tab (?run=<plugin_run_id>).
+		var selected *historyvm.RunView
+		if runID := c.Query("run"); runID != "" {
+			var run plugins.PluginRun
+			if err := db.Where("plugin_run_id = ? AND user_id = ? AND plugin_id = ? AND status = ?",
+				runID, user.ID, pluginRow.ID, plugins.PluginRunStatusCompleted).First(&run).Error; err == nil {
+				selected = &historyvm.RunView{
+					PluginRunID: run.PluginRunID,
+					DateLabel:   runLabel(run, loc),
+					Content:     extractContent(run.Output),
+					Version:     run.PluginVersion,
+				}
+			}
+		}
+
+		q := history.Query{
+			UserID:       user.ID,
+			PluginName:   pluginName,
+			LookbackDays: history.ParseLookback(c.Query("days"), lookbackDays),
+		}
+		timeline, err := history.Load(db, q, loc, time.Now(), "/api/history", "", lookbackDays)
+		if err != nil {
+			slog.Error("dashboard: failed to load plugin history", "plugin", pluginName, "user_id", user.ID, "error", err)
+		}
+
+		if tab != "history" || selected != nil {
+			tab = "latest"
+		}
 		sidebarPlugins := GetSidebarPlugins(db, user.ID)
-		render(c, templates.PluginDetailPage(*tile, sidebarPlugins))
+		render(c, templates.PluginDetailPage(*tile, timeline, selected, nil, tab, sidebarPlugins))
 	}
 }
 
@@ -0,0 +1,33 @@
+package dashboard
+
+import (
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/plugins"
+)
+
+// applyLifecycle fills the tile's removal and deprecation notices. An
+// unavailable plugin never runs, so its tile shows no next run; its schedule
+// is paused unavailableGraceDays after it went missing.
+func applyLifecycle(tile *TileViewModel, cfg configRow, unavailableGraceDays int) {
+	tile.Deprecated = cfg.Deprecated
+	if cfg.ReplacedBy != "" {
+		tile.ReplacedBy = plugins.HumanizeName(cfg.ReplacedBy)
+	}
+	if cfg.PluginStatus != plugins.PluginStatusUnavailable {
+		return
+	}
+
+	tile.Unavailable = true
+	tile.NextRunAt = nil
+	if cfg.UnavailableSince == nil {
+		return
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	off := plugins.ScheduleDisableAt(*cfg.UnavailableSince, time.Duration(unavailableGraceDays)*24*time.Hour)
+	tile.ScheduleOff = cfg.CronExpression == "" || cfg.SchedulePaused || !off.After(time.Now())
+	tile.ScheduleOffLabel = off.In(loc).Format("January 2")
+}
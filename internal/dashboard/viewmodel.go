@@ -1,6 +1,7 @@
 package dashboard
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -8,6 +9,8 @@ import (
 	"time"
 
 	cron "github.com/robfig/cron/v3"
+	"github.com/jimdaga/first-sip/internal/pluginoutput"
+	"github.com/jimdaga/first-sip/internal/plugins"
 	"github.com/jimdaga/first-sip/internal/templates"
 	"github.com/jimdaga/first-sip/internal/tiles"
 	"gorm.io/gorm"
@@ -21,18 +24,13 @@ var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month
 // internal/dashboard (query layer) and internal/templates (render layer).
 type TileViewModel = tiles.TileViewModel
 
-// OutputSection is a titled section within a plugin run's output.
-type OutputSection struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
-}
+// OutputSection is an alias for pluginoutput.Section: a titled section within
+// a plugin run's output, with optional typed blocks (output v2).
+type OutputSection = pluginoutput.Section
 
-// PluginRunOutput is the JSONB structure stored in plugin_runs.output.
-type PluginRunOutput struct {
-	Summary  string          `json:"summary"`
-	Sections []OutputSection `json:"sections"`
-	Content  string          `json:"content"` // legacy — old completed runs only
-}
+// PluginRunOutput is an alias for pluginoutput.Output, the JSONB structure
+// stored in plugin_runs.output.
+type PluginRunOutput = pluginoutput.Output
 
 // configRow is an intermediate type for scanning the configs query result.
 type configRow struct {
@@ -43,6 +41,13 @@ type configRow struct {
 	DisplayOrder   *int
 	CronExpression string
 	Timezone       string
+	WhatsNewOnly   bool
+	SchedulePaused bool
+
+	PluginStatus     string
+	UnavailableSince *time.Time
+	Deprecated       bool
+	ReplacedBy       string
 }
 
 // latestRunRow is an intermediate type for scanning the latest runs query result.
@@ -61,13 +66,16 @@ type latestSuccessfulRunRow struct {
 }
 
 // getDashboardTiles fetches all enabled plugin configs for the user and assembles
-// TileViewModels with the latest run data. Uses exactly three queries:
+// TileViewModels with the latest run data. Uses three queries:
 //  1. Enabled plugin configs joined with plugins table.
 //  2. DISTINCT ON (plugin_id) latest plugin runs for this user (any status).
 //  3. DISTINCT ON (plugin_id) latest successful (completed) plugin runs.
 //
+// plus a fourth for the previous completed run when any tile is in
+// "only show what's new" mode.
+//
 // No N+1 — last-successful lookup is map-based from the batch query.
-func getDashboardTiles(db *gorm.DB, userID uint) ([]TileViewModel, error) {
+func getDashboardTiles(db *gorm.DB, userID uint, unavailableGraceDays int) ([]TileViewModel, error) {
 	// --- Query 1: Enabled plugin configs ordered by display_order ---
 	var configs []configRow
 	err := db.Raw(`
@@ -78,7 +86,13 @@ func getDashboardTiles(db *gorm.DB, userID uint) ([]TileViewModel, error) {
 			p.tile_size,
 			upc.display_order,
 			upc.cron_expression,
-			COALESCE(u.timezone, 'UTC') AS timezone
+			COALESCE(u.timezone, 'UTC') AS timezone,
+			upc.whats_new_only,
+			upc.schedule_paused,
+			p.status    AS plugin_status,
+			p.unavailable_since,
+			p.deprecated,
+			p.replaced_by
 		FROM user_plugin_configs upc
 		JOIN plugins p ON p.id = upc.plugin_id
 		JOIN users u ON u.id = upc.user_id
@@ -142,17 +156,30 @@ func getDashboardTiles(db *gorm.DB, userID uint) ([]TileViewModel, error) {
 		latestSuccessMap[r.PluginID] = r
 	}
 
+	// --- Query 4 (what's-new tiles only): previous completed run per plugin ---
+	var whatsNewIDs []uint
+	for _, cfg := range configs {
+		if cfg.WhatsNewOnly {
+			whatsNewIDs = append(whatsNewIDs, cfg.PluginID)
+		}
+	}
+	prevOutputs, err := previousSuccessfulOutputs(db, userID, whatsNewIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	// --- Assemble TileViewModels ---
 	tiles := make([]TileViewModel, 0, len(configs))
 	for _, cfg := range configs {
 		tile := TileViewModel{
 			PluginID:     cfg.PluginID,
 			PluginName:   cfg.PluginName,
-			DisplayName:  humanizePluginName(cfg.PluginName),
+			DisplayName:  plugins.HumanizeName(cfg.PluginName),
 			PluginIcon:   cfg.Icon,
 			TileSize:     cfg.TileSize,
 			DisplayOrder: cfg.DisplayOrder,
 			Enabled:      true,
+			WhatsNewOnly: cfg.WhatsNewOnly,
 		}
 
 		if tile.TileSize == "" {
@@ -170,7 +197,7 @@ func getDashboardTiles(db *gorm.DB, userID uint) ([]TileViewModel, error) {
 				tile.HasContent = true
 			}
 
-			if run.Status == "failed" {
+			if isFailedRunStatus(run.Status) {
 				tile.HasError = true
 				// O(1) lookup for last successful run — no per-row query.
 				if successRun, ok := latestSuccessMap[cfg.PluginID]; ok {
@@ -181,10 +208,12 @@ func getDashboardTiles(db *gorm.DB, userID uint) ([]TileViewModel, error) {
 					}
 				}
 			}
+			applyWhatsNew(&tile, run.Output, prevOutputs)
 		}
 
 		// Compute next scheduled run.
 		tile.NextRunAt = computeNextRun(cfg.CronExpression, cfg.Timezone)
+		applyLifecycle(&tile, cfg, unavailableGraceDays)
 
 		// Format timing tooltip.
 		tile.TimingTooltip = formatTimingTooltip(tile)
@@ -197,7 +226,7 @@ func getDashboardTiles(db *gorm.DB, userID uint) ([]TileViewModel, error) {
 
 // GetSingleTile fetches a single plugin's tile data for HTMX per-tile polling.
 // Uses the same three-query batch logic as getDashboardTiles but filtered to one plugin.
-func GetSingleTile(db *gorm.DB, userID, pluginID uint) (*TileViewModel, error) {
+func GetSingleTile(db *gorm.DB, userID, pluginID uint, unavailableGraceDays int) (*TileViewModel, error) {
 	// Query config for this specific plugin.
 	var configs []configRow
 	err := db.Raw(`
@@ -208,7 +237,13 @@ func GetSingleTile(db *gorm.DB, userID, pluginID uint) (*TileViewModel, error) {
 			p.tile_size,
 			upc.display_order,
 			upc.cron_expression,
-			COALESCE(u.timezone, 'UTC') AS timezone
+			COALESCE(u.timezone, 'UTC') AS timezone,
+			upc.whats_new_only,
+			upc.schedule_paused,
+			p.status    AS plugin_status,
+			p.unavailable_since,
+			p.deprecated,
+			p.replaced_by
 		FROM user_plugin_configs upc
 		JOIN plugins p ON p.id = upc.plugin_id
 		JOIN users u ON u.id = upc.user_id
@@ -265,11 +300,12 @@ func GetSingleTile(db *gorm.DB, userID, pluginID uint) (*TileViewModel, error) {
 	tile := &TileViewModel{
 		PluginID:     cfg.PluginID,
 		PluginName:   cfg.PluginName,
-		DisplayName:  humanizePluginName(cfg.PluginName),
+		DisplayName:  plugins.HumanizeName(cfg.PluginName),
 		PluginIcon:   cfg.Icon,
 		TileSize:     cfg.TileSize,
 		DisplayOrder: cfg.DisplayOrder,
 		Enabled:      true,
+		WhatsNewOnly: cfg.WhatsNewOnly,
 	}
 	if tile.TileSize == "" {
 		tile.TileSize = "1x1"
@@ -285,7 +321,7 @@ func GetSingleTile(db *gorm.DB, userID, pluginID uint) (*TileViewModel, error) {
 		if run.Status == "completed" {
 			tile.HasContent = true
 		}
-		if run.Status == "failed" {
+		if isFailedRunStatus(run.Status) {
 			tile.HasError = true
 			if len(latestSuccessRuns) > 0 {
 				tile.LastSuccessfulSummary = extractSummary(latestSuccessRuns[0].Output)
@@ -295,14 +331,31 @@ func GetSingleTile(db *gorm.DB, userID, pluginID uint) (*TileViewModel, error) {
 				}
 			}
 		}
+
+		if cfg.WhatsNewOnly {
+			prevOutputs, err := previousSuccessfulOutputs(db, userID, []uint{pluginID})
+			if err != nil {
+				return nil, err
+			}
+			applyWhatsNew(tile, run.Output, prevOutputs)
+		}
 	}
 
 	tile.NextRunAt = computeNextRun(cfg.CronExpression, cfg.Timezone)
+	applyLifecycle(tile, cfg, unavailableGraceDays)
 	tile.TimingTooltip = formatTimingTooltip(*tile)
 
 	return tile, nil
 }
 
+// isFailedRunStatus reports whether a run ended without output — failed outright,
+// or timed out / cancelled by the PluginRun state machine.
+func isFailedRunStatus(status string) bool {
+	return status == plugins.PluginRunStatusFailed ||
+		status == plugins.PluginRunStatusTimedOut ||
+		status == plugins.PluginRunStatusCancelled
+}
+
 // computeNextRun parses a 5-field cron expression and returns the next scheduled
 // time in the given IANA timezone. Returns nil if cronExpr is empty or invalid.
 func computeNextRun(cronExpr, timezone string) *time.Time {
@@ -343,7 +396,8 @@ func extractSummary(output []byte) string {
 }
 
 // extractContent parses the JSONB output and returns HTML for tile display.
-// New format: renders sections array as HTML (<h3> headings + <p> content).
+// New format: renders sections array as HTML (<h3> headings + <p> content,
+// followed by any typed blocks via SectionHTML).
 // Legacy format: falls back to the Content string field for old completed runs.
 // Malformed/unparseable output returns "" so old runs show as empty (no crash).
 func extractContent(output []byte) string {
@@ -363,9 +417,12 @@ func extractContent(output []byte) string {
 				sb.WriteString(template.HTMLEscapeString(s.Title))
 				sb.WriteString("</h3>")
 			}
-			sb.WriteString("<p>")
-			sb.WriteString(s.Content)
-			sb.WriteString("</p>")
+			if s.Content != "" || len(s.Blocks) == 0 {
+				sb.WriteString("<p>")
+				sb.WriteString(s.Content)
+				sb.WriteString("</p>")
+			}
+			sb.WriteString(blocksHTML(s.Blocks))
 		}
 		return sb.String()
 	}
@@ -373,6 +430,25 @@ func extractContent(output []byte) string {
 	return out.Content
 }
 
+// SectionHTML returns a section's HTML body: its content followed by its typed
+// blocks, rendered with the templates.OutputBlocks component. Used by other
+// renderers (feeds, channels, digest) that lay out sections themselves.
+func SectionHTML(s OutputSection) string {
+	return s.Content + blocksHTML(s.Blocks)
+}
+
+// blocksHTML renders typed output blocks to an HTML string.
+func blocksHTML(blocks []pluginoutput.Block) string {
+	if len(blocks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	if err := templates.OutputBlocks(blocks).Render(context.Background(), &sb); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
 // timeAwareGreeting returns a time-appropriate greeting based on the user's timezone.
 func timeAwareGreeting(name, timezone string) string {
 	loc, err := time.LoadLocation(timezone)
@@ -472,21 +548,9 @@ func GetSidebarPlugins(db *gorm.DB, userID uint) []templates.SidebarPlugin {
 	for _, r := range rows {
 		result = append(result, templates.SidebarPlugin{
 			PluginName:  r.Name,
-			DisplayName: humanizePluginName(r.Name),
+			DisplayName: plugins.HumanizeName(r.Name),
 			Icon:        r.Icon,
 		})
 	}
 	return result
 }
-
-// humanizePluginName converts a kebab-case plugin name to title case.
-// E.g. "daily-news-digest" → "Daily News Digest".
-func humanizePluginName(name string) string {
-	words := strings.Split(name, "-")
-	for i, w := range words {
-		if len(w) > 0 {
-			words[i] = strings.ToUpper(w[:1]) + w[1:]
-		}
-	}
-	return strings.Join(words, " ")
-}
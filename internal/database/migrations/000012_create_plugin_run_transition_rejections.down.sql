@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_plugin_runs_processing_started_at;
+DROP TABLE IF EXISTS plugin_run_transition_rejections;
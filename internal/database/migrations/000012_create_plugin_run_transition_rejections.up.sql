@@ -0,0 +1,18 @@
+-- Append-only log of PluginRun status transitions refused by the state machine
+-- (duplicate or late results, results for runs that were already reaped).
+CREATE TABLE plugin_run_transition_rejections (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    plugin_run_id VARCHAR(36) NOT NULL,
+    from_status VARCHAR(50) NOT NULL DEFAULT '',
+    to_status VARCHAR(50) NOT NULL,
+    source VARCHAR(50) NOT NULL DEFAULT '',
+    reason TEXT
+);
+
+CREATE INDEX idx_plugin_run_transition_rejections_plugin_run_id ON plugin_run_transition_rejections(plugin_run_id);
+
+-- Accelerates the stale-run reaper: only processing runs are scanned.
+CREATE INDEX idx_plugin_runs_processing_started_at
+    ON plugin_runs(started_at)
+    WHERE status = 'processing' AND deleted_at IS NULL;
@@ -0,0 +1,32 @@
+-- User-registered outbound webhooks. url and secret are encrypted at rest.
+CREATE TABLE user_webhooks (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    description VARCHAR(100) NOT NULL DEFAULT '',
+    encrypted_url TEXT NOT NULL,
+    encrypted_secret TEXT NOT NULL,
+    events TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_user_webhooks_deleted_at ON user_webhooks(deleted_at);
+CREATE INDEX idx_user_webhooks_user_id ON user_webhooks(user_id);
+
+-- Append-only log of every delivery attempt (including retries and test events).
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_webhook_id BIGINT NOT NULL REFERENCES user_webhooks(id) ON DELETE CASCADE,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    success BOOLEAN NOT NULL DEFAULT FALSE,
+    error_message TEXT,
+    duration_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_created ON webhook_deliveries(user_webhook_id, created_at DESC);
+CREATE INDEX idx_webhook_deliveries_event_id ON webhook_deliveries(event_id);
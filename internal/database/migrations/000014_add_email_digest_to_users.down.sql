@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_email_unsubscribe_token;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email_digest_enabled,
+    DROP COLUMN IF EXISTS email_unsubscribe_token,
+    DROP COLUMN IF EXISTS last_digest_sent_at;
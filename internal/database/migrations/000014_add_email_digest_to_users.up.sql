@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_digest_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS email_unsubscribe_token VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS last_digest_sent_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_unsubscribe_token
+    ON users(email_unsubscribe_token)
+    WHERE email_unsubscribe_token IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS notification_channel_plugins;
+DROP TABLE IF EXISTS notification_channels;
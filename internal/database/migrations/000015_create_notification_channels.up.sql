@@ -0,0 +1,27 @@
+-- Per-user chat delivery channels (Slack, Discord, Matrix incoming webhooks).
+-- The webhook URL is the credential and is encrypted at rest.
+CREATE TABLE notification_channels (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    encrypted_webhook_url TEXT NOT NULL
+);
+
+CREATE INDEX idx_notification_channels_deleted_at ON notification_channels(deleted_at);
+CREATE INDEX idx_notification_channels_user_id ON notification_channels(user_id);
+
+-- Which plugins' outputs are posted to which channel.
+CREATE TABLE notification_channel_plugins (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    notification_channel_id BIGINT NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    plugin_id BIGINT NOT NULL REFERENCES plugins(id) ON DELETE CASCADE,
+    CONSTRAINT uq_notification_channel_plugin UNIQUE (notification_channel_id, plugin_id)
+);
+
+CREATE INDEX idx_notification_channel_plugins_user_plugin ON notification_channel_plugins(user_id, plugin_id);
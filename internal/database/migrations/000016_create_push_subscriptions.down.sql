@@ -0,0 +1,4 @@
+ALTER TABLE user_plugin_configs
+    DROP COLUMN IF EXISTS push_enabled;
+
+DROP TABLE IF EXISTS push_subscriptions;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_feed_token;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS feed_token;
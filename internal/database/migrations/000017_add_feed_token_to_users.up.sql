@@ -0,0 +1,7 @@
+-- Private token for the user's Atom / JSON Feed URLs. NULL until first requested.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS feed_token VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_feed_token
+    ON users(feed_token)
+    WHERE feed_token IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_briefings_search_vector;
+ALTER TABLE briefings DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS idx_plugin_runs_search_vector;
+ALTER TABLE plugin_runs DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,16 @@
+-- Full-text search over briefing history. Only string values are indexed, so
+-- JSON keys are ignored; HTML tags in plugin output are parsed as "tag" tokens,
+-- which the english configuration drops.
+ALTER TABLE plugin_runs
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (jsonb_to_tsvector('english', COALESCE(output, '{}'::jsonb), '["string"]')) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_plugin_runs_search_vector
+    ON plugin_runs USING GIN (search_vector);
+
+ALTER TABLE briefings
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (jsonb_to_tsvector('english', COALESCE(content, '{}'::jsonb), '["string"]')) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_briefings_search_vector
+    ON briefings USING GIN (search_vector);
@@ -0,0 +1,4 @@
+-- Per-plugin "only show what's new" tile mode: the dashboard tile shows the
+-- items added or changed since the previous completed run.
+ALTER TABLE user_plugin_configs
+    ADD COLUMN IF NOT EXISTS whats_new_only BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+ALTER TABLE plugins
+    DROP COLUMN IF EXISTS replaced_by,
+    DROP COLUMN IF EXISTS deprecated,
+    DROP COLUMN IF EXISTS unavailable_since,
+    DROP COLUMN IF EXISTS status;
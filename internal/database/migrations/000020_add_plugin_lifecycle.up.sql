@@ -0,0 +1,8 @@
+-- Plugin lifecycle: plugins removed from PLUGIN_DIR are marked unavailable
+-- (unavailable_since records when), and manifests can mark a plugin
+-- deprecated in favour of a replacement.
+ALTER TABLE plugins
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'available',
+    ADD COLUMN IF NOT EXISTS unavailable_since TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS deprecated BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS replaced_by VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+ALTER TABLE user_plugin_configs
+    DROP COLUMN IF EXISTS settings_schema_hash,
+    DROP COLUMN IF EXISTS settings_version;
+
+ALTER TABLE plugin_runs
+    DROP COLUMN IF EXISTS schema_hash,
+    DROP COLUMN IF EXISTS plugin_version;
+
+DROP TABLE IF EXISTS plugin_versions;
+
+ALTER TABLE plugins
+    DROP COLUMN IF EXISTS schema_hash;
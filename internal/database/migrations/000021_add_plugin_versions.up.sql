@@ -0,0 +1,23 @@
+-- Plugin versioning: every version of a plugin seen at sync time, the current
+-- settings schema hash, the version that produced each run, and the version
+-- a user's settings were saved under.
+ALTER TABLE plugins
+    ADD COLUMN IF NOT EXISTS schema_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE TABLE plugin_versions (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    plugin_id BIGINT NOT NULL REFERENCES plugins(id) ON DELETE CASCADE,
+    version VARCHAR(50) NOT NULL,
+    schema_version VARCHAR(20) NOT NULL DEFAULT 'v1',
+    schema_hash VARCHAR(64) NOT NULL DEFAULT '',
+    CONSTRAINT uq_plugin_versions_plugin_version UNIQUE (plugin_id, version)
+);
+
+ALTER TABLE plugin_runs
+    ADD COLUMN IF NOT EXISTS plugin_version VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS schema_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE user_plugin_configs
+    ADD COLUMN IF NOT EXISTS settings_version VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS settings_schema_hash VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Validation errors, keyed by JSON pointer, for saved settings that no longer
+-- match the plugin's settings schema. NULL while the settings are valid.
+ALTER TABLE user_plugin_configs
+    ADD COLUMN IF NOT EXISTS settings_errors JSONB NULL;
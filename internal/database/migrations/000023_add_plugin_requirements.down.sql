@@ -0,0 +1,5 @@
+ALTER TABLE plugins
+    DROP COLUMN IF EXISTS min_tier,
+    DROP COLUMN IF EXISTS network,
+    DROP COLUMN IF EXISTS optional_secrets,
+    DROP COLUMN IF EXISTS requires_secrets;
@@ -0,0 +1,8 @@
+-- Plugin requirements declared in plugin.yaml: the user API keys a plugin
+-- needs (requires_secrets) or can use (optional_secrets), whether it reaches
+-- the network, and the lowest account tier allowed to enable it.
+ALTER TABLE plugins
+    ADD COLUMN IF NOT EXISTS requires_secrets JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS optional_secrets JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS network BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS min_tier VARCHAR(50) NOT NULL DEFAULT '';
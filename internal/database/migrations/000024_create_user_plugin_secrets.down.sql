@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_plugin_secrets;
+
+ALTER TABLE plugins
+    DROP COLUMN IF EXISTS secret_slots;
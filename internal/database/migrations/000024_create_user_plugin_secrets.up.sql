@@ -0,0 +1,19 @@
+-- Custom secret slots declared by a plugin under `secrets:` in plugin.yaml,
+-- and the values users save for them, encrypted per user per plugin.
+ALTER TABLE plugins
+    ADD COLUMN IF NOT EXISTS secret_slots JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE user_plugin_secrets (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plugin_id BIGINT NOT NULL REFERENCES plugins(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    encrypted_value TEXT NOT NULL
+);
+
+CREATE INDEX idx_user_plugin_secrets_deleted_at ON user_plugin_secrets(deleted_at);
+CREATE INDEX idx_user_plugin_secrets_user_plugin ON user_plugin_secrets(user_id, plugin_id);
+CREATE UNIQUE INDEX idx_user_plugin_secrets_unique_active ON user_plugin_secrets(user_id, plugin_id, name) WHERE deleted_at IS NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_plugin_runs_shared_from_run_id;
+DROP INDEX IF EXISTS idx_plugin_runs_input_hash;
+
+ALTER TABLE plugin_runs
+    DROP COLUMN IF EXISTS shared_from_run_id,
+    DROP COLUMN IF EXISTS input_hash;
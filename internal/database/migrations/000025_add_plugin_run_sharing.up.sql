@@ -0,0 +1,9 @@
+-- Shared results: runs of plugins that declare `cache: {mode: shared}` record
+-- a hash of their non-secret inputs, and runs served from another user's run
+-- (reused or coalesced) record that run's plugin_run_id.
+ALTER TABLE plugin_runs
+    ADD COLUMN IF NOT EXISTS input_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS shared_from_run_id VARCHAR(36) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_plugin_runs_input_hash ON plugin_runs(plugin_id, input_hash) WHERE input_hash <> '';
+CREATE INDEX IF NOT EXISTS idx_plugin_runs_shared_from_run_id ON plugin_runs(shared_from_run_id) WHERE shared_from_run_id <> '';
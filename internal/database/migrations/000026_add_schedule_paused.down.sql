@@ -0,0 +1,2 @@
+ALTER TABLE user_plugin_configs
+    DROP COLUMN IF EXISTS schedule_paused;
@@ -0,0 +1,4 @@
+-- Schedules of plugins that have been unavailable past the grace period are
+-- paused rather than cleared, so they resume when the plugin is restored.
+ALTER TABLE user_plugin_configs
+    ADD COLUMN IF NOT EXISTS schedule_paused BOOLEAN NOT NULL DEFAULT false;
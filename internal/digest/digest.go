@@ -0,0 +1,294 @@
+// Package digest builds and sends the daily email digest: one message per user
+// bundling the latest output of each enabled plugin completed since the last digest.
+package digest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/digestvm"
+	"github.com/jimdaga/first-sip/internal/email"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/templates"
+	"gorm.io/gorm"
+)
+
+// DefaultBriefingTime is used when a user's PreferredBriefingTime is unparseable.
+const DefaultBriefingTime = "06:00"
+
+// dueWindow is how long after PreferredBriefingTime a digest may still be sent.
+// It lets a missed tick (deploy, worker restart) catch up without mailing users
+// who enable the digest late in the day.
+const dueWindow = time.Hour
+
+// lookbackWindow bounds how old a run may be to appear in a digest. Runs already
+// mailed are excluded by LastDigestSentAt; this also keeps a first digest, or one
+// after the digest was re-enabled, from mailing days-old output.
+const lookbackWindow = 24 * time.Hour
+
+// Service renders and sends digests through a Transport.
+type Service struct {
+	db        *gorm.DB
+	transport email.Transport
+	baseURL   string
+	from      string
+}
+
+// NewService creates a digest Service. baseURL is the externally reachable app
+// URL used for dashboard and unsubscribe links.
+func NewService(db *gorm.DB, transport email.Transport, baseURL, from string) *Service {
+	return &Service{
+		db:        db,
+		transport: transport,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		from:      from,
+	}
+}
+
+// IsDue reports whether a user's digest should be sent at now: the local time
+// has reached briefingTime ("HH:MM" in timezone) within dueWindow, and no
+// digest has been sent since that moment today.
+func IsDue(briefingTime, timezone string, lastSent *time.Time, now time.Time) bool {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" {
+		loc = time.UTC
+	}
+	clock, err := time.Parse("15:04", briefingTime)
+	if err != nil {
+		clock, _ = time.Parse("15:04", DefaultBriefingTime)
+	}
+
+	local := now.In(loc)
+	target := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if local.Before(target) || local.Sub(target) > dueWindow {
+		return false
+	}
+	return lastSent == nil || lastSent.Before(target)
+}
+
+// Send builds and sends the digest for one user, then records LastDigestSentAt.
+// Users with nothing to report are marked as sent without an email. Returns
+// (false, nil) when the user is no longer due (disabled or already sent).
+func (s *Service) Send(ctx context.Context, userID uint, now time.Time) (bool, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		return false, fmt.Errorf("digest: fetch user: %w", err)
+	}
+	if !user.EmailDigestEnabled || !IsDue(user.PreferredBriefingTime, user.Timezone, user.LastDigestSentAt, now) {
+		return false, nil
+	}
+
+	msg, items, err := s.Build(ctx, &user, now)
+	if err != nil {
+		return false, err
+	}
+	if items > 0 {
+		if err := s.transport.Send(ctx, msg); err != nil {
+			return false, err
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Model(&user).Update("last_digest_sent_at", now).Error; err != nil {
+		return items > 0, fmt.Errorf("digest: record send: %w", err)
+	}
+	return items > 0, nil
+}
+
+// Build renders the digest message for user. Returns the number of plugin
+// outputs included; the message should not be sent when it is zero.
+func (s *Service) Build(ctx context.Context, user *models.User, now time.Time) (email.Message, int, error) {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	since := now.Add(-lookbackWindow)
+	if user.LastDigestSentAt != nil && user.LastDigestSentAt.After(since) {
+		since = *user.LastDigestSentAt
+	}
+	items, err := s.collect(ctx, user.ID, since, loc)
+	if err != nil {
+		return email.Message{}, 0, err
+	}
+
+	token, err := EnsureUnsubscribeToken(s.db.WithContext(ctx), user)
+	if err != nil {
+		return email.Message{}, 0, err
+	}
+
+	vm := digestvm.EmailViewModel{
+		Greeting:       greeting(user.Name, now.In(loc)),
+		Date:           now.In(loc).Format("Monday, January 2"),
+		Items:          items,
+		DashboardURL:   s.baseURL + "/dashboard",
+		UnsubscribeURL: s.baseURL + "/unsubscribe/" + token,
+	}
+
+	var htmlBody bytes.Buffer
+	if err := templates.DigestEmail(vm).Render(ctx, &htmlBody); err != nil {
+		return email.Message{}, 0, fmt.Errorf("digest: render html: %w", err)
+	}
+
+	return email.Message{
+		From:    s.from,
+		To:      user.Email,
+		Subject: "Your First Sip briefing for " + vm.Date,
+		Text:    textBody(vm),
+		HTML:    htmlBody.String(),
+		Headers: map[string]string{
+			// RFC 8058 one-click unsubscribe: mail clients POST to the URL.
+			"List-Unsubscribe":      "<" + vm.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, len(items), nil
+}
+
+// collect returns the latest run output of each enabled plugin completed after
+// since, in the user's dashboard order. Plugins with no newer run are left out
+// rather than repeating output an earlier digest already sent.
+func (s *Service) collect(ctx context.Context, userID uint, since time.Time, loc *time.Location) ([]digestvm.Item, error) {
+	var configs []plugins.UserPluginConfig
+	if err := s.db.WithContext(ctx).
+		Preload("Plugin").
+		Where("user_id = ? AND enabled = ?", userID, true).
+		Order("display_order ASC NULLS LAST").
+		Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("digest: list plugin configs: %w", err)
+	}
+
+	items := make([]digestvm.Item, 0, len(configs))
+	for _, cfg := range configs {
+		var run plugins.PluginRun
+		err := s.db.WithContext(ctx).
+			Where("user_id = ? AND plugin_id = ? AND status = ? AND completed_at > ?", userID, cfg.PluginID, plugins.PluginRunStatusCompleted, since).
+			Order("completed_at DESC NULLS LAST").
+			First(&run).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("digest: latest run for plugin %d: %w", cfg.PluginID, err)
+		}
+
+		var out dashboard.PluginRunOutput
+		if err := json.Unmarshal(run.Output, &out); err != nil {
+			continue
+		}
+		item := digestvm.Item{
+			PluginName:  cfg.Plugin.Name,
+			DisplayName: plugins.HumanizeName(cfg.Plugin.Name),
+			Icon:        cfg.Plugin.Icon,
+			Summary:     out.Summary,
+			DetailURL:   s.baseURL + "/plugins/" + cfg.Plugin.Name,
+		}
+		for _, sec := range out.Sections {
+			item.Sections = append(item.Sections, digestvm.Section{Title: sec.Title, Content: dashboard.SectionHTML(sec)})
+		}
+		if len(item.Sections) == 0 && out.Content != "" {
+			item.Sections = []digestvm.Section{{Content: out.Content}}
+		}
+		if run.CompletedAt != nil {
+			item.GeneratedAt = run.CompletedAt.In(loc).Format("Jan 2, 3:04 PM")
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// EnsureUnsubscribeToken returns the user's unsubscribe token, generating and
+// saving one if the user does not have one yet.
+func EnsureUnsubscribeToken(db *gorm.DB, user *models.User) (string, error) {
+	if user.EmailUnsubscribeToken != nil && *user.EmailUnsubscribeToken != "" {
+		return *user.EmailUnsubscribeToken, nil
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("digest: generate unsubscribe token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	if err := db.Model(user).Update("email_unsubscribe_token", token).Error; err != nil {
+		return "", fmt.Errorf("digest: save unsubscribe token: %w", err)
+	}
+	user.EmailUnsubscribeToken = &token
+	return token, nil
+}
+
+// Unsubscribe disables the email digest for the user owning token.
+// Returns false when the token is unknown.
+func Unsubscribe(db *gorm.DB, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	result := db.Model(&models.User{}).
+		Where("email_unsubscribe_token = ?", token).
+		Update("email_digest_enabled", false)
+	if result.Error != nil {
+		return false, fmt.Errorf("digest: unsubscribe: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// greeting mirrors the dashboard greeting for the digest header.
+func greeting(name string, local time.Time) string {
+	if name == "" {
+		name = "there"
+	}
+	switch hour := local.Hour(); {
+	case hour < 12:
+		return "Good morning, " + name
+	case hour < 17:
+		return "Good afternoon, " + name
+	default:
+		return "Good evening, " + name
+	}
+}
+
+// textBody renders the plain-text alternative of the digest.
+func textBody(vm digestvm.EmailViewModel) string {
+	var sb strings.Builder
+	sb.WriteString(vm.Greeting + "\n")
+	sb.WriteString(vm.Date + "\n\n")
+	for _, item := range vm.Items {
+		sb.WriteString(strings.ToUpper(item.DisplayName) + "\n")
+		if item.Summary != "" {
+			sb.WriteString(item.Summary + "\n")
+		}
+		for _, sec := range item.Sections {
+			sb.WriteString("\n")
+			if sec.Title != "" {
+				sb.WriteString("## " + sec.Title + "\n")
+			}
+			sb.WriteString(htmlToText(sec.Content) + "\n")
+		}
+		sb.WriteString(item.DetailURL + "\n\n")
+	}
+	sb.WriteString("Open your dashboard: " + vm.DashboardURL + "\n")
+	sb.WriteString("Unsubscribe from these emails: " + vm.UnsubscribeURL + "\n")
+	return sb.String()
+}
+
+var (
+	blockTagPattern = regexp.MustCompile(`(?i)<\s*(br|/p|/li|/h[1-6]|/div)\s*/?>`)
+	tagPattern      = regexp.MustCompile(`<[^>]*>`)
+	blankLines      = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText converts plugin section HTML to readable plain text.
+func htmlToText(s string) string {
+	s = blockTagPattern.ReplaceAllString(s, "\n")
+	s = tagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = blankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,202 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestIsDue(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	at := func(hour, min int) time.Time {
+		return time.Date(2026, 3, 10, hour, min, 0, 0, ny)
+	}
+	sentAt := func(tm time.Time) *time.Time { return &tm }
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		lastSent *time.Time
+		want     bool
+	}{
+		{"before briefing time", at(6, 59), nil, false},
+		{"at briefing time", at(7, 0), nil, true},
+		{"within window", at(7, 45), nil, true},
+		{"after window", at(8, 1), nil, false},
+		{"already sent today", at(7, 10), sentAt(at(7, 1)), false},
+		{"sent yesterday", at(7, 10), sentAt(at(7, 1).AddDate(0, 0, -1)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Pass now in UTC to check the timezone conversion.
+			if got := IsDue("07:00", "America/New_York", tt.lastSent, tt.now.UTC()); got != tt.want {
+				t.Errorf("IsDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDueFallsBackToDefaults(t *testing.T) {
+	now := time.Date(2026, 3, 10, 6, 5, 0, 0, time.UTC)
+	if !IsDue("not-a-time", "Not/AZone", nil, now) {
+		t.Error("expected invalid time and timezone to fall back to 06:00 UTC")
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	in := `<p>Rain &amp; wind</p><ul><li>One</li><li>Two</li></ul>Line<br/>Break`
+	want := "Rain & wind\nOne\nTwo\nLine\nBreak"
+	if got := htmlToText(in); got != want {
+		t.Errorf("htmlToText() = %q, want %q", got, want)
+	}
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &plugins.Plugin{}, &plugins.UserPluginConfig{}, &plugins.PluginRun{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestBuild(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2026, 3, 10, 11, 0, 0, 0, time.UTC)
+	lastSent := now.Add(-24 * time.Hour)
+	user := models.User{Email: "ada@example.com", Name: "Ada <Admin>", Timezone: "UTC", LastDigestSentAt: &lastSent}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	order := func(n int) *int { return &n }
+	seed := func(name string, enabled bool, displayOrder *int, status string, completedAt time.Time, out dashboard.PluginRunOutput) {
+		t.Helper()
+		plugin := plugins.Plugin{Name: name, Version: "1.0.0"}
+		if err := db.Create(&plugin).Error; err != nil {
+			t.Fatal(err)
+		}
+		cfg := plugins.UserPluginConfig{UserID: user.ID, PluginID: plugin.ID, Enabled: enabled, DisplayOrder: displayOrder}
+		if err := db.Create(&cfg).Error; err != nil {
+			t.Fatal(err)
+		}
+		output, err := json.Marshal(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		run := plugins.PluginRun{PluginRunID: name + "-run", UserID: user.ID, PluginID: plugin.ID, Status: status, Output: output, CompletedAt: &completedAt}
+		if err := db.Create(&run).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fresh := now.Add(-time.Hour)
+	seed("weather", true, order(2), plugins.PluginRunStatusCompleted, fresh, dashboard.PluginRunOutput{
+		Summary:  "Rain <b>later</b>",
+		Sections: []dashboard.OutputSection{{Title: "Forecast & Wind", Content: "<p>Showers</p>"}},
+	})
+	seed("daily-news-digest", true, order(1), plugins.PluginRunStatusCompleted, fresh, dashboard.PluginRunOutput{
+		Summary: "Three stories",
+		Content: "<p>Legacy body</p>",
+	})
+	seed("stock-ticker", true, nil, plugins.PluginRunStatusCompleted, fresh, dashboard.PluginRunOutput{Summary: "Markets up"})
+	// Already mailed in the previous digest.
+	seed("github-activity", true, order(3), plugins.PluginRunStatusCompleted, lastSent.Add(-time.Minute), dashboard.PluginRunOutput{Summary: "Stale"})
+	seed("sports-scores", true, order(4), plugins.PluginRunStatusFailed, fresh, dashboard.PluginRunOutput{Summary: "Failed"})
+	seed("horoscope", false, order(5), plugins.PluginRunStatusCompleted, fresh, dashboard.PluginRunOutput{Summary: "Disabled"})
+
+	svc := NewService(db, nil, "https://firstsip.example/", "First Sip <digest@firstsip.example>")
+	msg, items, err := svc.Build(context.Background(), &user, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items != 3 {
+		t.Fatalf("Build included %d items, want 3", items)
+	}
+
+	// Dashboard order: explicit display_order first, unordered plugins last.
+	last := -1
+	for _, want := range []string{"Three stories", "Rain &lt;b&gt;later&lt;/b&gt;", "Markets up"} {
+		i := strings.Index(msg.HTML, want)
+		if i < 0 {
+			t.Fatalf("HTML missing %q", want)
+		}
+		if i < last {
+			t.Errorf("%q rendered out of dashboard order", want)
+		}
+		last = i
+	}
+	for _, absent := range []string{"Stale", "Failed", "Disabled"} {
+		if strings.Contains(msg.HTML, absent) || strings.Contains(msg.Text, absent) {
+			t.Errorf("digest includes %q", absent)
+		}
+	}
+
+	// Plugin-supplied text is escaped; section content is trusted plugin HTML.
+	for _, want := range []string{"Ada &lt;Admin&gt;", "Forecast &amp; Wind", "<p>Showers</p>", "<p>Legacy body</p>"} {
+		if !strings.Contains(msg.HTML, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+	if strings.Contains(msg.HTML, "<b>later</b>") || strings.Contains(msg.HTML, "<Admin>") {
+		t.Error("HTML contains unescaped user or plugin text")
+	}
+	if !strings.Contains(msg.Text, "## Forecast & Wind\nShowers") {
+		t.Errorf("text body = %q, want sections converted to plain text", msg.Text)
+	}
+
+	if user.EmailUnsubscribeToken == nil {
+		t.Fatal("Build did not assign an unsubscribe token")
+	}
+	unsubscribe := "https://firstsip.example/unsubscribe/" + *user.EmailUnsubscribeToken
+	if msg.Headers["List-Unsubscribe"] != "<"+unsubscribe+">" || !strings.Contains(msg.Text, unsubscribe) {
+		t.Errorf("unsubscribe header = %q, want %q", msg.Headers["List-Unsubscribe"], unsubscribe)
+	}
+}
+
+func TestBuildFirstDigestLooksBackOneDay(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Date(2026, 3, 10, 11, 0, 0, 0, time.UTC)
+	user := models.User{Email: "new@example.com", Timezone: "UTC"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	plugin := plugins.Plugin{Name: "weather", Version: "1.0.0"}
+	if err := db.Create(&plugin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&plugins.UserPluginConfig{UserID: user.ID, PluginID: plugin.ID, Enabled: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	old := now.Add(-lookbackWindow - time.Minute)
+	run := plugins.PluginRun{PluginRunID: "old", UserID: user.ID, PluginID: plugin.ID, Status: plugins.PluginRunStatusCompleted, Output: []byte(`{"summary":"old"}`), CompletedAt: &old}
+	if err := db.Create(&run).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	_, items, err := NewService(db, nil, "https://firstsip.example", "digest@firstsip.example").Build(context.Background(), &user, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items != 0 {
+		t.Errorf("Build included %d items, want runs older than the lookback window left out", items)
+	}
+}
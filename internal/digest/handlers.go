@@ -0,0 +1,53 @@
+package digest
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/templates"
+	"gorm.io/gorm"
+)
+
+// render writes a templ component as an HTML response.
+// Duplicated from settings/handlers.go to avoid import cycles.
+func render(c *gin.Context, component templ.Component) {
+	c.Header("Content-Type", "text/html")
+	component.Render(c.Request.Context(), c.Writer)
+}
+
+// UnsubscribePageHandler returns a Gin handler for GET /unsubscribe/:token.
+// Public route: the token in the digest email identifies the user.
+func UnsubscribePageHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Param("token")
+		var count int64
+		if token != "" {
+			db.Model(&models.User{}).Where("email_unsubscribe_token = ?", token).Count(&count)
+		}
+		render(c, templates.UnsubscribePage(token, false, count > 0))
+	}
+}
+
+// UnsubscribeHandler returns a Gin handler for POST /unsubscribe/:token.
+// Handles both the confirmation form and RFC 8058 one-click requests from
+// mail clients (List-Unsubscribe-Post).
+func UnsubscribeHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Param("token")
+		ok, err := Unsubscribe(db, token)
+		if err != nil {
+			slog.Error("digest: unsubscribe failed", "error", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			c.Status(http.StatusNotFound)
+			render(c, templates.UnsubscribePage(token, false, false))
+			return
+		}
+		render(c, templates.UnsubscribePage(token, true, true))
+	}
+}
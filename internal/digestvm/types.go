@@ -0,0 +1,31 @@
+// Package digestvm holds view-model types for the email digest templates.
+// It is a leaf package so internal/templates can import it without creating
+// a cycle with internal/digest (which imports internal/dashboard).
+package digestvm
+
+// Section is one titled block of a plugin's output. Content is HTML produced
+// by the plugin (already escaped), the same as on the dashboard.
+type Section struct {
+	Title   string
+	Content string
+}
+
+// Item is the latest completed output of one plugin.
+type Item struct {
+	PluginName  string
+	DisplayName string
+	Icon        string
+	Summary     string
+	Sections    []Section
+	GeneratedAt string // formatted in the user's timezone
+	DetailURL   string
+}
+
+// EmailViewModel is the data for the digest email HTML body.
+type EmailViewModel struct {
+	Greeting       string
+	Date           string
+	Items          []Item
+	DashboardURL   string
+	UnsubscribeURL string
+}
@@ -0,0 +1,118 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Transport delivers a Message.
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPTransport sends mail through an SMTP relay. STARTTLS is used whenever the
+// server offers it; auth is only attempted when a username is configured.
+// A local Mailpit instance (SMTP_HOST=localhost, SMTP_PORT=1025) works as-is.
+type SMTPTransport struct {
+	addr     string
+	host     string
+	username string
+	password string
+}
+
+// NewSMTPTransport creates an SMTPTransport for host:port.
+func NewSMTPTransport(host, port, username, password string) *SMTPTransport {
+	return &SMTPTransport{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+	}
+}
+
+// Send implements Transport. net/smtp has no context support, so ctx is only
+// checked before sending.
+func (t *SMTPTransport) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("email: invalid sender %q: %w", msg.From, err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("email: invalid recipient %q: %w", msg.To, err)
+	}
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if t.username != "" {
+		auth = smtp.PlainAuth("", t.username, t.password, t.host)
+	}
+	if err := smtp.SendMail(t.addr, auth, from.Address, []string{to.Address}, body); err != nil {
+		return fmt.Errorf("email: smtp send: %w", err)
+	}
+	return nil
+}
+
+// FileTransport writes each message to Dir as an .eml file instead of sending
+// it. Used for local development and tests.
+type FileTransport struct {
+	Dir string
+}
+
+// NewFileTransport creates a FileTransport, creating dir if needed.
+func NewFileTransport(dir string) (*FileTransport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("email: create sink dir: %w", err)
+	}
+	return &FileTransport{Dir: dir}, nil
+}
+
+// Send implements Transport.
+func (t *FileTransport) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	to, _ := mail.ParseAddress(msg.To) // validated by Bytes
+	name := fmt.Sprintf("%s-%s.eml",
+		time.Now().UTC().Format("20060102T150405.000000000"),
+		strings.NewReplacer("@", "_at_", "/", "_").Replace(to.Address),
+	)
+	if err := os.WriteFile(filepath.Join(t.Dir, name), body, 0o644); err != nil {
+		return fmt.Errorf("email: write message: %w", err)
+	}
+	return nil
+}
+
+// NewTransport picks a transport from configuration: SMTP when smtpHost is
+// set, otherwise a file sink when sinkDir is set. Returns nil when neither is
+// configured, which disables email delivery.
+func NewTransport(smtpHost, smtpPort, username, password, sinkDir string) (Transport, error) {
+	if smtpHost != "" {
+		return NewSMTPTransport(smtpHost, smtpPort, username, password), nil
+	}
+	if sinkDir != "" {
+		t, err := NewFileTransport(sinkDir)
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,112 @@
+package email
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileTransportWritesMultipartMessage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sink")
+	transport, err := NewFileTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsubscribe := "https://firstsip.example/unsubscribe/tok123"
+	msg := Message{
+		From:    "First Sip <digest@firstsip.example>",
+		To:      "ada@example.com",
+		Subject: "Your briefing ☕",
+		Text:    "Good morning\nUnsubscribe: " + unsubscribe,
+		HTML:    `<p>Good morning</p><a href="` + unsubscribe + `">Unsubscribe</a>`,
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribe + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click\r\nBcc: evil@example.com",
+		},
+	}
+	if err := transport.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*ada_at_example.com.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("sink files = %v, %v; want one .eml for the recipient", files, err)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	parsed, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q, %v; want %q", subject, err, msg.Subject)
+	}
+	if got := parsed.Header.Get("List-Unsubscribe"); got != "<"+unsubscribe+">" {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+	if parsed.Header.Get("Bcc") != "" {
+		t.Error("header value with CRLF injected a Bcc header")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v; want multipart/alternative", mediaType, err)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	bodies := map[string]string{}
+	var order []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NextPart decodes quoted-printable bodies; line breaks come back as CRLF.
+		b, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[ct] = strings.ReplaceAll(string(b), "\r\n", "\n")
+		order = append(order, ct)
+	}
+
+	// Clients show the last alternative they support, so HTML must come last.
+	if strings.Join(order, ",") != "text/plain,text/html" {
+		t.Fatalf("parts = %v, want text/plain then text/html", order)
+	}
+	if bodies["text/plain"] != msg.Text {
+		t.Errorf("text part = %q, want %q", bodies["text/plain"], msg.Text)
+	}
+	if bodies["text/html"] != msg.HTML {
+		t.Errorf("html part = %q, want %q", bodies["text/html"], msg.HTML)
+	}
+}
+
+func TestFileTransportRejectsInvalidRecipient(t *testing.T) {
+	dir := t.TempDir()
+	transport, err := NewFileTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.Send(context.Background(), Message{From: "digest@firstsip.example", To: "not an address"}); err == nil {
+		t.Error("Send accepted an invalid recipient")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("sink has %d files, want none", len(entries))
+	}
+}
@@ -0,0 +1,175 @@
+// Package feeds publishes a user's completed plugin runs and legacy briefings
+// as private Atom and JSON Feed documents, authenticated by a per-user token.
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"io"
+	"time"
+)
+
+// Feed is the format-neutral representation rendered by WriteAtom and WriteJSON.
+type Feed struct {
+	ID      string // stable IRI for the feed
+	Title   string
+	HomeURL string // HTML page the feed mirrors
+	AtomURL string
+	JSONURL string
+	Updated time.Time
+	Author  string
+	Entries []Entry
+}
+
+// Entry is one plugin run or briefing.
+type Entry struct {
+	ID          string // stable across renders, migrations and token rotation
+	Title       string
+	URL         string
+	Summary     string
+	ContentHTML string
+	Category    string // plugin name
+	Published   time.Time
+	Updated     time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID        string        `xml:"id"`
+	Title     string        `xml:"title"`
+	Updated   string        `xml:"updated"`
+	Published string        `xml:"published"`
+	Links     []atomLink    `xml:"link"`
+	Category  *atomCategory `xml:"category,omitempty"`
+	Summary   *atomText     `xml:"summary,omitempty"`
+	Content   *atomText     `xml:"content,omitempty"`
+}
+
+// WriteAtom renders f as an Atom 1.0 (RFC 4287) document.
+func WriteAtom(w io.Writer, f Feed) error {
+	doc := atomFeed{
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Author:  atomPerson{Name: f.Author},
+		Links: []atomLink{
+			{Href: f.AtomURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.HomeURL, Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, e := range f.Entries {
+		ae := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Updated:   e.Updated.UTC().Format(time.RFC3339),
+			Published: e.Published.UTC().Format(time.RFC3339),
+			Links:     []atomLink{{Href: e.URL, Rel: "alternate", Type: "text/html"}},
+		}
+		if e.Category != "" {
+			ae.Category = &atomCategory{Term: e.Category}
+		}
+		if e.Summary != "" {
+			ae.Summary = &atomText{Type: "text", Body: e.Summary}
+		}
+		if e.ContentHTML != "" {
+			ae.Content = &atomText{Type: "html", Body: e.ContentHTML}
+		}
+		doc.Entries = append(doc.Entries, ae)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(doc)
+}
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url"`
+	FeedURL     string       `json:"feed_url"`
+	Authors     []jsonAuthor `json:"authors,omitempty"`
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	Summary       string   `json:"summary,omitempty"`
+	ContentHTML   string   `json:"content_html"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// WriteJSON renders f as a JSON Feed 1.1 document.
+func WriteJSON(w io.Writer, f Feed) error {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.HomeURL,
+		FeedURL:     f.JSONURL,
+		Items:       make([]jsonItem, 0, len(f.Entries)),
+	}
+	if f.Author != "" {
+		doc.Authors = []jsonAuthor{{Name: f.Author}}
+	}
+	for _, e := range f.Entries {
+		item := jsonItem{
+			ID:            e.ID,
+			URL:           e.URL,
+			Title:         e.Title,
+			Summary:       e.Summary,
+			ContentHTML:   e.ContentHTML,
+			DatePublished: e.Published.UTC().Format(time.RFC3339),
+			DateModified:  e.Updated.UTC().Format(time.RFC3339),
+		}
+		if e.Category != "" {
+			item.Tags = []string{e.Category}
+		}
+		// JSON Feed requires content_html or content_text.
+		if item.ContentHTML == "" {
+			item.ContentHTML = html.EscapeString(e.Summary)
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,112 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/dashboard"
+)
+
+func testFeed() Feed {
+	t1 := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	return Feed{
+		ID:      "tag:example.com,2025:feed/1",
+		Title:   "First Sip",
+		HomeURL: "https://example.com/dashboard",
+		AtomURL: "https://example.com/feeds/tok/atom.xml",
+		JSONURL: "https://example.com/feeds/tok/feed.json",
+		Updated: t1,
+		Author:  "First Sip",
+		Entries: []Entry{{
+			ID:          "tag:example.com,2025:run/abc",
+			Title:       "Daily News — Mar 2, 2026",
+			URL:         "https://example.com/plugins/daily-news",
+			Summary:     "3 headlines & more",
+			ContentHTML: "<p>3 headlines</p>",
+			Category:    "daily-news",
+			Published:   t1,
+			Updated:     t1,
+		}},
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAtom(&buf, testFeed()); err != nil {
+		t.Fatalf("WriteAtom: %v", err)
+	}
+
+	var doc atomFeed
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+	}
+	if doc.XMLName.Space != "http://www.w3.org/2005/Atom" {
+		t.Errorf("namespace = %q", doc.XMLName.Space)
+	}
+	if len(doc.Entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(doc.Entries))
+	}
+	e := doc.Entries[0]
+	if e.ID != "tag:example.com,2025:run/abc" || e.Updated != "2026-03-02T07:00:00Z" {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Content == nil || e.Content.Type != "html" || e.Content.Body != "<p>3 headlines</p>" {
+		t.Errorf("content = %+v, want escaped html round trip", e.Content)
+	}
+	if !strings.Contains(buf.String(), `rel="self"`) {
+		t.Error("missing self link")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, testFeed()); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	var doc jsonFeed
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if doc.Version != "https://jsonfeed.org/version/1.1" || doc.FeedURL != "https://example.com/feeds/tok/feed.json" {
+		t.Errorf("feed = %+v", doc)
+	}
+	if len(doc.Items) != 1 || doc.Items[0].ID != "tag:example.com,2025:run/abc" || doc.Items[0].Tags[0] != "daily-news" {
+		t.Errorf("items = %+v", doc.Items)
+	}
+
+	empty := testFeed()
+	empty.Entries[0].ContentHTML = ""
+	buf.Reset()
+	WriteJSON(&buf, empty)
+	json.Unmarshal(buf.Bytes(), &doc)
+	if doc.Items[0].ContentHTML != "3 headlines &amp; more" {
+		t.Errorf("fallback content_html = %q, want escaped summary", doc.Items[0].ContentHTML)
+	}
+}
+
+func TestContentHTMLEscapesTitles(t *testing.T) {
+	got := contentHTML(dashboard.PluginRunOutput{
+		Summary:  "a < b",
+		Sections: []dashboard.OutputSection{{Title: "<Top>", Content: "<b>kept</b>"}},
+	})
+	want := "<p>a &lt; b</p><h3>&lt;Top&gt;</h3><div><b>kept</b></div>"
+	if got != want {
+		t.Errorf("contentHTML = %q, want %q", got, want)
+	}
+}
+
+func TestTagURIAndURLs(t *testing.T) {
+	b := NewBuilder(nil, "https://sip.example.com:8443/", 30)
+	if got := b.tagURI("run/x"); got != "tag:sip.example.com,2025:run/x" {
+		t.Errorf("tagURI = %q", got)
+	}
+	atom, js := URLs("https://sip.example.com/", "tok", "daily-news")
+	if atom != "https://sip.example.com/feeds/tok/plugins/daily-news/atom.xml" || js != "https://sip.example.com/feeds/tok/plugins/daily-news/feed.json" {
+		t.Errorf("URLs = %q, %q", atom, js)
+	}
+}
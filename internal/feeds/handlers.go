@@ -0,0 +1,186 @@
+package feeds
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/feedsvm"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/templates"
+	"gorm.io/gorm"
+)
+
+// Format selects the feed document type served by FeedHandler.
+type Format string
+
+const (
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+// render is a package-local helper for rendering Templ components in Gin handlers.
+// Duplicated from settings/handlers.go to avoid import cycles.
+func render(c *gin.Context, component templ.Component) {
+	c.Header("Content-Type", "text/html")
+	component.Render(c.Request.Context(), c.Writer)
+}
+
+// getAuthUser extracts the authenticated user from the Gin context and fetches
+// the full User record from the database.
+// Duplicated from settings/handlers.go to avoid import cycles.
+func getAuthUser(c *gin.Context, db *gorm.DB) (*models.User, error) {
+	emailVal, exists := c.Get("user_email")
+	if !exists {
+		return nil, fmt.Errorf("user_email not found in context")
+	}
+	emailStr, ok := emailVal.(string)
+	if !ok || emailStr == "" {
+		return nil, fmt.Errorf("user_email is empty")
+	}
+	var user models.User
+	if err := db.Where("email = ?", emailStr).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return &user, nil
+}
+
+// FeedHandler returns a public Gin handler for
+// GET /feeds/:token/atom.xml, /feeds/:token/feed.json and their
+// /feeds/:token/plugins/:pluginName/ counterparts. The token is the only
+// credential, so unknown tokens and plugins both return 404.
+func FeedHandler(db *gorm.DB, baseURL string, lookbackDays int, format Format) gin.HandlerFunc {
+	builder := NewBuilder(db, baseURL, lookbackDays)
+	return func(c *gin.Context) {
+		user, err := UserByToken(db, c.Param("token"))
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		feed, err := builder.Build(c.Request.Context(), user, c.Param("pluginName"), time.Now())
+		if err != nil {
+			if errors.Is(err, ErrUnknownPlugin) {
+				c.Status(http.StatusNotFound)
+				return
+			}
+			slog.Error("feeds: failed to build feed", "user_id", user.ID, "error", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		// Feed readers poll; answer conditional requests without a body.
+		lastModified := feed.Updated.UTC().Truncate(time.Second)
+		if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !lastModified.After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+
+		var buf bytes.Buffer
+		contentType := "application/atom+xml; charset=utf-8"
+		if format == FormatJSON {
+			contentType = "application/feed+json; charset=utf-8"
+			err = WriteJSON(&buf, feed)
+		} else {
+			err = WriteAtom(&buf, feed)
+		}
+		if err != nil {
+			slog.Error("feeds: failed to render feed", "user_id", user.ID, "format", format, "error", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+		c.Header("Cache-Control", "private, max-age=300")
+		c.Header("X-Robots-Tag", "noindex")
+		c.Data(http.StatusOK, contentType, buf.Bytes())
+	}
+}
+
+// PageHandler returns a Gin handler for GET /settings/feeds.
+// A feed token is created on first visit.
+func PageHandler(db *gorm.DB, baseURL string, lookbackDays int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+
+		vm, err := BuildViewModel(db, user, baseURL, lookbackDays)
+		if err != nil {
+			slog.Error("feeds: failed to build settings page", "user_id", user.ID, "error", err)
+			vm.Notice = "Failed to load your feed URLs."
+			vm.NoticeIsError = true
+		}
+		sidebarPlugins := dashboard.GetSidebarPlugins(db, user.ID)
+		render(c, templates.FeedsSettingsPage(vm, sidebarPlugins))
+	}
+}
+
+// RotateHandler returns a Gin handler for POST /api/user/feeds/rotate.
+// Replaces the feed token and returns the refreshed #feeds-section fragment.
+func RotateHandler(db *gorm.DB, baseURL string, lookbackDays int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		if _, err := RotateToken(db, user); err != nil {
+			slog.Error("feeds: failed to rotate token", "user_id", user.ID, "error", err)
+			vm, _ := BuildViewModel(db, user, baseURL, lookbackDays)
+			vm.Notice = "Failed to reset your feed URLs."
+			vm.NoticeIsError = true
+			render(c, templates.FeedsSection(vm))
+			return
+		}
+
+		vm, err := BuildViewModel(db, user, baseURL, lookbackDays)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		vm.Notice = "Feed URLs reset. Update your feed reader with the new addresses."
+		render(c, templates.FeedsSection(vm))
+	}
+}
+
+// BuildViewModel returns the feed URLs for the user's combined feed and each
+// enabled plugin, creating the feed token if needed.
+func BuildViewModel(db *gorm.DB, user *models.User, baseURL string, lookbackDays int) (feedsvm.FeedsPageViewModel, error) {
+	vm := feedsvm.FeedsPageViewModel{RetentionDays: lookbackDays}
+
+	token, err := EnsureToken(db, user)
+	if err != nil {
+		return vm, err
+	}
+
+	vm.Combined.Label = "All plugins"
+	vm.Combined.AtomURL, vm.Combined.JSONURL = URLs(baseURL, token, "")
+
+	var configs []plugins.UserPluginConfig
+	if err := db.Preload("Plugin").
+		Where("user_id = ? AND enabled = ?", user.ID, true).
+		Order("display_order ASC NULLS LAST").
+		Find(&configs).Error; err != nil {
+		return vm, fmt.Errorf("feeds: list plugin configs: %w", err)
+	}
+	for _, cfg := range configs {
+		links := feedsvm.FeedLinks{
+			Label: plugins.HumanizeName(cfg.Plugin.Name),
+			Icon:  cfg.Plugin.Icon,
+		}
+		links.AtomURL, links.JSONURL = URLs(baseURL, token, cfg.Plugin.Name)
+		vm.Plugins = append(vm.Plugins, links)
+	}
+	return vm, nil
+}
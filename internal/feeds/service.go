@@ -0,0 +1,267 @@
+package feeds
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/briefings"
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/webhook"
+	"gorm.io/gorm"
+)
+
+// MaxEntries caps the number of entries in one feed document.
+const MaxEntries = 50
+
+// ErrUnknownPlugin is returned by Build when a per-plugin feed names a plugin
+// that does not exist.
+var ErrUnknownPlugin = errors.New("feeds: unknown plugin")
+
+// tagDate is the fixed date component of entry tag URIs (RFC 4151). It must
+// never change, or every reader would see every entry as new.
+const tagDate = "2025"
+
+// EnsureToken returns the user's feed token, generating and saving one if the
+// user does not have one yet.
+func EnsureToken(db *gorm.DB, user *models.User) (string, error) {
+	if user.FeedToken != nil && *user.FeedToken != "" {
+		return *user.FeedToken, nil
+	}
+	return RotateToken(db, user)
+}
+
+// RotateToken replaces the user's feed token, invalidating all existing feed URLs.
+func RotateToken(db *gorm.DB, user *models.User) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("feeds: generate token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	if err := db.Model(user).Update("feed_token", token).Error; err != nil {
+		return "", fmt.Errorf("feeds: save token: %w", err)
+	}
+	user.FeedToken = &token
+	return token, nil
+}
+
+// UserByToken looks up the owner of a feed token.
+func UserByToken(db *gorm.DB, token string) (*models.User, error) {
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var user models.User
+	if err := db.Where("feed_token = ?", token).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// URLs returns the Atom and JSON Feed URLs for a token. pluginName selects a
+// per-plugin feed; empty means the combined feed.
+func URLs(baseURL, token, pluginName string) (atomURL, jsonURL string) {
+	prefix := strings.TrimRight(baseURL, "/") + "/feeds/" + token
+	if pluginName != "" {
+		prefix += "/plugins/" + url.PathEscape(pluginName)
+	}
+	return prefix + "/atom.xml", prefix + "/feed.json"
+}
+
+// Builder assembles feeds for a user.
+type Builder struct {
+	db           *gorm.DB
+	baseURL      string
+	lookbackDays int
+}
+
+// NewBuilder creates a Builder. baseURL is used for entry links and tag URIs;
+// lookbackDays bounds how far back entries are included.
+func NewBuilder(db *gorm.DB, baseURL string, lookbackDays int) *Builder {
+	return &Builder{db: db, baseURL: strings.TrimRight(baseURL, "/"), lookbackDays: lookbackDays}
+}
+
+// Build returns the feed for user: completed plugin runs within the history
+// lookback window, newest first, plus legacy briefings that were not
+// migrated to plugin runs. pluginName restricts the feed to one plugin
+// (legacy briefings appear under the daily-briefing plugin).
+func (b *Builder) Build(ctx context.Context, user *models.User, pluginName string, now time.Time) (Feed, error) {
+	db := b.db.WithContext(ctx)
+	cutoff := now.AddDate(0, 0, -b.lookbackDays)
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	token := ""
+	if user.FeedToken != nil {
+		token = *user.FeedToken
+	}
+
+	feed := Feed{
+		ID:      b.tagURI("feed/" + strconv.FormatUint(uint64(user.ID), 10)),
+		Title:   "First Sip",
+		HomeURL: b.baseURL + "/dashboard",
+		Author:  "First Sip",
+	}
+	feed.AtomURL, feed.JSONURL = URLs(b.baseURL, token, pluginName)
+
+	query := db.Preload("Plugin").
+		Where("user_id = ? AND status = ? AND completed_at >= ?", user.ID, plugins.PluginRunStatusCompleted, cutoff)
+	if pluginName != "" {
+		var plugin plugins.Plugin
+		if err := db.Where("name = ?", pluginName).First(&plugin).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return Feed{}, ErrUnknownPlugin
+			}
+			return Feed{}, fmt.Errorf("feeds: look up plugin: %w", err)
+		}
+		query = query.Where("plugin_id = ?", plugin.ID)
+		feed.ID = b.tagURI("feed/" + strconv.FormatUint(uint64(user.ID), 10) + "/" + plugin.Name)
+		feed.Title = "First Sip — " + plugins.HumanizeName(plugin.Name)
+		feed.HomeURL = b.baseURL + "/plugins/" + plugin.Name
+	}
+
+	var runs []plugins.PluginRun
+	if err := query.Order("completed_at DESC").Limit(MaxEntries).Find(&runs).Error; err != nil {
+		return Feed{}, fmt.Errorf("feeds: list runs: %w", err)
+	}
+
+	seen := make(map[string]bool, len(runs))
+	for i := range runs {
+		e, ok := b.entryFromRun(&runs[i], loc)
+		if !ok {
+			continue
+		}
+		seen[e.ID] = true
+		feed.Entries = append(feed.Entries, e)
+	}
+
+	if pluginName == "" || pluginName == models.LegacyBriefingPluginName {
+		var legacy []models.Briefing
+		if err := db.Where("user_id = ? AND status = ? AND created_at >= ?", user.ID, models.BriefingStatusCompleted, cutoff).
+			Order("created_at DESC").
+			Limit(MaxEntries).
+			Find(&legacy).Error; err != nil {
+			return Feed{}, fmt.Errorf("feeds: list briefings: %w", err)
+		}
+		for i := range legacy {
+			e, ok := b.entryFromBriefing(&legacy[i], loc)
+			if !ok || seen[e.ID] {
+				continue // already published as its migrated plugin run
+			}
+			feed.Entries = append(feed.Entries, e)
+		}
+	}
+
+	sortEntries(feed.Entries)
+	if len(feed.Entries) > MaxEntries {
+		feed.Entries = feed.Entries[:MaxEntries]
+	}
+	feed.Updated = user.CreatedAt
+	if len(feed.Entries) > 0 {
+		feed.Updated = feed.Entries[0].Updated
+	}
+	return feed, nil
+}
+
+// entryFromRun converts a completed run; titles are dated in loc. Runs whose output cannot be parsed are skipped.
+func (b *Builder) entryFromRun(run *plugins.PluginRun, loc *time.Location) (Entry, bool) {
+	var out dashboard.PluginRunOutput
+	if err := json.Unmarshal(run.Output, &out); err != nil {
+		return Entry{}, false
+	}
+	completed := run.UpdatedAt
+	if run.CompletedAt != nil {
+		completed = *run.CompletedAt
+	}
+	return Entry{
+		ID:          b.tagURI("run/" + run.PluginRunID),
+		Title:       plugins.HumanizeName(run.Plugin.Name) + " — " + completed.In(loc).Format("Jan 2, 2006"),
+		URL:         b.baseURL + "/plugins/" + run.Plugin.Name,
+		Summary:     out.Summary,
+		ContentHTML: contentHTML(out),
+		Category:    run.Plugin.Name,
+		Published:   completed,
+		Updated:     completed,
+	}, true
+}
+
+// entryFromBriefing converts a legacy briefing. The ID matches the plugin run
+// the briefing becomes under briefings.MigrateToPluginRuns, so readers do not
+// see a duplicate after the migration.
+func (b *Builder) entryFromBriefing(br *models.Briefing, loc *time.Location) (Entry, bool) {
+	var content webhook.BriefingContent
+	if err := json.Unmarshal(br.Content, &content); err != nil {
+		return Entry{}, false
+	}
+	raw, err := webhook.ToPluginOutput(&content)
+	if err != nil {
+		return Entry{}, false
+	}
+	var out dashboard.PluginRunOutput
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return Entry{}, false
+	}
+	generated := br.UpdatedAt
+	if br.GeneratedAt != nil {
+		generated = *br.GeneratedAt
+	}
+	return Entry{
+		ID:          b.tagURI("run/" + briefings.LegacyRunID(br.ID)),
+		Title:       "Daily Briefing — " + generated.In(loc).Format("Jan 2, 2006"),
+		URL:         b.baseURL + "/history",
+		Summary:     out.Summary,
+		ContentHTML: contentHTML(out),
+		Category:    models.LegacyBriefingPluginName,
+		Published:   generated,
+		Updated:     generated,
+	}, true
+}
+
+// contentHTML joins output sections into one HTML fragment. Section content is
+// already-escaped HTML produced by the plugin, as on the dashboard.
+func contentHTML(out dashboard.PluginRunOutput) string {
+	var sb strings.Builder
+	if out.Summary != "" {
+		sb.WriteString("<p>" + html.EscapeString(out.Summary) + "</p>")
+	}
+	for _, sec := range out.Sections {
+		if sec.Title != "" {
+			sb.WriteString("<h3>" + html.EscapeString(sec.Title) + "</h3>")
+		}
+		sb.WriteString("<div>" + dashboard.SectionHTML(sec) + "</div>")
+	}
+	if len(out.Sections) == 0 && out.Content != "" {
+		sb.WriteString("<div>" + out.Content + "</div>")
+	}
+	return sb.String()
+}
+
+// tagURI builds a stable RFC 4151 tag URI scoped to the app's host.
+func (b *Builder) tagURI(specific string) string {
+	host := "localhost"
+	if u, err := url.Parse(b.baseURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return "tag:" + host + "," + tagDate + ":" + specific
+}
+
+// sortEntries orders entries newest first; ties break on ID for stable output.
+func sortEntries(entries []Entry) {
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		if c := b.Updated.Compare(a.Updated); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
@@ -0,0 +1,23 @@
+// Package feedsvm contains view model types for the private feeds settings page.
+// It is a leaf package (no internal imports) so that the templates package can
+// import it without creating an import cycle with the feeds package.
+package feedsvm
+
+// FeedLinks is one feed available in both formats.
+type FeedLinks struct {
+	Label   string // "All plugins" or the plugin display name
+	Icon    string
+	AtomURL string
+	JSONURL string
+}
+
+// FeedsPageViewModel is the view model passed to the FeedsSettingsPage template.
+type FeedsPageViewModel struct {
+	Combined      FeedLinks
+	Plugins       []FeedLinks
+	RetentionDays int
+
+	// Notice is a one-off status message (e.g. after rotating the token).
+	Notice        string
+	NoticeIsError bool
+}
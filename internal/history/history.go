@@ -0,0 +1,254 @@
+// Package history builds the unified timeline of a user's plugin runs and
+// legacy briefings, with keyset (cursor) pagination.
+package history
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/historyvm"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/gorm"
+)
+
+// PageSize is the number of entries per page.
+const PageSize = 10
+
+// DefaultLookbackDays is used when HISTORY_LOOKBACK_DAYS is unset.
+const DefaultLookbackDays = 30
+
+// lookbackChoices are offered in the lookback selector alongside the
+// configured default.
+var lookbackChoices = []int{7, 30, 90, 365}
+
+// failedRunStatuses are the terminal run statuses shown as failed.
+var failedRunStatuses = []string{"failed", "timed_out", "cancelled"}
+
+// ErrInvalidCursor is returned for a malformed or tampered cursor.
+var ErrInvalidCursor = errors.New("history: invalid cursor")
+
+// Cursor is the position of the last entry on a page. Entries are ordered by
+// (occurred_at, kind, id) descending, which is unique across both sources.
+type Cursor struct {
+	OccurredAt time.Time
+	Kind       string
+	ID         uint
+}
+
+// Encode serialises the cursor for use in a URL.
+func (c Cursor) Encode() string {
+	raw := strconv.FormatInt(c.OccurredAt.UnixMicro(), 10) + ":" + c.Kind + ":" + strconv.FormatUint(uint64(c.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor produced by Encode. An empty string yields nil.
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || (parts[1] != historyvm.KindRun && parts[1] != historyvm.KindBriefing) {
+		return nil, ErrInvalidCursor
+	}
+	micros, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{OccurredAt: time.UnixMicro(micros).UTC(), Kind: parts[1], ID: uint(id)}, nil
+}
+
+// LookbackOptions returns the selectable lookback periods, including defaultDays.
+func LookbackOptions(defaultDays int) []int {
+	opts := slices.Clone(lookbackChoices)
+	if !slices.Contains(opts, defaultDays) {
+		opts = append(opts, defaultDays)
+		slices.Sort(opts)
+	}
+	return opts
+}
+
+// ParseLookback returns the requested lookback in days, falling back to
+// defaultDays for missing or unsupported values.
+func ParseLookback(v string, defaultDays int) int {
+	days, err := strconv.Atoi(v)
+	if err != nil || !slices.Contains(LookbackOptions(defaultDays), days) {
+		return defaultDays
+	}
+	return days
+}
+
+// Query selects one page of a user's timeline.
+type Query struct {
+	UserID       uint
+	PluginName   string // empty for all plugins
+	LookbackDays int
+	Cursor       *Cursor
+}
+
+// row is the scan type for the union query.
+type row struct {
+	Kind        string
+	ID          uint
+	PluginRunID string
+	PluginName  string
+	Icon        string
+	Status      string
+	OccurredAt  time.Time
+	Summary     string
+	ReadAt      *time.Time
+}
+
+// Fetch returns one page of the timeline, newest first, and the cursor for
+// the next page (nil on the last page). Times are formatted in loc.
+func Fetch(db *gorm.DB, q Query, loc *time.Location, now time.Time) ([]historyvm.Entry, *Cursor, error) {
+	since := now.AddDate(0, 0, -q.LookbackDays)
+	runDate := "COALESCE(pr.completed_at, pr.created_at)"
+	briefingDate := "COALESCE(b.generated_at, b.created_at)"
+
+	runs := db.Table("plugin_runs pr").
+		Select("'"+historyvm.KindRun+"' AS kind, pr.id, pr.plugin_run_id, p.name AS plugin_name, p.icon, pr.status, "+
+			runDate+" AS occurred_at, COALESCE(pr.output->>'summary', '') AS summary, NULL::timestamptz AS read_at").
+		Joins("JOIN plugins p ON p.id = pr.plugin_id").
+		Where("pr.user_id = ? AND pr.deleted_at IS NULL", q.UserID).
+		Where("pr.status IN ?", append([]string{"completed"}, failedRunStatuses...)).
+		Where(runDate+" >= ?", since)
+	if q.PluginName != "" {
+		runs = runs.Where("p.name = ?", q.PluginName)
+	}
+
+	var rows []row
+	var err error
+	if q.PluginName == "" || q.PluginName == models.LegacyBriefingPluginName {
+		legacy := db.Table("briefings b").
+			Select("'"+historyvm.KindBriefing+"' AS kind, b.id, '' AS plugin_run_id, '"+models.LegacyBriefingPluginName+"' AS plugin_name, '' AS icon, b.status, "+
+				briefingDate+" AS occurred_at, '' AS summary, b.read_at").
+			Where("b.user_id = ? AND b.deleted_at IS NULL", q.UserID).
+			Where("b.status IN ?", []string{models.BriefingStatusCompleted, models.BriefingStatusFailed}).
+			Where(briefingDate+" >= ?", since).
+			Where("NOT EXISTS (SELECT 1 FROM plugin_runs m WHERE m.plugin_run_id = '" + models.LegacyBriefingRunIDPrefix + "' || b.id::text)")
+		err = page(db, "? UNION ALL ?", []interface{}{runs, legacy}, q.Cursor).Scan(&rows).Error
+	} else {
+		err = page(db, "?", []interface{}{runs}, q.Cursor).Scan(&rows).Error
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("history: query timeline: %w", err)
+	}
+
+	var next *Cursor
+	if len(rows) > PageSize {
+		rows = rows[:PageSize]
+		last := rows[len(rows)-1]
+		next = &Cursor{OccurredAt: last.OccurredAt, Kind: last.Kind, ID: last.ID}
+	}
+
+	entries := make([]historyvm.Entry, 0, len(rows))
+	for _, r := range rows {
+		entries = append(entries, toEntry(r, loc))
+	}
+	return entries, next, nil
+}
+
+// page wraps the source subqueries in the keyset-paginated outer query.
+func page(db *gorm.DB, sources string, args []interface{}, cursor *Cursor) *gorm.DB {
+	sql := "SELECT * FROM (" + sources + ") timeline"
+	if cursor != nil {
+		sql += " WHERE (occurred_at, kind, id) < (?, ?, ?)"
+		args = append(args, cursor.OccurredAt, cursor.Kind, cursor.ID)
+	}
+	sql += " ORDER BY occurred_at DESC, kind DESC, id DESC LIMIT ?"
+	args = append(args, PageSize+1)
+	return db.Raw(sql, args...)
+}
+
+// toEntry formats a row for display.
+func toEntry(r row, loc *time.Location) historyvm.Entry {
+	local := r.OccurredAt.In(loc)
+	e := historyvm.Entry{
+		Kind:        r.Kind,
+		ID:          r.ID,
+		PluginRunID: r.PluginRunID,
+		PluginName:  r.PluginName,
+		DisplayName: plugins.HumanizeName(r.PluginName),
+		Icon:        r.Icon,
+		Failed:      r.Status != "completed",
+		Day:         local.Format("2006-01-02"),
+		DateLabel:   local.Format("January 2, 2006"),
+		TimeLabel:   local.Format("3:04 PM"),
+		Summary:     r.Summary,
+	}
+	if r.Kind == historyvm.KindRun {
+		e.URL = "/plugins/" + url.PathEscape(r.PluginName) + "?run=" + url.QueryEscape(r.PluginRunID)
+	} else {
+		e.Unread = r.ReadAt == nil
+	}
+	return e
+}
+
+// BriefingEntry builds the timeline entry for a single legacy briefing, used
+// when re-rendering a card after it is marked read.
+func BriefingEntry(b *models.Briefing, loc *time.Location) historyvm.Entry {
+	occurred := b.CreatedAt
+	if b.GeneratedAt != nil {
+		occurred = *b.GeneratedAt
+	}
+	return toEntry(row{
+		Kind:       historyvm.KindBriefing,
+		ID:         b.ID,
+		PluginName: models.LegacyBriefingPluginName,
+		Status:     b.Status,
+		OccurredAt: occurred,
+		ReadAt:     b.ReadAt,
+	}, loc)
+}
+
+// NextURL builds the URL of the page after next. basePath is the fragment
+// endpoint; pluginName and days are carried over.
+func NextURL(basePath string, next *Cursor, pluginName string, days int, prevDay string) string {
+	if next == nil {
+		return ""
+	}
+	v := url.Values{}
+	v.Set("cursor", next.Encode())
+	v.Set("days", strconv.Itoa(days))
+	v.Set("prev_day", prevDay)
+	if pluginName != "" {
+		v.Set("plugin", pluginName)
+	}
+	return basePath + "?" + v.Encode()
+}
+
+// Load fetches one page and assembles the Timeline view model. basePath is
+// the HTMX fragment endpoint used for the next-page URL; prevDay is the Day of
+// the last entry already on screen (empty for the first page).
+func Load(db *gorm.DB, q Query, loc *time.Location, now time.Time, basePath, prevDay string, defaultDays int) (historyvm.Timeline, error) {
+	entries, next, err := Fetch(db, q, loc, now)
+	if err != nil {
+		return historyvm.Timeline{}, err
+	}
+	tl := historyvm.Timeline{
+		Entries:         entries,
+		PrevDay:         prevDay,
+		LookbackDays:    q.LookbackDays,
+		LookbackOptions: LookbackOptions(defaultDays),
+		PluginName:      q.PluginName,
+	}
+	if len(entries) > 0 {
+		tl.NextURL = NextURL(basePath, next, q.PluginName, q.LookbackDays, entries[len(entries)-1].Day)
+	}
+	return tl, nil
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/historyvm"
+	"github.com/jimdaga/first-sip/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{OccurredAt: time.Date(2026, 3, 2, 7, 15, 30, 123456000, time.UTC), Kind: historyvm.KindRun, ID: 42}
+	got, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !got.OccurredAt.Equal(c.OccurredAt) || got.Kind != c.Kind || got.ID != c.ID {
+		t.Errorf("round trip = %+v, want %+v", got, c)
+	}
+
+	if got, err := DecodeCursor(""); got != nil || err != nil {
+		t.Errorf("empty cursor = %v, %v; want nil, nil", got, err)
+	}
+	for _, bad := range []string{"!!", "MTIzOnJ1bg", "MTIzOmJvZ3VzOjE"} { // "123:run", "123:bogus:1"
+		if _, err := DecodeCursor(bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", bad, err)
+		}
+	}
+}
+
+func TestLookback(t *testing.T) {
+	if got := LookbackOptions(30); !reflect.DeepEqual(got, []int{7, 30, 90, 365}) {
+		t.Errorf("LookbackOptions(30) = %v", got)
+	}
+	if got := LookbackOptions(14); !reflect.DeepEqual(got, []int{7, 14, 30, 90, 365}) {
+		t.Errorf("LookbackOptions(14) = %v", got)
+	}
+	if got := ParseLookback("90", 30); got != 90 {
+		t.Errorf("ParseLookback(90) = %d", got)
+	}
+	if got := ParseLookback("5000", 30); got != 30 {
+		t.Errorf("ParseLookback(5000) = %d, want default", got)
+	}
+}
+
+func TestNextURL(t *testing.T) {
+	if got := NextURL("/api/history", nil, "", 30, "2026-03-02"); got != "" {
+		t.Errorf("NextURL(nil) = %q, want empty", got)
+	}
+	c := &Cursor{OccurredAt: time.Unix(1700000000, 0), Kind: historyvm.KindBriefing, ID: 7}
+	u, err := url.Parse(NextURL("/api/history", c, "daily-news", 90, "2026-03-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Path != "/api/history" || q.Get("cursor") != c.Encode() || q.Get("plugin") != "daily-news" || q.Get("days") != "90" || q.Get("prev_day") != "2026-03-02" {
+		t.Errorf("NextURL = %s", u)
+	}
+}
+
+func TestToEntry(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	occurred := time.Date(2026, 3, 2, 3, 30, 0, 0, time.UTC) // 10:30 PM Mar 1 in New York
+
+	run := toEntry(row{Kind: historyvm.KindRun, ID: 1, PluginRunID: "abc", PluginName: "daily-news", Status: "timed_out", OccurredAt: occurred}, loc)
+	if run.Day != "2026-03-01" || run.TimeLabel != "10:30 PM" || !run.Failed || run.URL != "/plugins/daily-news?run=abc" {
+		t.Errorf("run entry = %+v", run)
+	}
+
+	briefing := toEntry(row{Kind: historyvm.KindBriefing, ID: 2, PluginName: models.LegacyBriefingPluginName, Status: "completed", OccurredAt: occurred}, loc)
+	if !briefing.Unread || briefing.Failed || briefing.URL != "" || briefing.DisplayName != "Daily Briefing" {
+		t.Errorf("briefing entry = %+v", briefing)
+	}
+}
@@ -0,0 +1,51 @@
+// Package historyvm contains view model types for the unified history
+// timeline. It is a leaf package (no internal imports) so that the templates
+// package can import it without creating an import cycle with internal/history.
+package historyvm
+
+// Entry kinds.
+const (
+	KindRun      = "run"
+	KindBriefing = "briefing"
+)
+
+// Entry is one plugin run or legacy briefing on the timeline.
+type Entry struct {
+	Kind        string
+	ID          uint // plugin_runs.id or briefings.id, depending on Kind
+	PluginRunID string
+	PluginName  string
+	DisplayName string
+	Icon        string
+	Failed      bool
+	Unread      bool   // legacy briefings only
+	Day         string // YYYY-MM-DD in the user's timezone, for date grouping
+	DateLabel   string // e.g. "January 2, 2006"
+	TimeLabel   string // e.g. "3:04 PM"
+	Summary     string
+	URL         string // run detail link; empty for legacy briefings
+}
+
+// Timeline is one page of history entries.
+type Timeline struct {
+	Entries []Entry
+
+	// NextURL fetches the following page; empty on the last page.
+	NextURL string
+
+	// PrevDay is the Day of the last entry on the previous page, so a date
+	// heading is not repeated when a page continues the same day.
+	PrevDay string
+
+	LookbackDays    int
+	LookbackOptions []int
+	PluginName      string // set for a per-plugin timeline
+}
+
+// RunView is a past run selected from a plugin's history tab.
+type RunView struct {
+	PluginRunID string
+	DateLabel   string
+	Content     string // HTML built from the run's sections
+	Version     string // plugin version that produced the run; "" for older runs
+}
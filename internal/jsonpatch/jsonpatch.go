@@ -0,0 +1,288 @@
+// Package jsonpatch applies RFC 6902 JSON Patch documents to decoded JSON
+// values (map[string]interface{}, []interface{} and scalars). It is used for
+// plugin settings migrations and has no internal imports.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation is one JSON Patch operation. Value is used by add, replace and
+// test; From by move and copy.
+type Operation struct {
+	Op    string      `json:"op" yaml:"op"`
+	Path  string      `json:"path" yaml:"path"`
+	From  string      `json:"from,omitempty" yaml:"from"`
+	Value interface{} `json:"value,omitempty" yaml:"value"`
+}
+
+// ErrTestFailed is returned when a test operation does not match.
+var ErrTestFailed = errors.New("jsonpatch: test failed")
+
+// Decode parses a JSON Patch document and checks each operation's shape.
+func Decode(data []byte) ([]Operation, error) {
+	var ops []Operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("jsonpatch: %w", err)
+	}
+	for i, op := range ops {
+		if err := op.check(); err != nil {
+			return nil, fmt.Errorf("jsonpatch: operation %d: %w", i, err)
+		}
+	}
+	return ops, nil
+}
+
+func (o Operation) check() error {
+	switch o.Op {
+	case "add", "remove", "replace", "test":
+	case "move", "copy":
+		if _, err := parsePointer(o.From); err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown op %q", o.Op)
+	}
+	if _, err := parsePointer(o.Path); err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+	return nil
+}
+
+// Apply applies ops in order to a deep copy of doc and returns the result.
+// doc is not modified. The first failing operation aborts the patch.
+func Apply(doc interface{}, ops []Operation) (interface{}, error) {
+	out, err := clone(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if err := op.check(); err != nil {
+			return nil, fmt.Errorf("jsonpatch: operation %d: %w", i, err)
+		}
+		out, err = apply(out, op)
+		if err != nil {
+			return nil, fmt.Errorf("jsonpatch: operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return out, nil
+}
+
+func apply(doc interface{}, op Operation) (interface{}, error) {
+	path, _ := parsePointer(op.Path)
+	switch op.Op {
+	case "add":
+		v, err := clone(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		v, err := clone(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return v, nil
+		}
+		return mutate(doc, path, func(container interface{}, key string) (interface{}, error) {
+			switch c := container.(type) {
+			case map[string]interface{}:
+				c[key] = v
+				return c, nil
+			case []interface{}:
+				i, err := index(key, len(c)-1)
+				if err != nil {
+					return nil, err
+				}
+				c[i] = v
+				return c, nil
+			}
+			return nil, fmt.Errorf("cannot replace in %T", container)
+		})
+	case "move", "copy":
+		from, _ := parsePointer(op.From)
+		v, err := get(doc, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		if v, err = clone(v); err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		}
+		return add(doc, path, v)
+	case "test":
+		v, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		want, err := clone(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(v, want) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+func add(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return mutate(doc, path, func(container interface{}, key string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[key] = v
+			return c, nil
+		case []interface{}:
+			if key == "-" {
+				return append(c, v), nil
+			}
+			i, err := index(key, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = v
+			return c, nil
+		}
+		return nil, fmt.Errorf("cannot add to %T", container)
+	})
+}
+
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot remove the whole document")
+	}
+	return mutate(doc, path, func(container interface{}, key string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, ok := c[key]; !ok {
+				return nil, fmt.Errorf("%q not found", key)
+			}
+			delete(c, key)
+			return c, nil
+		case []interface{}:
+			i, err := index(key, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove from %T", container)
+	})
+}
+
+// mutate walks to the container holding the last path token, lets leaf
+// change it, and stores the (possibly reallocated) container back.
+func mutate(node interface{}, path []string, leaf func(container interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return leaf(node, path[0])
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("%q not found", path[0])
+		}
+		next, err := mutate(child, path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = next
+		return n, nil
+	case []interface{}:
+		i, err := index(path[0], len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		next, err := mutate(n[i], path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = next
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot traverse %T at %q", node, path[0])
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, key := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[key]
+			if !ok {
+				return nil, fmt.Errorf("%q not found", key)
+			}
+			node = v
+		case []interface{}:
+			i, err := index(key, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot traverse %T at %q", node, key)
+		}
+	}
+	return node, nil
+}
+
+// index parses an array index token, allowing 0..max.
+func index(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("pointer %q must start with /", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// clone deep-copies v through JSON, which also normalizes values decoded from
+// YAML (ints, typed slices) to their JSON forms.
+func clone(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("jsonpatch: %w", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("jsonpatch: %w", err)
+	}
+	return out, nil
+}
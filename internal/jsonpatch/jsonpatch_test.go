@@ -0,0 +1,81 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add key", `{"a":1}`, `[{"op":"add","path":"/b","value":[1]}]`, `{"a":1,"b":[1]}`},
+		{"append", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`},
+		{"insert", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{"remove", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{"replace nested", `{"a":{"b":"x"}}`, `[{"op":"replace","path":"/a/b","value":"y"}]`, `{"a":{"b":"y"}}`},
+		{"move (rename)", `{"topics":["t"]}`, `[{"op":"move","from":"/topics","path":"/interests"}]`, `{"interests":["t"]}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"escaped key", `{"a/b":1}`, `[{"op":"remove","path":"/a~1b"}]`, `{}`},
+		{"test then replace", `{"len":"long"}`, `[{"op":"test","path":"/len","value":"long"},{"op":"replace","path":"/len","value":"detailed"}]`, `{"len":"detailed"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := Decode([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			doc := decodeJSON(t, tt.doc)
+			got, err := Apply(doc, ops)
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("Apply = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(doc, decodeJSON(t, tt.doc)) {
+				t.Error("Apply modified its input")
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	doc := decodeJSON(t, `{"a":[1]}`)
+	for _, patch := range []string{
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"replace","path":"/a/5","value":1}]`,
+		`[{"op":"add","path":"/x/y","value":1}]`,
+		`[{"op":"move","from":"/nope","path":"/b"}]`,
+	} {
+		ops, err := Decode([]byte(patch))
+		if err != nil {
+			t.Fatalf("Decode(%s): %v", patch, err)
+		}
+		if _, err := Apply(doc, ops); err == nil {
+			t.Errorf("Apply(%s) = nil error", patch)
+		}
+	}
+
+	ops, _ := Decode([]byte(`[{"op":"test","path":"/a/0","value":2}]`))
+	if _, err := Apply(doc, ops); !errors.Is(err, ErrTestFailed) {
+		t.Errorf("failed test op = %v, want ErrTestFailed", err)
+	}
+
+	for _, patch := range []string{`[{"op":"frobnicate","path":"/a"}]`, `[{"op":"add","path":"a"}]`, `{}`} {
+		if _, err := Decode([]byte(patch)); err == nil {
+			t.Errorf("Decode(%s) = nil error", patch)
+		}
+	}
+}
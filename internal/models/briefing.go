@@ -15,6 +15,18 @@ const (
 	BriefingStatusFailed     = "failed"
 )
 
+// Legacy briefings are migrated onto runs of the n8n-backed plugin that
+// replaced briefing:generate.
+const (
+	// LegacyBriefingPluginName is the plugin legacy briefings are migrated to
+	// and listed under.
+	LegacyBriefingPluginName = "daily-briefing"
+	// LegacyBriefingRunIDPrefix makes migrated plugin_run_ids deterministic
+	// ("legacy-briefing-<briefing ID>") so the migration can be re-run safely
+	// and migrated briefings are not listed twice.
+	LegacyBriefingRunIDPrefix = "legacy-briefing-"
+)
+
 // Briefing represents a daily briefing with JSONB content and status lifecycle
 type Briefing struct {
 	gorm.Model
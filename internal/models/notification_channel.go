@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification channel kinds.
+const (
+	ChannelKindSlack   = "slack"
+	ChannelKindDiscord = "discord"
+	ChannelKindMatrix  = "matrix" // matrix-hookshot generic webhook
+)
+
+// ChannelKinds lists the supported kinds in display order.
+var ChannelKinds = []string{ChannelKindSlack, ChannelKindDiscord, ChannelKindMatrix}
+
+// NotificationChannel is a user's chat destination for plugin output. The
+// incoming-webhook URL is the credential, so it is encrypted at rest like
+// UserAPIKey values.
+type NotificationChannel struct {
+	gorm.Model
+	UserID     uint   `gorm:"not null;index"`
+	User       User   `gorm:"constraint:OnDelete:CASCADE;"`
+	Kind       string `gorm:"not null"`
+	Name       string `gorm:"not null;default:''"`
+	WebhookURL string `gorm:"column:encrypted_webhook_url;type:text;not null"` // stored encrypted
+}
+
+// BeforeSave encrypts the webhook URL before saving to database.
+func (ch *NotificationChannel) BeforeSave(tx *gorm.DB) error {
+	if encryptor == nil {
+		// Allow operations without encryption (e.g., for testing or if encryption not initialized)
+		return nil
+	}
+
+	if ch.WebhookURL != "" {
+		encrypted, err := encryptor.Encrypt(ch.WebhookURL)
+		if err != nil {
+			return err
+		}
+		ch.WebhookURL = encrypted
+	}
+
+	return nil
+}
+
+// AfterFind decrypts the webhook URL after loading from database.
+func (ch *NotificationChannel) AfterFind(tx *gorm.DB) error {
+	if encryptor == nil {
+		// Allow operations without encryption
+		return nil
+	}
+
+	if ch.WebhookURL != "" {
+		decrypted, err := encryptor.Decrypt(ch.WebhookURL)
+		if err != nil {
+			return err
+		}
+		ch.WebhookURL = decrypted
+	}
+
+	return nil
+}
+
+// NotificationChannelPlugin routes one plugin's output to one channel.
+type NotificationChannelPlugin struct {
+	ID                    uint      `gorm:"primarykey"`
+	CreatedAt             time.Time `gorm:"not null"`
+	UserID                uint      `gorm:"not null"`
+	NotificationChannelID uint      `gorm:"not null"`
+	PluginID              uint      `gorm:"not null"`
+}
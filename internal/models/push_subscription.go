@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PushSubscription is a browser's Web Push subscription (one per device).
+// Endpoint is the push service URL; P256dh and Auth are the base64url keys
+// used to encrypt payloads for that browser.
+type PushSubscription struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+	UserID    uint      `gorm:"not null;index"`
+	User      User      `gorm:"constraint:OnDelete:CASCADE;"`
+	Endpoint  string    `gorm:"type:text;not null;uniqueIndex"`
+	P256dh    string    `gorm:"column:p256dh;not null"`
+	Auth      string    `gorm:"not null"`
+	UserAgent string    `gorm:"not null;default:''"`
+}
@@ -23,6 +23,15 @@ type User struct {
 	LLMPreferredProvider string `gorm:"not null;default:''"`
 	LLMPreferredModel    string `gorm:"not null;default:''"`
 
+	// Email digest preferences. The digest is sent daily at PreferredBriefingTime
+	// in Timezone. EmailUnsubscribeToken backs the unauthenticated unsubscribe link.
+	EmailDigestEnabled    bool    `gorm:"not null;default:false"`
+	EmailUnsubscribeToken *string `gorm:"size:64"`
+	LastDigestSentAt      *time.Time
+
+	// FeedToken authenticates the user's private Atom / JSON Feed URLs.
+	FeedToken *string `gorm:"size:64"`
+
 	// Associations
 	AuthIdentities []AuthIdentity `gorm:"constraint:OnDelete:CASCADE;"`
 	Briefings      []Briefing     `gorm:"constraint:OnDelete:CASCADE;"`
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// UserPluginSecret stores an encrypted value a user saved for one of a
+// plugin's declared secret slots (plugin.yaml `secrets:`).
+type UserPluginSecret struct {
+	gorm.Model
+	UserID         uint   `gorm:"not null;index"`
+	User           User   `gorm:"constraint:OnDelete:CASCADE;"`
+	PluginID       uint   `gorm:"not null;index"`
+	Name           string `gorm:"not null"`           // slot name from the manifest
+	EncryptedValue string `gorm:"type:text;not null"` // stored encrypted
+}
+
+// BeforeSave encrypts the secret value before saving to database.
+func (s *UserPluginSecret) BeforeSave(tx *gorm.DB) error {
+	if encryptor == nil {
+		// Allow operations without encryption (e.g., for testing or if encryption not initialized)
+		return nil
+	}
+
+	if s.EncryptedValue != "" {
+		encrypted, err := encryptor.Encrypt(s.EncryptedValue)
+		if err != nil {
+			return err
+		}
+		s.EncryptedValue = encrypted
+	}
+
+	return nil
+}
+
+// AfterFind decrypts the secret value after loading from database
+func (s *UserPluginSecret) AfterFind(tx *gorm.DB) error {
+	if encryptor == nil {
+		// Allow operations without encryption
+		return nil
+	}
+
+	if s.EncryptedValue != "" {
+		decrypted, err := encryptor.Decrypt(s.EncryptedValue)
+		if err != nil {
+			return err
+		}
+		s.EncryptedValue = decrypted
+	}
+
+	return nil
+}
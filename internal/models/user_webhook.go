@@ -0,0 +1,103 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Outbound webhook event types
+const (
+	WebhookEventRunCompleted  = "run.completed"
+	WebhookEventRunFailed     = "run.failed"
+	WebhookEventBriefingReady = "briefing.ready"
+	WebhookEventTest          = "test"
+)
+
+// WebhookEventTypes lists the event types a user can subscribe to.
+var WebhookEventTypes = []string{WebhookEventRunCompleted, WebhookEventRunFailed, WebhookEventBriefingReady}
+
+// UserWebhook is a user-registered URL that receives signed event payloads.
+// URL and Secret are encrypted at rest: webhook URLs (Slack, Discord, home
+// automation) frequently embed credentials.
+type UserWebhook struct {
+	gorm.Model
+	UserID      uint   `gorm:"not null;index"`
+	User        User   `gorm:"constraint:OnDelete:CASCADE;"`
+	Description string `gorm:"not null;default:''"`
+	URL         string `gorm:"column:encrypted_url;type:text;not null"`    // stored encrypted
+	Secret      string `gorm:"column:encrypted_secret;type:text;not null"` // stored encrypted
+	Events      string `gorm:"not null;default:''"`                        // comma-separated event types
+}
+
+// Subscribes reports whether the webhook should receive the given event type.
+// Test events are always delivered.
+func (w *UserWebhook) Subscribes(eventType string) bool {
+	if eventType == WebhookEventTest {
+		return true
+	}
+	for _, e := range strings.Split(w.Events, ",") {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// BeforeSave encrypts the URL and signing secret before saving to database.
+func (w *UserWebhook) BeforeSave(tx *gorm.DB) error {
+	if encryptor == nil {
+		// Allow operations without encryption (e.g., for testing or if encryption not initialized)
+		return nil
+	}
+
+	for _, field := range []*string{&w.URL, &w.Secret} {
+		if *field == "" {
+			continue
+		}
+		encrypted, err := encryptor.Encrypt(*field)
+		if err != nil {
+			return err
+		}
+		*field = encrypted
+	}
+
+	return nil
+}
+
+// AfterFind decrypts the URL and signing secret after loading from database.
+func (w *UserWebhook) AfterFind(tx *gorm.DB) error {
+	if encryptor == nil {
+		// Allow operations without encryption
+		return nil
+	}
+
+	for _, field := range []*string{&w.URL, &w.Secret} {
+		if *field == "" {
+			continue
+		}
+		decrypted, err := encryptor.Decrypt(*field)
+		if err != nil {
+			return err
+		}
+		*field = decrypted
+	}
+
+	return nil
+}
+
+// WebhookDelivery records a single delivery attempt to a UserWebhook.
+// Rows are append-only.
+type WebhookDelivery struct {
+	ID            uint      `gorm:"primarykey"`
+	CreatedAt     time.Time `gorm:"not null"`
+	UserWebhookID uint      `gorm:"not null;index"`
+	EventID       string    `gorm:"not null;index"`
+	EventType     string    `gorm:"not null"`
+	Attempt       int       `gorm:"not null;default:1"`
+	StatusCode    int       `gorm:"not null;default:0"` // 0 when no response was received
+	Success       bool      `gorm:"not null;default:false"`
+	ErrorMessage  string    `gorm:"column:error_message;type:text"`
+	DurationMs    int       `gorm:"column:duration_ms;not null;default:0"`
+}
@@ -0,0 +1,255 @@
+// Package pluginbundle implements installable plugin bundles: a gzipped tar
+// of one plugin directory (plugin.yaml, settings schema, crew), signed by its
+// publisher with an ed25519 key. Bundles are built with `first-sip plugin
+// pack` and installed into PLUGIN_DIR through the admin install API.
+package pluginbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestName is the file every bundle must contain at its root.
+const ManifestName = "plugin.yaml"
+
+// maxEntries bounds the number of files and directories in a bundle.
+const maxEntries = 1000
+
+// ErrUntrusted is returned by Verify when no trusted key signed the bundle.
+var ErrUntrusted = errors.New("pluginbundle: signature does not match any trusted publisher key")
+
+// TrustedKeys maps a publisher name to its ed25519 public key.
+type TrustedKeys map[string]ed25519.PublicKey
+
+// ParseTrustedKeys parses a comma-separated list of publisher=base64-key
+// pairs, as set in PLUGIN_TRUSTED_KEYS. An empty spec yields no keys.
+func ParseTrustedKeys(spec string) (TrustedKeys, error) {
+	keys := make(TrustedKeys)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, encoded, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("pluginbundle: trusted key %q is not publisher=base64-key", pair)
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("pluginbundle: trusted key for %s is not a base64 ed25519 public key", name)
+		}
+		keys[name] = ed25519.PublicKey(raw)
+	}
+	return keys, nil
+}
+
+// Verify checks sig over bundle against every trusted key and returns the
+// name of the publisher whose key matches.
+func (k TrustedKeys) Verify(bundle, sig []byte) (publisher string, err error) {
+	if len(sig) != ed25519.SignatureSize {
+		return "", fmt.Errorf("pluginbundle: signature must be %d bytes, got %d", ed25519.SignatureSize, len(sig))
+	}
+	names := make([]string, 0, len(k))
+	for name := range k {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ed25519.Verify(k[name], bundle, sig) {
+			return name, nil
+		}
+	}
+	return "", ErrUntrusted
+}
+
+// Sign returns the base64-encoded ed25519 signature of bundle, the format
+// written to .sig files.
+func Sign(bundle []byte, key ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, bundle)) + "\n")
+}
+
+// DecodeSignature accepts a raw 64-byte signature or its base64 encoding.
+func DecodeSignature(data []byte) ([]byte, error) {
+	if len(data) == ed25519.SignatureSize {
+		return data, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("pluginbundle: signature is neither raw nor base64: %w", err)
+	}
+	return raw, nil
+}
+
+// DecodePrivateKey reads a base64 ed25519 private key (or 32-byte seed), as
+// written by `first-sip plugin keygen`.
+func DecodePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("pluginbundle: private key is not base64: %w", err)
+	}
+	switch len(raw) {
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	}
+	return nil, fmt.Errorf("pluginbundle: private key has %d bytes, want %d", len(raw), ed25519.PrivateKeySize)
+}
+
+// skipPacking reports whether a file or directory is left out of bundles:
+// dotfiles (.git, .env) and Python bytecode caches.
+func skipPacking(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "__pycache__"
+}
+
+// Pack writes dir as a bundle to w. Entries are sorted with zeroed
+// timestamps and owners, so packing the same tree twice yields the same
+// bytes (and signature).
+func Pack(dir string, w io.Writer) error {
+	if _, err := os.Stat(filepath.Join(dir, ManifestName)); err != nil {
+		return fmt.Errorf("pluginbundle: %s: %w", ManifestName, err)
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if skipPacking(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Name: filepath.ToSlash(rel), Mode: 0o644, Format: tar.FormatPAX}
+		switch {
+		case d.IsDir():
+			hdr.Typeflag, hdr.Name, hdr.Mode = tar.TypeDir, hdr.Name+"/", 0o755
+			return tw.WriteHeader(hdr)
+		case info.Mode().IsRegular():
+			hdr.Typeflag, hdr.Size = tar.TypeReg, info.Size()
+			if info.Mode()&0o111 != 0 {
+				hdr.Mode = 0o755
+			}
+		default:
+			return fmt.Errorf("pluginbundle: %s: only regular files and directories can be packed", rel)
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Unpack extracts a bundle into dest, which must not exist. Only regular
+// files and directories are allowed; absolute paths, ".." components and
+// links are rejected, and the uncompressed size is capped at maxBytes.
+func Unpack(r io.Reader, dest string, maxBytes int64) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("pluginbundle: not a gzip file: %w", err)
+	}
+	defer gz.Close()
+	if err := os.Mkdir(dest, 0o755); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gz)
+	var total int64
+	for entries := 0; ; entries++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("pluginbundle: read archive: %w", err)
+		}
+		if entries >= maxEntries {
+			return fmt.Errorf("pluginbundle: more than %d entries", maxEntries)
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if name == "." {
+			continue
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("pluginbundle: entry %q escapes the bundle", hdr.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			total += hdr.Size
+			if total > maxBytes {
+				return fmt.Errorf("pluginbundle: unpacked size exceeds %d bytes", maxBytes)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			mode := os.FileMode(0o644)
+			if hdr.Mode&0o111 != 0 {
+				mode = 0o755
+			}
+			if err := writeFile(target, tr, hdr.Size, mode); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("pluginbundle: entry %q is not a regular file or directory", hdr.Name)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dest, ManifestName)); err != nil {
+		return fmt.Errorf("pluginbundle: bundle has no %s at its root", ManifestName)
+	}
+	return nil
+}
+
+func writeFile(target string, r io.Reader, size int64, mode os.FileMode) error {
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, r, size); err != nil {
+		f.Close()
+		return fmt.Errorf("pluginbundle: extract %s: %w", filepath.Base(target), err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,174 @@
+package pluginbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePlugin(t *testing.T, dir, version string) {
+	t.Helper()
+	files := map[string]string{
+		"plugin.yaml":          "name: weather-watch\nowner: acme\nversion: \"" + version + "\"\nsettings_schema_path: settings.schema.json\n",
+		"settings.schema.json": `{"type":"object","properties":{"city":{"type":"string"}}}`,
+		"crew/crew.py":         "def create_crew(settings):\n    pass\n",
+		".env":                 "SECRET=1\n",
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPackVerifyInstall(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	keys, err := ParseTrustedKeys("acme=" + base64.StdEncoding.EncodeToString(pub))
+	if err != nil {
+		t.Fatalf("ParseTrustedKeys: %v", err)
+	}
+
+	src := t.TempDir()
+	writePlugin(t, src, "1.0.0")
+	var buf bytes.Buffer
+	if err := Pack(src, &buf); err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	bundle := buf.Bytes()
+
+	var again bytes.Buffer
+	if err := Pack(src, &again); err != nil || !bytes.Equal(bundle, again.Bytes()) {
+		t.Error("Pack is not deterministic")
+	}
+
+	sig, err := DecodeSignature(Sign(bundle, priv))
+	if err != nil {
+		t.Fatalf("DecodeSignature: %v", err)
+	}
+	if publisher, err := keys.Verify(bundle, sig); err != nil || publisher != "acme" {
+		t.Fatalf("Verify = %q, %v; want acme", publisher, err)
+	}
+	tampered := append([]byte{}, bundle...)
+	tampered[len(tampered)/2] ^= 0xff
+	if _, err := keys.Verify(tampered, sig); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("Verify(tampered) = %v, want ErrUntrusted", err)
+	}
+
+	pluginDir := t.TempDir()
+	meta, replaced, err := Install(pluginDir, bundle, 1<<20, "acme")
+	if err != nil || meta.Name != "weather-watch" || replaced {
+		t.Fatalf("Install = %v, %v, %v", meta, replaced, err)
+	}
+	if _, err := os.Stat(filepath.Join(pluginDir, "weather-watch", "crew", "crew.py")); err != nil {
+		t.Errorf("crew not installed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(pluginDir, "weather-watch", ".env")); !os.IsNotExist(err) {
+		t.Error("dotfile was packed")
+	}
+
+	writePlugin(t, src, "1.1.0")
+	buf.Reset()
+	if err := Pack(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+	meta, replaced, err = Install(pluginDir, buf.Bytes(), 1<<20, "acme")
+	if err != nil || meta.Version != "1.1.0" || !replaced {
+		t.Fatalf("reinstall = %v, %v, %v", meta, replaced, err)
+	}
+	entries, _ := os.ReadDir(pluginDir)
+	if len(entries) != 1 {
+		t.Errorf("plugin dir has %d entries after reinstall, want 1 (staging left behind?)", len(entries))
+	}
+
+	if _, _, err := Install(pluginDir, bundle, 10, "acme"); err == nil {
+		t.Error("Install accepted a bundle over maxBytes")
+	}
+}
+
+func TestInstallRequiresOwnership(t *testing.T) {
+	src := t.TempDir()
+	writePlugin(t, src, "1.0.0")
+	var buf bytes.Buffer
+	if err := Pack(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+	bundle := buf.Bytes()
+
+	pluginDir := t.TempDir()
+	if _, _, err := Install(pluginDir, bundle, 1<<20, "mallory"); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("install signed by another publisher = %v, want ErrNotOwner", err)
+	}
+
+	// A plugin shipped in PLUGIN_DIR (no publisher recorded) is not replaced.
+	shipped := filepath.Join(pluginDir, "weather-watch")
+	writePlugin(t, shipped, "0.9.0")
+	if _, _, err := Install(pluginDir, bundle, 1<<20, "acme"); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("replacing a shipped plugin = %v, want ErrNotOwner", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(shipped, "plugin.yaml")); !bytes.Contains(data, []byte("0.9.0")) {
+		t.Error("shipped plugin was modified")
+	}
+
+	// Another publisher's installed plugin is not replaced either.
+	if err := os.WriteFile(filepath.Join(shipped, PublisherFile), []byte("globex\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Install(pluginDir, bundle, 1<<20, "acme"); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("replacing globex's plugin = %v, want ErrNotOwner", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(shipped, PublisherFile), []byte("acme\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, replaced, err := Install(pluginDir, bundle, 1<<20, "acme"); err != nil || !replaced {
+		t.Errorf("replacing acme's own plugin = %v, %v", replaced, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(shipped, PublisherFile)); string(data) != "acme\n" {
+		t.Errorf("%s = %q, want acme", PublisherFile, data)
+	}
+}
+
+func TestUnpackRejectsUnsafeEntries(t *testing.T) {
+	for name, hdr := range map[string]*tar.Header{
+		"traversal": {Name: "../evil", Typeflag: tar.TypeReg, Size: 1, Mode: 0o644},
+		"absolute":  {Name: "/etc/evil", Typeflag: tar.TypeReg, Size: 1, Mode: 0o644},
+		"symlink":   {Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	} {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			tw.Write([]byte("x"))
+		}
+		tw.Close()
+		gz.Close()
+
+		if err := Unpack(&buf, filepath.Join(t.TempDir(), "out"), 1<<20); err == nil {
+			t.Errorf("%s: Unpack accepted %q", name, hdr.Name)
+		}
+	}
+}
+
+func TestParseTrustedKeys(t *testing.T) {
+	if keys, err := ParseTrustedKeys(""); err != nil || len(keys) != 0 {
+		t.Errorf("ParseTrustedKeys(\"\") = %v, %v", keys, err)
+	}
+	for _, bad := range []string{"acme", "acme=notbase64!", "=" + base64.StdEncoding.EncodeToString(make([]byte, 32)), "acme=" + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParseTrustedKeys(bad); err == nil {
+			t.Errorf("ParseTrustedKeys(%q) = nil error", bad)
+		}
+	}
+}
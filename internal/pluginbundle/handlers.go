@@ -0,0 +1,120 @@
+package pluginbundle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/plugins"
+)
+
+// ReloadFunc reloads the plugin registry (and database) after an install.
+type ReloadFunc func(ctx context.Context) (plugins.ReloadSummary, error)
+
+// multipartOverhead is allowed on top of the bundle size for the signature
+// and form encoding.
+const multipartOverhead = 64 << 10
+
+// InstallHandler returns a Gin handler for POST /api/admin/plugins/install.
+// The multipart form carries the bundle file in "bundle" and its signature in
+// "signature", either as a file (the .sig written by `plugin pack`) or as a
+// base64 form value. The signature must verify against one of keys before
+// anything is written. The bundle is then unpacked into pluginDir and the
+// registry reloaded. A publisher can only install plugins it owns (see Install).
+func InstallHandler(keys TrustedKeys, pluginDir string, maxBytes int64, reload ReloadFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+
+		file, err := c.FormFile("bundle")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bundle file is required"})
+			return
+		}
+		if file.Size > maxBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "bundle is too large"})
+			return
+		}
+		bundle, err := readFormFile(file)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read bundle"})
+			return
+		}
+
+		sigData := []byte(c.PostForm("signature"))
+		if sigFile, err := c.FormFile("signature"); err == nil {
+			if sigData, err = readFormFile(sigFile); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read signature"})
+				return
+			}
+		}
+		if len(sigData) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "signature is required"})
+			return
+		}
+		sig, err := DecodeSignature(sigData)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		admin := c.GetString("user_email")
+		publisher, err := keys.Verify(bundle, sig)
+		if err != nil {
+			slog.Warn("pluginbundle: rejected bundle with untrusted signature", "admin", admin, "file", file.Filename, "error", err)
+			status := http.StatusBadRequest
+			if errors.Is(err, ErrUntrusted) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		meta, replaced, err := Install(pluginDir, bundle, maxBytes, publisher)
+		if err != nil {
+			slog.Warn("pluginbundle: install failed", "admin", admin, "publisher", publisher, "file", file.Filename, "error", err)
+			status := http.StatusUnprocessableEntity
+			if errors.Is(err, ErrNotOwner) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		slog.Info("Plugin bundle installed",
+			"plugin", meta.Name,
+			"version", meta.Version,
+			"publisher", publisher,
+			"admin", admin,
+			"replaced", replaced,
+		)
+
+		summary, err := reload(c.Request.Context())
+		if err != nil {
+			slog.Error("pluginbundle: reload after install failed", "plugin", meta.Name, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "plugin installed but registry reload failed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"name":      meta.Name,
+			"version":   meta.Version,
+			"publisher": publisher,
+			"replaced":  replaced,
+			"added":     summary.Added,
+			"updated":   summary.Updated,
+			"kept":      summary.Kept,
+		})
+	}
+}
+
+func readFormFile(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,144 @@
+package pluginbundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/kaptinlin/jsonschema"
+)
+
+// namePattern matches plugin names; it mirrors the check in pluginctl. The
+// name becomes the directory under PLUGIN_DIR, so it must be path-safe.
+var namePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// PublisherFile records, inside an installed plugin's directory, the
+// publisher whose key signed the bundle. Dotfiles are never packed, so a
+// bundle cannot carry its own.
+const PublisherFile = ".publisher"
+
+// ErrNotOwner is returned by Install when the signing publisher may not
+// install or replace the plugin.
+var ErrNotOwner = errors.New("pluginbundle: publisher does not own the plugin")
+
+// installMu serializes installs within a process so two uploads of the same
+// plugin cannot interleave their directory swaps.
+var installMu sync.Mutex
+
+// Install unpacks a bundle verified as signed by publisher into
+// pluginDir/<name>, replacing any existing version of the plugin, and returns
+// the bundle's manifest and whether a previous version was replaced. The
+// bundle is staged in a hidden directory inside pluginDir and moved into place
+// only after its manifest and settings schema validate; the registry is not
+// reloaded.
+//
+// A publisher may only install plugins whose manifest owner is the publisher
+// itself, and may only replace plugins it installed: plugins shipped in
+// PLUGIN_DIR without a PublisherFile, or installed by another publisher, are
+// refused.
+func Install(pluginDir string, bundle []byte, maxBytes int64, publisher string) (*plugins.PluginMetadata, bool, error) {
+	installMu.Lock()
+	defer installMu.Unlock()
+
+	staging, err := os.MkdirTemp(pluginDir, ".install-")
+	if err != nil {
+		return nil, false, fmt.Errorf("pluginbundle: create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	unpacked := filepath.Join(staging, "bundle")
+	if err := Unpack(bytes.NewReader(bundle), unpacked, maxBytes); err != nil {
+		return nil, false, err
+	}
+	meta, err := checkUnpacked(unpacked)
+	if err != nil {
+		return nil, false, err
+	}
+	if meta.Owner != publisher {
+		return nil, false, fmt.Errorf("%w: owner %q does not match signing publisher %q", ErrNotOwner, meta.Owner, publisher)
+	}
+
+	target := filepath.Join(pluginDir, meta.Name)
+	if _, err := os.Stat(target); err == nil {
+		if err := checkReplaceable(target, meta.Name, publisher); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(unpacked, PublisherFile), []byte(publisher+"\n"), 0o644); err != nil {
+		return nil, false, fmt.Errorf("pluginbundle: record publisher: %w", err)
+	}
+
+	previous := filepath.Join(staging, "previous")
+	replaced := false
+	if _, err := os.Stat(target); err == nil {
+		if err := os.Rename(target, previous); err != nil {
+			return nil, false, fmt.Errorf("pluginbundle: move previous version aside: %w", err)
+		}
+		replaced = true
+	}
+	if err := os.Rename(unpacked, target); err != nil {
+		if replaced {
+			_ = os.Rename(previous, target)
+		}
+		return nil, false, fmt.Errorf("pluginbundle: move plugin into place: %w", err)
+	}
+
+	meta.Dir = target
+	return meta, replaced, nil
+}
+
+// checkReplaceable refuses to replace the installed plugin in dir unless
+// publisher installed it.
+func checkReplaceable(dir, name, publisher string) error {
+	data, err := os.ReadFile(filepath.Join(dir, PublisherFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s was not installed from a bundle and cannot be replaced", ErrNotOwner, name)
+	}
+	if err != nil {
+		return fmt.Errorf("pluginbundle: read publisher of %s: %w", name, err)
+	}
+	if owner := strings.TrimSpace(string(data)); owner != publisher {
+		return fmt.Errorf("%w: %s was installed by publisher %q", ErrNotOwner, name, owner)
+	}
+	return nil
+}
+
+// checkUnpacked validates an unpacked bundle the way plugin discovery will:
+// a strict manifest with a path-safe name that no compiled-in plugin uses,
+// and, if declared, a settings schema inside the bundle that compiles.
+func checkUnpacked(dir string) (*plugins.PluginMetadata, error) {
+	meta, err := plugins.LoadPluginMetadata(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("pluginbundle: %w", err)
+	}
+	if !namePattern.MatchString(meta.Name) {
+		return nil, fmt.Errorf("pluginbundle: plugin name %q must be lowercase letters, digits and single hyphens", meta.Name)
+	}
+	if meta.Runtime == plugins.RuntimeGoNative {
+		return nil, fmt.Errorf("pluginbundle: runtime %s plugins are compiled into the binary and cannot be installed", meta.Runtime)
+	}
+	if plugins.IsCompiled(meta.Name) {
+		return nil, fmt.Errorf("pluginbundle: %s is a compiled-in plugin", meta.Name)
+	}
+
+	if meta.SettingsSchemaPath != "" {
+		schemaPath := filepath.Join(dir, meta.SettingsSchemaPath)
+		if rel, err := filepath.Rel(dir, schemaPath); err != nil || strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("pluginbundle: settings_schema_path %q is outside the bundle", meta.SettingsSchemaPath)
+		}
+		data, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("pluginbundle: read settings schema: %w", err)
+		}
+		if _, err := jsonschema.NewCompiler().Compile(data); err != nil {
+			return nil, fmt.Errorf("pluginbundle: settings schema does not compile: %w", err)
+		}
+	}
+	return meta, nil
+}
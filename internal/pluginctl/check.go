@@ -0,0 +1,287 @@
+package pluginctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/kaptinlin/jsonschema"
+)
+
+// Severity levels for a Problem. Errors make validate and lint fail;
+// warnings are reported by lint only.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is one finding about a plugin directory.
+type Problem struct {
+	Severity string
+	Field    string // manifest field or file the problem is about
+	Message  string
+}
+
+func (p Problem) String() string {
+	if p.Field == "" {
+		return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Severity, p.Field, p.Message)
+}
+
+var (
+	// namePattern matches plugin names: they appear in URLs and, for the
+	// sidecar, must equal the directory name.
+	namePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	// semverPattern is deliberately loose: MAJOR.MINOR.PATCH with an optional suffix.
+	semverPattern = regexp.MustCompile(`^\d+\.\d+\.\d+([-+].+)?$`)
+	// createCrewPattern finds the factory the sidecar imports from crew/crew.py.
+	createCrewPattern = regexp.MustCompile(`(?m)^def create_crew\(`)
+)
+
+// Check inspects the plugin in dir and returns its problems. Warnings are
+// included only when lint is true.
+func Check(dir string, lint bool) []Problem {
+	c := &checker{dir: dir, lint: lint}
+	c.run()
+	return c.problems
+}
+
+// HasErrors reports whether any problem is an error.
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+type checker struct {
+	dir      string
+	lint     bool
+	problems []Problem
+}
+
+func (c *checker) errorf(field, format string, args ...any) {
+	c.problems = append(c.problems, Problem{SeverityError, field, fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) warnf(field, format string, args ...any) {
+	if c.lint {
+		c.problems = append(c.problems, Problem{SeverityWarning, field, fmt.Sprintf(format, args...)})
+	}
+}
+
+func (c *checker) run() {
+	manifest := filepath.Join(c.dir, "plugin.yaml")
+	if _, err := os.Stat(manifest); err != nil {
+		c.errorf("plugin.yaml", "not found in %s", c.dir)
+		return
+	}
+	// LoadPluginMetadata covers unknown fields, required fields, tile_size,
+	// capabilities, runtime configuration and the output schema.
+	meta, err := plugins.LoadPluginMetadata(manifest)
+	if err != nil {
+		c.errorf("plugin.yaml", "%v", err)
+		return
+	}
+
+	c.checkIdentity(meta)
+	schema := c.checkSettingsSchema(meta)
+	c.checkDefaultConfig(meta, schema)
+	c.checkCacheIgnore(meta, schema)
+	c.checkRuntime(meta)
+}
+
+func (c *checker) checkIdentity(meta *plugins.PluginMetadata) {
+	if !namePattern.MatchString(meta.Name) {
+		c.errorf("name", "%q must be lowercase letters, digits and single hyphens", meta.Name)
+	}
+	if base := filepath.Base(filepath.Clean(c.dir)); meta.Name != base {
+		c.errorf("name", "%q does not match the plugin directory %q", meta.Name, base)
+	}
+	if !semverPattern.MatchString(meta.Version) {
+		c.warnf("version", "%q is not MAJOR.MINOR.PATCH", meta.Version)
+	}
+	if strings.TrimSpace(meta.Description) == "" {
+		c.warnf("description", "is empty; it is shown in plugin settings")
+	}
+	if meta.Owner == "" {
+		c.warnf("owner", "is empty")
+	}
+	if meta.Icon == "" {
+		c.warnf("icon", "is empty; the dashboard tile will have no icon")
+	}
+	if len(meta.Capabilities) == 0 {
+		c.warnf("capabilities", "none declared")
+	}
+	if meta.Runtime == plugins.RuntimeCrewAIStream && len(meta.DeclaredSecrets()) == 0 {
+		c.warnf("requires_secrets", "none declared; crews usually need the user's llm key, and undeclared keys are not passed to runs")
+	}
+	if !meta.Network && (meta.RequiresSecret(plugins.SecretTavily) || containsString(meta.OptionalSecrets, plugins.SecretTavily)) {
+		c.warnf("network", "tavily is declared but network is not; web search needs network: true")
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSettingsSchema reads and compiles the settings schema. Returns nil
+// when the plugin has none or it is broken.
+func (c *checker) checkSettingsSchema(meta *plugins.PluginMetadata) *jsonschema.Schema {
+	const field = "settings_schema_path"
+	if meta.SettingsSchemaPath == "" {
+		return nil
+	}
+	rel := filepath.Clean(meta.SettingsSchemaPath)
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		c.errorf(field, "%q must be a path inside the plugin directory", meta.SettingsSchemaPath)
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, rel))
+	if err != nil {
+		c.errorf(field, "%v", err)
+		return nil
+	}
+	if !json.Valid(data) {
+		c.errorf(field, "%s is not valid JSON", rel)
+		return nil
+	}
+	schema, err := jsonschema.NewCompiler().Compile(data)
+	if err != nil {
+		c.errorf(field, "compile %s: %v", rel, err)
+		return nil
+	}
+
+	if len(schema.Type) > 0 && schema.Type[0] != "object" {
+		c.errorf(field, "top-level type must be object, got %q", schema.Type[0])
+	}
+	if schema.Properties != nil {
+		keys := make([]string, 0, len(*schema.Properties))
+		for k := range *schema.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			prop := (*schema.Properties)[k]
+			if strings.HasPrefix(k, "_") {
+				continue // reserved keys such as _llm_model
+			}
+			if prop.Description == nil || *prop.Description == "" {
+				c.warnf(field, "property %q has no description; it is the field's help text", k)
+			}
+		}
+	}
+	return schema
+}
+
+// checkDefaultConfig validates default_config against the settings schema.
+func (c *checker) checkDefaultConfig(meta *plugins.PluginMetadata, schema *jsonschema.Schema) {
+	const field = "default_config"
+	if schema == nil {
+		if len(meta.DefaultConfig) > 0 && meta.SettingsSchemaPath == "" {
+			c.warnf(field, "is set but the plugin has no settings schema")
+		}
+		return
+	}
+
+	// Round-trip through JSON so YAML-decoded values (ints, nested maps)
+	// have the types the validator expects.
+	raw, err := json.Marshal(meta.DefaultConfig)
+	if err != nil {
+		c.errorf(field, "%v", err)
+		return
+	}
+	var values map[string]any
+	if err := json.Unmarshal(raw, &values); err != nil {
+		c.errorf(field, "%v", err)
+		return
+	}
+	if values == nil {
+		values = map[string]any{}
+	}
+
+	if result := schema.Validate(values); !result.IsValid() {
+		errs := result.DetailedErrors()
+		paths := make([]string, 0, len(errs))
+		for path := range errs {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			c.errorf(field, "%s: %s", path, errs[path])
+		}
+	}
+
+	if schema.Properties != nil {
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, ok := (*schema.Properties)[k]; !ok {
+				c.warnf(field, "key %q is not a property of the settings schema", k)
+			}
+		}
+	}
+}
+
+// checkCacheIgnore warns about cache.ignore entries that name no setting.
+func (c *checker) checkCacheIgnore(meta *plugins.PluginMetadata, schema *jsonschema.Schema) {
+	if meta.Cache == nil || schema == nil || schema.Properties == nil {
+		return
+	}
+	for _, k := range meta.Cache.Ignore {
+		if _, ok := (*schema.Properties)[k]; !ok {
+			c.warnf("cache.ignore", "key %q is not a property of the settings schema", k)
+		}
+	}
+}
+
+// checkRuntime verifies the runtime's entrypoint exists.
+func (c *checker) checkRuntime(meta *plugins.PluginMetadata) {
+	switch meta.Runtime {
+	case plugins.RuntimeCrewAIStream:
+		crew := filepath.Join(c.dir, "crew", "crew.py")
+		src, err := os.ReadFile(crew)
+		if err != nil {
+			c.errorf("crew/crew.py", "the crewai-stream runtime needs a crew entrypoint: %v", err)
+			return
+		}
+		if !createCrewPattern.Match(src) {
+			c.errorf("crew/crew.py", "does not define create_crew(settings, llm=None, search_tool=None)")
+		}
+	case plugins.RuntimeExec:
+		name := meta.Exec.Command[0]
+		if !strings.ContainsRune(name, '/') {
+			return // looked up on PATH at run time
+		}
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.dir, name)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			c.errorf("exec.command", "%v", err)
+			return
+		}
+		if fi.Mode()&0o111 == 0 {
+			c.errorf("exec.command", "%s is not executable", name)
+		}
+	case plugins.RuntimeGoNative:
+		c.errorf("runtime", "go-native is reserved for compiled-in plugins; use the sdk package instead")
+	}
+}
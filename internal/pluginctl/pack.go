@@ -0,0 +1,96 @@
+package pluginctl
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jimdaga/first-sip/internal/pluginbundle"
+	"github.com/jimdaga/first-sip/internal/plugins"
+)
+
+// Pack validates the plugin in dir and writes it to outDir as
+// <name>-<version>.tar.gz. When key is set, the detached signature is written
+// next to it with a .sig suffix. It returns the bundle path.
+func Pack(dir string, key ed25519.PrivateKey, outDir string) (string, error) {
+	if problems := Check(dir, false); HasErrors(problems) {
+		return "", fmt.Errorf("%s does not validate; run `first-sip plugin validate %s`", dir, dir)
+	}
+	meta, err := plugins.LoadPluginMetadata(filepath.Join(dir, "plugin.yaml"))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := pluginbundle.Pack(dir, &buf); err != nil {
+		return "", err
+	}
+	bundlePath := filepath.Join(outDir, fmt.Sprintf("%s-%s.tar.gz", meta.Name, meta.Version))
+	if err := os.WriteFile(bundlePath, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	if key != nil {
+		if err := os.WriteFile(bundlePath+".sig", pluginbundle.Sign(buf.Bytes(), key), 0o644); err != nil {
+			return "", err
+		}
+	}
+	return bundlePath, nil
+}
+
+func runPack(dir, keyFile, outDir string, stdout, stderr io.Writer) int {
+	var key ed25519.PrivateKey
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err == nil {
+			key, err = pluginbundle.DecodePrivateKey(data)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "read key: %v\n", err)
+			return exitProblems
+		}
+	}
+	path, err := Pack(dir, key, outDir)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitProblems
+	}
+	fmt.Fprintf(stdout, "Wrote %s\n", path)
+	if key != nil {
+		fmt.Fprintf(stdout, "Wrote %s.sig\n", path)
+	} else {
+		fmt.Fprintln(stdout, "Bundle is unsigned; pass -key to sign it for installation")
+	}
+	return exitOK
+}
+
+// runKeygen writes <publisher>.key (the base64 private key, mode 0600) to
+// outDir and prints the PLUGIN_TRUSTED_KEYS entry for the public key.
+func runKeygen(publisher, outDir string, stdout, stderr io.Writer) int {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitProblems
+	}
+	path := filepath.Join(outDir, publisher+".key")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitProblems
+	}
+	_, err = fmt.Fprintln(f, base64.StdEncoding.EncodeToString(priv))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitProblems
+	}
+	fmt.Fprintf(stdout, "Wrote private key %s (keep it secret)\n", path)
+	fmt.Fprintf(stdout, "Trust it on the server with:\n  PLUGIN_TRUSTED_KEYS=%s=%s\n", publisher, base64.StdEncoding.EncodeToString(pub))
+	return exitOK
+}
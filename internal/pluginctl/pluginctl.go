@@ -0,0 +1,159 @@
+// Package pluginctl implements the `plugin` subcommand for plugin authors:
+// validate and lint check plugin directories before they are deployed, new
+// scaffolds a plugin from a template, and pack and keygen build signed
+// bundles for the admin install API (see pluginbundle).
+//
+//	first-sip plugin validate [-dir PLUGIN_DIR] [plugin-path ...]
+//	first-sip plugin lint     [-dir PLUGIN_DIR] [plugin-path ...]
+//	first-sip plugin new      [-dir PLUGIN_DIR] [-owner OWNER] name
+//	first-sip plugin pack     [-key KEY_FILE] [-out DIR] plugin-path
+//	first-sip plugin keygen   [-out DIR] publisher
+package pluginctl
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Exit codes returned by Run.
+const (
+	exitOK       = 0
+	exitProblems = 1
+	exitUsage    = 2
+)
+
+const usage = `usage: first-sip plugin <command> [flags] [args]
+
+commands:
+  validate [plugin-path ...]  check manifests, settings schemas, default configs and entrypoints
+  lint     [plugin-path ...]  validate, plus style warnings
+  new      name               scaffold a crewai-stream plugin under -dir
+  pack     plugin-path        validate and pack a plugin into a bundle, signed with -key
+  keygen   publisher          create an ed25519 signing key for bundles
+
+With no plugin paths, validate and lint check every plugin under -dir.
+`
+
+// Run executes the plugin subcommand with args (excluding "plugin") and
+// returns the process exit code.
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	cmd := args[0]
+	fset := flag.NewFlagSet("plugin "+cmd, flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	dir := fset.String("dir", defaultPluginDir(), "plugin directory (defaults to $PLUGIN_DIR or ./plugins)")
+	owner := fset.String("owner", "", "owner written to plugin.yaml by new")
+	key := fset.String("key", "", "private key file used by pack to sign the bundle")
+	out := fset.String("out", ".", "output directory for pack and keygen")
+	if err := fset.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	switch cmd {
+	case "validate", "lint":
+		return runCheck(*dir, fset.Args(), cmd == "lint", stdout, stderr)
+	case "new":
+		if fset.NArg() != 1 {
+			fmt.Fprintln(stderr, "usage: first-sip plugin new [-dir PLUGIN_DIR] [-owner OWNER] name")
+			return exitUsage
+		}
+		path, err := New(*dir, fset.Arg(0), *owner)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitProblems
+		}
+		fmt.Fprintf(stdout, "Created %s\nNext: edit crew/crew.py and settings.schema.json, then run `first-sip plugin lint %s`\n", path, path)
+		return exitOK
+	case "pack":
+		if fset.NArg() != 1 {
+			fmt.Fprintln(stderr, "usage: first-sip plugin pack [-key KEY_FILE] [-out DIR] plugin-path")
+			return exitUsage
+		}
+		return runPack(fset.Arg(0), *key, *out, stdout, stderr)
+	case "keygen":
+		if fset.NArg() != 1 || !namePattern.MatchString(fset.Arg(0)) {
+			fmt.Fprintln(stderr, "usage: first-sip plugin keygen [-out DIR] publisher (lowercase letters, digits and hyphens)")
+			return exitUsage
+		}
+		return runKeygen(fset.Arg(0), *out, stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown plugin command %q\n\n%s", cmd, usage)
+		return exitUsage
+	}
+}
+
+// runCheck checks each plugin path (or every plugin under dir) and prints
+// the problems found.
+func runCheck(dir string, paths []string, lint bool, stdout, stderr io.Writer) int {
+	if len(paths) == 0 {
+		found, err := pluginDirs(dir)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitProblems
+		}
+		if len(found) == 0 {
+			fmt.Fprintf(stderr, "no plugins found in %s\n", dir)
+			return exitProblems
+		}
+		paths = found
+	}
+
+	failed := 0
+	for _, path := range paths {
+		problems := Check(path, lint)
+		if len(problems) == 0 {
+			fmt.Fprintf(stdout, "ok    %s\n", path)
+			continue
+		}
+		status := "warn "
+		if HasErrors(problems) {
+			status = "FAIL "
+			failed++
+		}
+		fmt.Fprintf(stdout, "%s %s\n", status, path)
+		for _, p := range problems {
+			fmt.Fprintf(stdout, "      %s\n", p)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(stdout, "%d of %d plugin(s) failed\n", failed, len(paths))
+		return exitProblems
+	}
+	return exitOK
+}
+
+// pluginDirs lists the subdirectories of dir that contain a plugin.yaml.
+func pluginDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if _, err := os.Stat(filepath.Join(path, "plugin.yaml")); err == nil {
+			dirs = append(dirs, path)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func defaultPluginDir() string {
+	if dir := os.Getenv("PLUGIN_DIR"); dir != "" {
+		return dir
+	}
+	return "./plugins"
+}
@@ -0,0 +1,119 @@
+package pluginctl
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewScaffoldPassesLint(t *testing.T) {
+	root := t.TempDir()
+	dir, err := New(root, "weather-watch", "first-sip")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if problems := Check(dir, true); len(problems) != 0 {
+		t.Errorf("Check(scaffold) = %v, want no problems", problems)
+	}
+	if _, err := New(root, "weather-watch", ""); err == nil {
+		t.Error("New over an existing plugin succeeded, want error")
+	}
+	if _, err := New(root, "Weather_Watch", ""); err == nil {
+		t.Error("New with an invalid name succeeded, want error")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "broken")
+	write := func(name, body string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("plugin.yaml", `name: not-broken
+version: "1"
+default_config:
+  count: many
+settings_schema_path: settings.schema.json
+`)
+	write("settings.schema.json", `{"type":"object","properties":{"count":{"type":"integer"}}}`)
+	write("crew/crew.py", "def build():\n    pass\n")
+
+	problems := Check(dir, false)
+	want := []string{
+		`error: name: "not-broken" does not match the plugin directory "broken"`,
+		"error: default_config: /count",
+		"error: crew/crew.py: does not define create_crew",
+	}
+	got := make([]string, len(problems))
+	for i, p := range problems {
+		got[i] = p.String()
+	}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			if strings.HasPrefix(g, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing problem %q in %q", w, got)
+		}
+	}
+	for _, p := range problems {
+		if p.Severity == SeverityWarning {
+			t.Errorf("validate reported warning %v", p)
+		}
+	}
+
+	lint := Check(dir, true)
+	var warnedVersion bool
+	for _, p := range lint {
+		if p.Severity == SeverityWarning && p.Field == "version" {
+			warnedVersion = true
+		}
+	}
+	if !warnedVersion {
+		t.Errorf("lint did not warn about version %q: %v", "1", lint)
+	}
+
+	if !HasErrors(Check(filepath.Join(root, "missing"), false)) {
+		t.Error("Check(missing dir) has no errors")
+	}
+}
+
+func TestPackScaffold(t *testing.T) {
+	root := t.TempDir()
+	dir, err := New(root, "weather-watch", "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, priv, _ := ed25519.GenerateKey(nil)
+	out := t.TempDir()
+	path, err := Pack(dir, priv, out)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if filepath.Base(path) != "weather-watch-0.1.0.tar.gz" {
+		t.Errorf("Pack wrote %s", path)
+	}
+	if _, err := os.Stat(path + ".sig"); err != nil {
+		t.Errorf("signature not written: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "crew", "crew.py")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Pack(dir, nil, out); err == nil {
+		t.Error("Pack of an invalid plugin succeeded")
+	}
+}
@@ -0,0 +1,83 @@
+package pluginctl
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// scaffoldFS holds the files written by New. Each *.tmpl file is rendered
+// with scaffoldData and written without the suffix.
+//
+//go:embed scaffold
+var scaffoldFS embed.FS
+
+type scaffoldData struct {
+	Name        string
+	DisplayName string
+	Description string
+	Owner       string
+}
+
+// New scaffolds a crewai-stream plugin named name under pluginDir and
+// returns its directory. It refuses to overwrite an existing directory.
+func New(pluginDir, name, owner string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("plugin name %q must be lowercase letters, digits and single hyphens", name)
+	}
+	dir := filepath.Join(pluginDir, name)
+	if _, err := os.Stat(dir); err == nil {
+		return "", fmt.Errorf("%s already exists", dir)
+	}
+
+	data := scaffoldData{
+		Name:        name,
+		DisplayName: displayName(name),
+		Description: "Describe what the " + displayName(name) + " briefing covers",
+		Owner:       owner,
+	}
+
+	err := fs.WalkDir(scaffoldFS, "scaffold", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel := strings.TrimSuffix(strings.TrimPrefix(path, "scaffold/"), ".tmpl")
+		tmpl, err := template.ParseFS(scaffoldFS, path)
+		if err != nil {
+			return err
+		}
+		out := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+			return err
+		}
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(f, data); err != nil {
+			f.Close()
+			return fmt.Errorf("render %s: %w", rel, err)
+		}
+		return f.Close()
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("scaffold %s: %w", name, err)
+	}
+	return dir, nil
+}
+
+// displayName humanizes a plugin name ("daily-news" → "Daily News").
+func displayName(name string) string {
+	words := strings.Split(name, "-")
+	for i, w := range words {
+		if len(w) > 0 {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
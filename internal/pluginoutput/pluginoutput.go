@@ -0,0 +1,390 @@
+// Package pluginoutput defines the output contract for plugin runs: the JSON
+// document stored in plugin_runs.output, and the versioned schema a plugin
+// declares for it in plugin.yaml.
+//
+// Version v1 is the original format: a summary plus sections whose content is
+// pre-escaped HTML. Version v2 adds typed blocks to each section (lists,
+// links, key-value pairs, metrics, tables, images, citations). Block fields are
+// plain text and are escaped when rendered.
+//
+// The package has no internal imports so the templates can render blocks
+// directly.
+package pluginoutput
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Output schema versions.
+const (
+	Version1 = "v1"
+	Version2 = "v2"
+)
+
+// Block types (v2).
+const (
+	BlockText      = "text"
+	BlockList      = "list"
+	BlockLinks     = "links"
+	BlockKV        = "kv"
+	BlockMetric    = "metric"
+	BlockTable     = "table"
+	BlockImage     = "image"
+	BlockCitations = "citations"
+)
+
+// Metric trends.
+const (
+	TrendUp   = "up"
+	TrendDown = "down"
+	TrendFlat = "flat"
+)
+
+// Limits keep a single run from producing an unrenderable page.
+const (
+	MaxSections         = 50
+	MaxBlocksPerSection = 50
+	MaxBlockEntries     = 200 // list items, links, pairs, table rows, citations
+)
+
+// knownBlocks lists every block type, for manifest and output validation.
+var knownBlocks = map[string]bool{
+	BlockText: true, BlockList: true, BlockLinks: true, BlockKV: true,
+	BlockMetric: true, BlockTable: true, BlockImage: true, BlockCitations: true,
+}
+
+// ErrInvalidOutput is returned (wrapped) when a run's output does not match the
+// plugin's declared output schema.
+var ErrInvalidOutput = errors.New("output does not match schema")
+
+// Output is the JSONB structure stored in plugin_runs.output.
+type Output struct {
+	Version  string    `json:"version,omitempty"`
+	Summary  string    `json:"summary"`
+	Sections []Section `json:"sections"`
+	Content  string    `json:"content,omitempty"` // legacy — old completed runs only
+}
+
+// Section is a titled section within a plugin run's output. Content is HTML;
+// Blocks (v2 only) are rendered after it.
+type Section struct {
+	Title   string  `json:"title"`
+	Content string  `json:"content,omitempty"`
+	Blocks  []Block `json:"blocks,omitempty"`
+}
+
+// Block is one typed element of a v2 section. Type selects which fields apply.
+type Block struct {
+	Type string `json:"type"`
+
+	// text
+	Text string `json:"text,omitempty"`
+
+	// list
+	Items   []string `json:"items,omitempty"`
+	Ordered bool     `json:"ordered,omitempty"`
+
+	// links
+	Links []Link `json:"links,omitempty"`
+
+	// kv
+	Pairs []Pair `json:"pairs,omitempty"`
+
+	// metric
+	Label string `json:"label,omitempty"`
+	Value string `json:"value,omitempty"` // formatted, e.g. "72°F" or "1,204"
+	Unit  string `json:"unit,omitempty"`
+	Trend string `json:"trend,omitempty"` // up, down, flat
+	Delta string `json:"delta,omitempty"` // e.g. "+2.1%"
+
+	// table
+	Columns []string   `json:"columns,omitempty"`
+	Rows    [][]string `json:"rows,omitempty"`
+
+	// image (Alt is required; Caption optional)
+	URL     string `json:"url,omitempty"`
+	Alt     string `json:"alt,omitempty"`
+	Caption string `json:"caption,omitempty"`
+
+	// citations
+	Citations []Citation `json:"citations,omitempty"`
+}
+
+// Link is an entry of a links block.
+type Link struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Pair is an entry of a kv block.
+type Pair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Citation is an entry of a citations block. At least one of Title and URL is set.
+type Citation struct {
+	Title  string `json:"title,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Source string `json:"source,omitempty"`
+}
+
+// Spec is the output schema a plugin declares in plugin.yaml:
+//
+//	output:
+//	  version: v2
+//	  blocks: [list, links, metric]
+//
+// Blocks restricts which block types the plugin may emit; empty allows all.
+type Spec struct {
+	Version string   `yaml:"version"`
+	Blocks  []string `yaml:"blocks"`
+}
+
+// Check validates the spec itself (at manifest load).
+func (s Spec) Check() error {
+	switch s.Version {
+	case "", Version1:
+		if len(s.Blocks) > 0 {
+			return fmt.Errorf("output.blocks requires output.version %s", Version2)
+		}
+	case Version2:
+		for _, b := range s.Blocks {
+			if !knownBlocks[b] {
+				return fmt.Errorf("output.blocks has unknown block type %q", b)
+			}
+		}
+	default:
+		return fmt.Errorf("output.version %q is not supported", s.Version)
+	}
+	return nil
+}
+
+// allows reports whether the spec permits block type t.
+func (s Spec) allows(t string) bool {
+	if len(s.Blocks) == 0 {
+		return true
+	}
+	for _, b := range s.Blocks {
+		if b == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks raw run output against spec. A plugin declaring v2 must
+// emit v2 output; v2 output is decoded strictly and every block is checked.
+// v1 output is accepted as before (any valid JSON), but may not carry blocks.
+func Validate(raw []byte, spec Spec) error {
+	var probe struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		if spec.Version == Version2 {
+			return fmt.Errorf("%w: %v", ErrInvalidOutput, err)
+		}
+		return nil // not an object; v1 never required one
+	}
+
+	if spec.Version == Version2 && probe.Version != Version2 {
+		return fmt.Errorf("%w: plugin declares output %s but run reported %q", ErrInvalidOutput, Version2, probe.Version)
+	}
+
+	switch probe.Version {
+	case "", Version1:
+		var out Output
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return nil // loosely shaped v1 output still renders as empty
+		}
+		for i, s := range out.Sections {
+			if len(s.Blocks) > 0 {
+				return fmt.Errorf("%w: sections[%d]: blocks require output version %s", ErrInvalidOutput, i, Version2)
+			}
+		}
+		return nil
+	case Version2:
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		var out Output
+		if err := dec.Decode(&out); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidOutput, err)
+		}
+		return validateV2(out, spec)
+	default:
+		return fmt.Errorf("%w: unknown output version %q", ErrInvalidOutput, probe.Version)
+	}
+}
+
+func validateV2(out Output, spec Spec) error {
+	if out.Content != "" {
+		return fmt.Errorf("%w: legacy content field is not allowed in %s", ErrInvalidOutput, Version2)
+	}
+	if len(out.Sections) > MaxSections {
+		return fmt.Errorf("%w: more than %d sections", ErrInvalidOutput, MaxSections)
+	}
+	for i, s := range out.Sections {
+		if strings.TrimSpace(s.Title) == "" {
+			return fmt.Errorf("%w: sections[%d]: title is required", ErrInvalidOutput, i)
+		}
+		if len(s.Blocks) > MaxBlocksPerSection {
+			return fmt.Errorf("%w: sections[%d]: more than %d blocks", ErrInvalidOutput, i, MaxBlocksPerSection)
+		}
+		for j, b := range s.Blocks {
+			if !knownBlocks[b.Type] {
+				return fmt.Errorf("%w: sections[%d].blocks[%d]: unknown block type %q", ErrInvalidOutput, i, j, b.Type)
+			}
+			if !spec.allows(b.Type) {
+				return fmt.Errorf("%w: sections[%d].blocks[%d]: block type %q is not declared in output.blocks", ErrInvalidOutput, i, j, b.Type)
+			}
+			if err := validateBlock(b); err != nil {
+				return fmt.Errorf("%w: sections[%d].blocks[%d]: %v", ErrInvalidOutput, i, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBlock checks the fields required by b.Type.
+func validateBlock(b Block) error {
+	switch b.Type {
+	case BlockText:
+		if b.Text == "" {
+			return errors.New("text block requires text")
+		}
+	case BlockList:
+		if err := entries(len(b.Items), "items"); err != nil {
+			return err
+		}
+	case BlockLinks:
+		if err := entries(len(b.Links), "links"); err != nil {
+			return err
+		}
+		for k, l := range b.Links {
+			if l.Title == "" || !validURL(l.URL) {
+				return fmt.Errorf("links[%d] requires a title and an http(s) url", k)
+			}
+		}
+	case BlockKV:
+		if err := entries(len(b.Pairs), "pairs"); err != nil {
+			return err
+		}
+		for k, p := range b.Pairs {
+			if p.Key == "" {
+				return fmt.Errorf("pairs[%d] requires a key", k)
+			}
+		}
+	case BlockMetric:
+		if b.Label == "" || b.Value == "" {
+			return errors.New("metric block requires label and value")
+		}
+		switch b.Trend {
+		case "", TrendUp, TrendDown, TrendFlat:
+		default:
+			return fmt.Errorf("metric trend %q must be up, down or flat", b.Trend)
+		}
+	case BlockTable:
+		if len(b.Columns) == 0 {
+			return errors.New("table block requires columns")
+		}
+		if err := entries(len(b.Rows), "rows"); err != nil {
+			return err
+		}
+		for k, r := range b.Rows {
+			if len(r) != len(b.Columns) {
+				return fmt.Errorf("rows[%d] has %d cells, want %d", k, len(r), len(b.Columns))
+			}
+		}
+	case BlockImage:
+		if !validURL(b.URL) || b.Alt == "" {
+			return errors.New("image block requires an http(s) url and alt text")
+		}
+	case BlockCitations:
+		if err := entries(len(b.Citations), "citations"); err != nil {
+			return err
+		}
+		for k, c := range b.Citations {
+			if c.Title == "" && c.URL == "" {
+				return fmt.Errorf("citations[%d] requires a title or url", k)
+			}
+			if c.URL != "" && !validURL(c.URL) {
+				return fmt.Errorf("citations[%d] url must be http(s)", k)
+			}
+		}
+	}
+	return nil
+}
+
+func entries(n int, field string) error {
+	if n == 0 {
+		return fmt.Errorf("%s must not be empty", field)
+	}
+	if n > MaxBlockEntries {
+		return fmt.Errorf("more than %d %s", MaxBlockEntries, field)
+	}
+	return nil
+}
+
+// validURL accepts absolute http and https URLs only, so blocks cannot carry
+// javascript: or data: links.
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Lines returns the block's content as plain-text lines, for diffs and
+// text-only renderings.
+func (b Block) Lines() []string {
+	var lines []string
+	switch b.Type {
+	case BlockText:
+		lines = strings.Split(b.Text, "\n")
+	case BlockList:
+		lines = append(lines, b.Items...)
+	case BlockLinks:
+		for _, l := range b.Links {
+			lines = append(lines, l.Title+" ("+l.URL+")")
+		}
+	case BlockKV:
+		for _, p := range b.Pairs {
+			lines = append(lines, p.Key+": "+p.Value)
+		}
+	case BlockMetric:
+		line := b.Label + ": " + strings.TrimSpace(b.Value+" "+b.Unit)
+		if b.Delta != "" {
+			line += " (" + b.Delta + ")"
+		}
+		lines = append(lines, line)
+	case BlockTable:
+		for _, r := range b.Rows {
+			lines = append(lines, strings.Join(r, " | "))
+		}
+	case BlockImage:
+		if b.Caption != "" {
+			lines = append(lines, b.Caption)
+		}
+	case BlockCitations:
+		for _, c := range b.Citations {
+			lines = append(lines, strings.TrimSpace(c.Title+" "+c.URL))
+		}
+	}
+	return lines
+}
+
+// Text returns the section's content followed by its blocks as plain lines.
+func (s Section) Text() string {
+	parts := []string{}
+	if s.Content != "" {
+		parts = append(parts, s.Content)
+	}
+	for _, b := range s.Blocks {
+		parts = append(parts, b.Lines()...)
+	}
+	return strings.Join(parts, "\n")
+}
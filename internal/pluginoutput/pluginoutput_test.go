@@ -0,0 +1,82 @@
+package pluginoutput
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSpecCheck(t *testing.T) {
+	valid := []Spec{{}, {Version: Version1}, {Version: Version2}, {Version: Version2, Blocks: []string{BlockList, BlockMetric}}}
+	for _, s := range valid {
+		if err := s.Check(); err != nil {
+			t.Errorf("Check(%+v) = %v, want nil", s, err)
+		}
+	}
+	invalid := []Spec{{Version: "v3"}, {Version: Version1, Blocks: []string{BlockList}}, {Version: Version2, Blocks: []string{"chart"}}}
+	for _, s := range invalid {
+		if err := s.Check(); err == nil {
+			t.Errorf("Check(%+v) = nil, want error", s)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	v2 := Spec{Version: Version2}
+	tests := []struct {
+		name    string
+		raw     string
+		spec    Spec
+		wantErr string // substring; empty means valid
+	}{
+		{"v1 legacy", `{"summary":"s","sections":[{"title":"T","content":"<b>x</b>"}]}`, Spec{}, ""},
+		{"v1 non-object", `["anything"]`, Spec{}, ""},
+		{"v1 with blocks", `{"sections":[{"title":"T","blocks":[{"type":"text","text":"x"}]}]}`, Spec{}, "require output version v2"},
+		{"v2 declared, v1 sent", `{"summary":"s","sections":[]}`, v2, "declares output v2"},
+		{"unknown version", `{"version":"v9"}`, Spec{}, "unknown output version"},
+		{"v2 ok", `{"version":"v2","summary":"s","sections":[{"title":"Markets","blocks":[
+			{"type":"metric","label":"S&P 500","value":"5,120","trend":"up","delta":"+0.4%"},
+			{"type":"table","columns":["A","B"],"rows":[["1","2"]]},
+			{"type":"links","links":[{"title":"Story","url":"https://example.com/a"}]},
+			{"type":"citations","citations":[{"title":"Source"}]}]}]}`, v2, ""},
+		{"v2 unknown field", `{"version":"v2","sections":[{"title":"T","blocks":[{"type":"text","text":"x","colour":"red"}]}]}`, v2, "unknown field"},
+		{"v2 undeclared block", `{"version":"v2","sections":[{"title":"T","blocks":[{"type":"image","url":"https://e.x/i.png","alt":"i"}]}]}`, Spec{Version: Version2, Blocks: []string{BlockList}}, "not declared"},
+		{"v2 javascript link", `{"version":"v2","sections":[{"title":"T","blocks":[{"type":"links","links":[{"title":"x","url":"javascript:alert(1)"}]}]}]}`, v2, "http(s) url"},
+		{"v2 ragged table", `{"version":"v2","sections":[{"title":"T","blocks":[{"type":"table","columns":["A","B"],"rows":[["1"]]}]}]}`, v2, "rows[0] has 1 cells"},
+		{"v2 bad trend", `{"version":"v2","sections":[{"title":"T","blocks":[{"type":"metric","label":"x","value":"1","trend":"sideways"}]}]}`, v2, "trend"},
+		{"v2 untitled section", `{"version":"v2","sections":[{"title":" ","blocks":[]}]}`, v2, "title is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate([]byte(tt.raw), tt.spec)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !errors.Is(err, ErrInvalidOutput) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate = %v, want ErrInvalidOutput containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSectionText(t *testing.T) {
+	s := Section{
+		Content: "Intro",
+		Blocks: []Block{
+			{Type: BlockList, Items: []string{"one", "two"}},
+			{Type: BlockKV, Pairs: []Pair{{Key: "API", Value: "degraded"}}},
+			{Type: BlockMetric, Label: "Temp", Value: "72", Unit: "°F", Delta: "+3"},
+		},
+	}
+	want := "Intro\none\ntwo\nAPI: degraded\nTemp: 72 °F (+3)"
+	if got := s.Text(); got != want {
+		t.Errorf("Text = %q, want %q", got, want)
+	}
+	if got := (Block{Type: BlockTable, Rows: [][]string{{"a", "b"}}}).Lines(); !reflect.DeepEqual(got, []string{"a | b"}) {
+		t.Errorf("table Lines = %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReconcilePlugins aligns plugin availability in the database with the
+// registry: rows for plugins missing from the registry are marked
+// unavailable (keeping the first time they went missing), and rows for
+// registered plugins are marked available again, resuming any user
+// schedules PauseUnavailableSchedules paused.
+//
+// Rows are never deleted, so user configs and run history survive a plugin
+// being removed and later restored.
+func ReconcilePlugins(db *gorm.DB, registry *Registry) (unavailable []string, err error) {
+	names := make([]string, 0, registry.Count())
+	for _, meta := range registry.List() {
+		names = append(names, meta.Name)
+	}
+
+	var restored []uint
+	if len(names) > 0 {
+		if err := db.Model(&Plugin{}).
+			Where("status <> ? AND name IN ?", PluginStatusAvailable, names).
+			Pluck("id", &restored).Error; err != nil {
+			return nil, fmt.Errorf("plugins: find restored plugins: %w", err)
+		}
+	}
+	if len(restored) > 0 {
+		if err := db.Model(&Plugin{}).Where("id IN ?", restored).Updates(map[string]interface{}{
+			"status":            PluginStatusAvailable,
+			"unavailable_since": nil,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("plugins: restore available plugins: %w", err)
+		}
+		if err := db.Model(&UserPluginConfig{}).
+			Where("plugin_id IN ? AND schedule_paused = ?", restored, true).
+			Update("schedule_paused", false).Error; err != nil {
+			return nil, fmt.Errorf("plugins: resume schedules of restored plugins: %w", err)
+		}
+	}
+
+	missing := db.Model(&Plugin{}).Where("status = ?", PluginStatusAvailable)
+	if len(names) > 0 {
+		missing = missing.Where("name NOT IN ?", names)
+	}
+	if err := missing.Pluck("name", &unavailable).Error; err != nil {
+		return nil, fmt.Errorf("plugins: find missing plugins: %w", err)
+	}
+	if len(unavailable) == 0 {
+		return nil, nil
+	}
+
+	if err := db.Model(&Plugin{}).
+		Where("name IN ?", unavailable).
+		Updates(map[string]interface{}{
+			"status":            PluginStatusUnavailable,
+			"unavailable_since": time.Now(),
+		}).Error; err != nil {
+		return nil, fmt.Errorf("plugins: mark plugins unavailable: %w", err)
+	}
+	for _, name := range unavailable {
+		log.Printf("Plugin %s is no longer in the plugin directory; marked unavailable", name)
+	}
+	return unavailable, nil
+}
+
+// ScheduleDisableAt returns when schedules for a plugin that became
+// unavailable at since are paused, given the grace period.
+func ScheduleDisableAt(since time.Time, grace time.Duration) time.Time {
+	return since.Add(grace)
+}
+
+// PauseUnavailableSchedules pauses the schedule of every user config whose
+// plugin has been unavailable for longer than grace. Cron expressions are
+// kept, so ReconcilePlugins resumes them if the plugin is restored, and the
+// configs stay enabled so the tile keeps showing the removal notice. Returns
+// the number of schedules paused.
+func PauseUnavailableSchedules(db *gorm.DB, grace time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-grace)
+	result := db.Model(&UserPluginConfig{}).
+		Where("schedule_paused = ?", false).
+		Where("cron_expression IS NOT NULL AND cron_expression != ''").
+		Where("plugin_id IN (?)", db.Model(&Plugin{}).
+			Select("id").
+			Where("status = ? AND unavailable_since < ?", PluginStatusUnavailable, cutoff)).
+		Update("schedule_paused", true)
+	if result.Error != nil {
+		return 0, fmt.Errorf("plugins: pause unavailable schedules: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconcileAndPauseUnavailableSchedules(t *testing.T) {
+	db := openTestDB(t, &UserPluginConfig{})
+
+	kept := Plugin{Name: "daily-news-digest", Version: "1.0.0", Status: PluginStatusAvailable}
+	gone := Plugin{Name: "weather-watch", Version: "1.0.0", Status: PluginStatusAvailable}
+	for _, p := range []*Plugin{&kept, &gone} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := UserPluginConfig{UserID: 1, PluginID: gone.ID, Enabled: true, CronExpression: "0 7 * * *"}
+	if err := db.Create(&cfg).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewRegistry()
+	if err := registry.Register(&PluginMetadata{Name: kept.Name, Version: "1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	unavailable, err := ReconcilePlugins(db, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unavailable) != 1 || unavailable[0] != gone.Name {
+		t.Fatalf("unavailable = %v, want [%s]", unavailable, gone.Name)
+	}
+
+	const grace = 7 * 24 * time.Hour
+	load := func() UserPluginConfig {
+		t.Helper()
+		var c UserPluginConfig
+		if err := db.First(&c, cfg.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// Inside the grace period the schedule is left alone.
+	if n, err := PauseUnavailableSchedules(db, grace); err != nil || n != 0 {
+		t.Fatalf("PauseUnavailableSchedules inside grace = %d, %v; want 0", n, err)
+	}
+	if load().SchedulePaused {
+		t.Fatal("schedule paused inside the grace period")
+	}
+
+	// Past it, the schedule is paused but the cron expression kept.
+	if err := db.Model(&Plugin{}).Where("id = ?", gone.ID).
+		Update("unavailable_since", time.Now().Add(-grace-time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := PauseUnavailableSchedules(db, grace); err != nil || n != 1 {
+		t.Fatalf("PauseUnavailableSchedules past grace = %d, %v; want 1", n, err)
+	}
+	if c := load(); !c.SchedulePaused || c.CronExpression != "0 7 * * *" {
+		t.Fatalf("config = paused %v, cron %q; want paused with cron kept", c.SchedulePaused, c.CronExpression)
+	}
+	if n, _ := PauseUnavailableSchedules(db, grace); n != 0 {
+		t.Errorf("second pause affected %d configs, want 0", n)
+	}
+
+	// Restoring the plugin makes it available and resumes the schedule.
+	if err := registry.Register(&PluginMetadata{Name: gone.Name, Version: "1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if unavailable, err := ReconcilePlugins(db, registry); err != nil || len(unavailable) != 0 {
+		t.Fatalf("ReconcilePlugins after restore = %v, %v", unavailable, err)
+	}
+	var restored Plugin
+	if err := db.First(&restored, gone.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if restored.Status != PluginStatusAvailable || restored.UnavailableSince != nil {
+		t.Errorf("restored plugin = %s since %v, want available", restored.Status, restored.UnavailableSince)
+	}
+	if c := load(); c.SchedulePaused || c.CronExpression != "0 7 * * *" {
+		t.Errorf("config after restore = paused %v, cron %q; want resumed", c.SchedulePaused, c.CronExpression)
+	}
+}
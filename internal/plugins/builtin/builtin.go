@@ -0,0 +1,4 @@
+// Package builtin holds the plugins compiled into the First Sip binary.
+// Each plugin registers itself with the sdk package from an init function, so
+// importing this package for side effects is enough to make them available.
+package builtin
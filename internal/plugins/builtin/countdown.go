@@ -0,0 +1,102 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/plugins/sdk"
+)
+
+func init() {
+	sdk.Register(&countdown{now: time.Now})
+}
+
+// countdown shows the number of days until a user-chosen date.
+// It needs no LLM and no network access.
+type countdown struct {
+	now func() time.Time
+}
+
+// countdownSchema is the settings schema for the countdown plugin.
+const countdownSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Countdown Settings",
+  "type": "object",
+  "properties": {
+    "event_name": {
+      "type": "string",
+      "minLength": 1,
+      "maxLength": 80,
+      "default": "Vacation",
+      "description": "What you are counting down to"
+    },
+    "event_date": {
+      "type": "string",
+      "pattern": "^[0-9]{4}-[0-9]{2}-[0-9]{2}$",
+      "description": "Date of the event in YYYY-MM-DD format"
+    }
+  },
+  "required": ["event_name", "event_date"]
+}`
+
+// Manifest implements sdk.BriefingPlugin.
+func (c *countdown) Manifest() sdk.Manifest {
+	return sdk.Manifest{
+		Name:         "countdown",
+		Description:  "Counts the days until an upcoming event",
+		Owner:        "first-sip",
+		Version:      "1.0.0",
+		Icon:         "⏳",
+		TileSize:     "1x1",
+		Capabilities: []string{"briefing", "scheduled"},
+		DefaultConfig: map[string]interface{}{
+			"event_name": "Vacation",
+		},
+	}
+}
+
+// SettingsSchema implements sdk.BriefingPlugin.
+func (c *countdown) SettingsSchema() []byte {
+	return []byte(countdownSchema)
+}
+
+// Run implements sdk.BriefingPlugin.
+func (c *countdown) Run(ctx context.Context, settings map[string]interface{}, secrets map[string]string) (sdk.Output, error) {
+	name, _ := settings["event_name"].(string)
+	if name == "" {
+		name = "your event"
+	}
+	dateStr, _ := settings["event_date"].(string)
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return sdk.Output{}, fmt.Errorf("event_date must be YYYY-MM-DD, got %q", dateStr)
+	}
+
+	// "Today" is the user's calendar day, not the server's.
+	now := c.now().In(sdk.Location(ctx))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(date.Sub(today).Hours() / 24)
+
+	var summary string
+	switch {
+	case days > 1:
+		summary = fmt.Sprintf("%d days until %s", days, name)
+	case days == 1:
+		summary = fmt.Sprintf("%s is tomorrow", name)
+	case days == 0:
+		summary = fmt.Sprintf("%s is today!", name)
+	default:
+		summary = fmt.Sprintf("%s was %d days ago", name, -days)
+	}
+
+	// Section content is rendered as HTML; the event name is user input.
+	return sdk.Output{
+		Summary: summary,
+		Sections: []sdk.Section{{
+			Title:   name,
+			Content: html.EscapeString(fmt.Sprintf("%s — %s", date.Format("Monday, January 2, 2006"), summary)),
+		}},
+	}, nil
+}
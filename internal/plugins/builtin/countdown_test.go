@@ -0,0 +1,66 @@
+package builtin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/plugins"
+)
+
+func TestCountdown(t *testing.T) {
+	// 03:00 UTC on March 9 is still the evening of March 8 in New York.
+	now := time.Date(2026, 3, 9, 3, 0, 0, 0, time.UTC)
+	newYork := time.FixedZone("EST", -5*60*60)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	c := &countdown{now: func() time.Time { return now }}
+
+	for _, tc := range []struct {
+		loc     *time.Location
+		date    string
+		summary string
+	}{
+		{nil, "2026-03-10", "Launch is tomorrow"},
+		{newYork, "2026-03-10", "2 days until Launch"},
+		{newYork, "2026-03-08", "Launch is today!"},
+		{tokyo, "2026-03-09", "Launch is today!"},
+		{time.UTC, "2026-03-19", "10 days until Launch"},
+		{time.UTC, "2026-03-01", "Launch was 8 days ago"},
+	} {
+		ctx := context.Background()
+		if tc.loc != nil {
+			ctx = plugins.WithUserLocation(ctx, tc.loc)
+		}
+		out, err := c.Run(ctx, map[string]interface{}{"event_name": "Launch", "event_date": tc.date}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Summary != tc.summary {
+			t.Errorf("%s in %v: summary = %q, want %q", tc.date, tc.loc, out.Summary, tc.summary)
+		}
+	}
+}
+
+func TestCountdownOutput(t *testing.T) {
+	c := &countdown{now: func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }}
+
+	out, err := c.Run(context.Background(), map[string]interface{}{"event_name": "<b>Party</b>", "event_date": "2026-03-04"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Sections) != 1 || out.Sections[0].Title != "<b>Party</b>" {
+		t.Fatalf("sections = %+v", out.Sections)
+	}
+	if content := out.Sections[0].Content; strings.Contains(content, "<b>") || !strings.Contains(content, "Wednesday, March 4, 2026") {
+		t.Errorf("content = %q, want the escaped name and the event date", content)
+	}
+
+	if _, err := c.Run(context.Background(), map[string]interface{}{"event_name": "Party", "event_date": "March 4"}, nil); err == nil {
+		t.Error("expected an error for a malformed event_date")
+	}
+	out, err = c.Run(context.Background(), map[string]interface{}{"event_date": "2026-03-02"}, nil)
+	if err != nil || out.Summary != "your event is tomorrow" {
+		t.Errorf("unnamed event: %q, %v", out.Summary, err)
+	}
+}
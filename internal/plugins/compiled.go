@@ -0,0 +1,91 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// compiledPlugin is a plugin built into the binary rather than discovered on disk.
+type compiledPlugin struct {
+	meta           *PluginMetadata
+	settingsSchema []byte
+}
+
+var (
+	compiledMu      sync.RWMutex
+	compiledPlugins = make(map[string]compiledPlugin)
+)
+
+// RegisterCompiled adds a compiled-in plugin. Its manifest is merged into every
+// Registry built by LoadRegistry (and so synced to the database by InitPlugins),
+// its settings schema is served by CompiledSettingsSchema, and fn runs it through
+// the go-native executor. The manifest runtime is forced to go-native.
+//
+// Most callers should use the sdk package instead of calling this directly.
+func RegisterCompiled(meta *PluginMetadata, settingsSchema []byte, fn NativeFunc) error {
+	if meta == nil || meta.Name == "" {
+		return fmt.Errorf("compiled plugin missing required field: name")
+	}
+	if meta.Version == "" {
+		return fmt.Errorf("compiled plugin %s missing required field: version", meta.Name)
+	}
+	if meta.SchemaVersion == "" {
+		meta.SchemaVersion = "v1"
+	}
+	meta.Runtime = RuntimeGoNative
+	if err := checkRequirements(meta); err != nil {
+		return fmt.Errorf("compiled plugin %s has %w", meta.Name, err)
+	}
+	if err := checkSecretSlots(meta.Secrets); err != nil {
+		return fmt.Errorf("compiled plugin %s has %w", meta.Name, err)
+	}
+	if err := checkCache(meta); err != nil {
+		return fmt.Errorf("compiled plugin %s has %w", meta.Name, err)
+	}
+
+	compiledMu.Lock()
+	if _, dup := compiledPlugins[meta.Name]; dup {
+		compiledMu.Unlock()
+		return fmt.Errorf("compiled plugin already registered: %s", meta.Name)
+	}
+	compiledPlugins[meta.Name] = compiledPlugin{meta: meta, settingsSchema: settingsSchema}
+	compiledMu.Unlock()
+
+	RegisterNative(meta.Name, fn)
+	return nil
+}
+
+// CompiledSettingsSchema returns the JSON Schema bytes of a compiled-in plugin.
+// ok is false if the plugin is not compiled in or declares no settings.
+func CompiledSettingsSchema(name string) ([]byte, bool) {
+	compiledMu.RLock()
+	defer compiledMu.RUnlock()
+	p, found := compiledPlugins[name]
+	if !found || len(p.settingsSchema) == 0 {
+		return nil, false
+	}
+	return p.settingsSchema, true
+}
+
+// IsCompiled reports whether name is a compiled-in plugin.
+func IsCompiled(name string) bool {
+	compiledMu.RLock()
+	defer compiledMu.RUnlock()
+	_, found := compiledPlugins[name]
+	return found
+}
+
+// compiledManifests returns the manifests of all compiled-in plugins, sorted by name.
+func compiledManifests() []*PluginMetadata {
+	compiledMu.RLock()
+	defer compiledMu.RUnlock()
+	metas := make([]*PluginMetadata, 0, len(compiledPlugins))
+	for _, p := range compiledPlugins {
+		metas = append(metas, p.meta)
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].Name < metas[j].Name
+	})
+	return metas
+}
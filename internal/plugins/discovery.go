@@ -12,12 +12,21 @@ import (
 //
 // Returns all successfully loaded plugin metadata.
 func DiscoverPlugins(pluginDir string) ([]*PluginMetadata, error) {
+	plugins, _, err := discoverPlugins(pluginDir)
+	return plugins, err
+}
+
+// discoverPlugins is DiscoverPlugins that also reports the manifests that
+// failed to load, keyed by plugin directory, so a reload can keep the
+// previous version of a plugin whose edited manifest is invalid.
+func discoverPlugins(pluginDir string) ([]*PluginMetadata, map[string]error, error) {
 	var plugins []*PluginMetadata
+	failed := make(map[string]error)
 
 	// List all entries in the plugin directory
 	entries, err := os.ReadDir(pluginDir)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Scan each subdirectory for plugin.yaml
@@ -38,11 +47,13 @@ func DiscoverPlugins(pluginDir string) ([]*PluginMetadata, error) {
 		meta, err := LoadPluginMetadata(manifestPath)
 		if err != nil {
 			log.Printf("Warning: failed to load plugin from %s: %v", entry.Name(), err)
+			failed[filepath.Join(pluginDir, entry.Name())] = err
 			continue // Log and skip invalid plugins
 		}
 
+		meta.Dir = filepath.Join(pluginDir, entry.Name())
 		plugins = append(plugins, meta)
 	}
 
-	return plugins, nil
+	return plugins, failed, nil
 }
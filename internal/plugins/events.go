@@ -0,0 +1,31 @@
+package plugins
+
+import "sync"
+
+// RunObserver is called after a PluginRun enters a terminal status through
+// TransitionRun. Observers run synchronously on the caller's goroutine and must
+// not block for long; enqueue work instead (e.g. webhook deliveries).
+type RunObserver func(pluginRunID, status string)
+
+var (
+	runObserversMu sync.RWMutex
+	runObservers   []RunObserver
+)
+
+// AddRunObserver registers an observer for terminal run transitions.
+// Typically called once at startup.
+func AddRunObserver(o RunObserver) {
+	runObserversMu.Lock()
+	defer runObserversMu.Unlock()
+	runObservers = append(runObservers, o)
+}
+
+// notifyRunObservers fans a terminal transition out to every observer.
+func notifyRunObservers(pluginRunID, status string) {
+	runObserversMu.RLock()
+	observers := runObservers
+	runObserversMu.RUnlock()
+	for _, o := range observers {
+		o(pluginRunID, status)
+	}
+}
@@ -0,0 +1,147 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runtime identifiers accepted in the plugin.yaml `runtime` field.
+const (
+	RuntimeCrewAIStream = "crewai-stream" // Python CrewAI sidecar via Redis Streams (default)
+	RuntimeHTTPWebhook  = "http-webhook"  // POST the request to an HTTP endpoint
+	RuntimeGoNative     = "go-native"     // in-process Go function
+	RuntimeExec         = "exec"          // local subprocess, JSON on stdin/stdout
+	RuntimeN8N          = "n8n"           // legacy n8n briefing workflow via webhook.Client
+)
+
+// knownRuntimes is used to validate manifests.
+var knownRuntimes = map[string]bool{
+	RuntimeCrewAIStream: true,
+	RuntimeHTTPWebhook:  true,
+	RuntimeGoNative:     true,
+	RuntimeExec:         true,
+	RuntimeN8N:          true,
+}
+
+// ErrExecutorSaturated is wrapped by executors that refuse work because a
+// downstream dependency is overloaded. The worker defers the task instead of
+// failing it.
+var ErrExecutorSaturated = errors.New("plugin executor saturated")
+
+// ExecutionRequest is the runtime-agnostic input handed to an Executor.
+// Settings never contain credentials; those are split into Secrets.
+type ExecutionRequest struct {
+	PluginRunID string
+	Plugin      *PluginMetadata
+	UserID      uint
+	Settings    map[string]interface{}
+	Secrets     map[string]string
+	Location    *time.Location // user's timezone; nil means UTC
+}
+
+// RunResult is the outcome of a plugin execution as reported by any runtime.
+// Status is PluginRunStatusCompleted or PluginRunStatusFailed; Output must be a
+// JSON document when completed.
+type RunResult struct {
+	Status string
+	Output string
+	Error  string
+}
+
+// Executor runs a plugin for one PluginRun.
+//
+// Synchronous runtimes return the finished *RunResult, which the caller applies
+// with ApplyRunResult. Asynchronous runtimes (crewai-stream) return a nil result
+// and report later through their own channel, ending in the same ApplyRunResult.
+// A returned error means the run could not be started or finished.
+type Executor interface {
+	Execute(ctx context.Context, req ExecutionRequest) (*RunResult, error)
+}
+
+// Saturable is implemented by executors that can report backpressure before
+// any work (including the PluginRun record) is created.
+type Saturable interface {
+	Saturated(ctx context.Context) (bool, int64, error)
+}
+
+// Executors selects an Executor for a plugin based on its manifest runtime.
+type Executors struct {
+	registry  *Registry
+	mu        sync.RWMutex
+	byRuntime map[string]Executor
+}
+
+// NewExecutors creates an empty executor set backed by the given registry.
+// registry may be nil, in which case every plugin resolves to crewai-stream.
+func NewExecutors(registry *Registry) *Executors {
+	return &Executors{
+		registry:  registry,
+		byRuntime: make(map[string]Executor),
+	}
+}
+
+// NewDefaultExecutors creates an executor set with the in-process runtimes
+// (http-webhook, exec, go-native) registered. The crewai-stream executor lives
+// in the streams package and is registered by the caller.
+func NewDefaultExecutors(registry *Registry) *Executors {
+	e := NewExecutors(registry)
+	e.Register(RuntimeHTTPWebhook, NewHTTPExecutor())
+	e.Register(RuntimeExec, NewExecExecutor())
+	e.Register(RuntimeGoNative, NewNativeExecutor())
+	return e
+}
+
+// Register installs the executor for a runtime, replacing any previous one.
+func (e *Executors) Register(runtime string, executor Executor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.byRuntime[runtime] = executor
+}
+
+// Resolve returns the executor and manifest for a plugin. Plugins missing from
+// the registry are treated as crewai-stream for backward compatibility, with
+// a manifest that declares no secrets, so their runs get no user API keys;
+// a warning is logged when that happens.
+// Returns an error if no executor is registered for the plugin's runtime.
+func (e *Executors) Resolve(pluginName string) (Executor, *PluginMetadata, error) {
+	meta := &PluginMetadata{Name: pluginName, Runtime: RuntimeCrewAIStream}
+	if e.registry != nil {
+		if m, ok := e.registry.Get(pluginName); ok {
+			meta = m
+		} else {
+			slog.Warn("Plugin not in registry — running as crewai-stream without user API keys",
+				"plugin_name", pluginName,
+			)
+		}
+	}
+
+	e.mu.RLock()
+	executor, ok := e.byRuntime[meta.Runtime]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, meta, fmt.Errorf("no executor configured for runtime %q", meta.Runtime)
+	}
+	return executor, meta, nil
+}
+
+// SplitSecrets separates credential keys (any key starting with "_", such as
+// _llm_api_key) from plugin settings. Non-string credential values are dropped.
+func SplitSecrets(settings map[string]interface{}) (map[string]interface{}, map[string]string) {
+	clean := make(map[string]interface{}, len(settings))
+	secrets := make(map[string]string)
+	for k, v := range settings {
+		if !strings.HasPrefix(k, "_") {
+			clean[k] = v
+			continue
+		}
+		if s, ok := v.(string); ok && s != "" {
+			secrets[k] = s
+		}
+	}
+	return clean, secrets
+}
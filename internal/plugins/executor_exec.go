@@ -0,0 +1,120 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultExecTimeout applies when a manifest does not set exec.timeout_seconds.
+const defaultExecTimeout = 5 * time.Minute
+
+// ExecRuntimeConfig configures the exec runtime in plugin.yaml.
+// Command[0] is resolved relative to the plugin directory when it is not absolute
+// and does not name a binary on PATH.
+type ExecRuntimeConfig struct {
+	Command        []string `yaml:"command"`
+	TimeoutSeconds int      `yaml:"timeout_seconds"`
+}
+
+// execPayload is the JSON document written to an exec plugin's stdin.
+type execPayload struct {
+	PluginRunID string                 `json:"plugin_run_id"`
+	PluginName  string                 `json:"plugin_name"`
+	UserID      uint                   `json:"user_id"`
+	Settings    map[string]interface{} `json:"settings"`
+	Secrets     map[string]string      `json:"secrets"`
+}
+
+// ExecExecutor runs exec plugins as local subprocesses. The request is written
+// to stdin as JSON and stdout must be the JSON run output. A non-zero exit status
+// fails the run with stderr as the error message.
+//
+// The subprocess gets a minimal environment (PATH, HOME, LANG) so server
+// credentials such as DATABASE_URL or ENCRYPTION_KEY never leak into plugins.
+type ExecExecutor struct{}
+
+// NewExecExecutor creates an ExecExecutor.
+func NewExecExecutor() *ExecExecutor {
+	return &ExecExecutor{}
+}
+
+// Execute implements Executor.
+func (e *ExecExecutor) Execute(ctx context.Context, req ExecutionRequest) (*RunResult, error) {
+	cfg := req.Plugin.Exec
+	if cfg == nil || len(cfg.Command) == 0 {
+		return nil, fmt.Errorf("plugin %s: exec runtime requires exec.command", req.Plugin.Name)
+	}
+
+	timeout := defaultExecTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdin, err := json.Marshal(execPayload{
+		PluginRunID: req.PluginRunID,
+		PluginName:  req.Plugin.Name,
+		UserID:      req.UserID,
+		Settings:    req.Settings,
+		Secrets:     req.Secrets,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, resolveCommand(req.Plugin.Dir, cfg.Command[0]), cfg.Command[1:]...)
+	cmd.Dir = req.Plugin.Dir
+	cmd.Env = minimalEnv()
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return &RunResult{
+				Status: PluginRunStatusFailed,
+				Error:  fmt.Sprintf("plugin process timed out after %s", timeout),
+			}, nil
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = exitErr.Error()
+			}
+			return &RunResult{Status: PluginRunStatusFailed, Error: truncate(msg, 2000)}, nil
+		}
+		return nil, fmt.Errorf("failed to start plugin process: %w", err)
+	}
+
+	return &RunResult{Status: PluginRunStatusCompleted, Output: stdout.String()}, nil
+}
+
+// resolveCommand makes relative script paths (e.g. "./run.sh", "bin/fetch")
+// relative to the plugin directory; bare names are looked up on PATH.
+func resolveCommand(pluginDir, name string) string {
+	if pluginDir == "" || name == "" || strings.HasPrefix(name, "/") || !strings.ContainsRune(name, '/') {
+		return name
+	}
+	return pluginDir + "/" + strings.TrimPrefix(name, "./")
+}
+
+// minimalEnv returns the environment passed to exec plugins.
+func minimalEnv() []string {
+	env := make([]string, 0, 3)
+	for _, key := range []string{"PATH", "HOME", "LANG"} {
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+	return env
+}
@@ -0,0 +1,154 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// defaultHTTPTimeout applies when a manifest does not set http.timeout_seconds.
+const defaultHTTPTimeout = 60 * time.Second
+
+// maxHTTPResponseBytes bounds how much of a webhook response is read.
+const maxHTTPResponseBytes = 1 << 20
+
+// headerSecretRef matches a ${secrets.<slot>} reference in a header value.
+var headerSecretRef = regexp.MustCompile(`\$\{secrets\.([a-z][a-z0-9_]*)\}`)
+
+// HTTPRuntimeConfig configures the http-webhook runtime in plugin.yaml.
+// Header values are sent as written, except that ${secrets.<slot>} is
+// replaced with the user's value for one of the plugin's declared secret
+// slots (e.g. "Bearer ${secrets.weather_token}"). Server environment
+// variables are never expanded: manifests, including installed bundles,
+// choose the URL the headers are sent to.
+type HTTPRuntimeConfig struct {
+	URL            string            `yaml:"url"`
+	Headers        map[string]string `yaml:"headers"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"`
+}
+
+// httpExecutionPayload is the JSON body POSTed to an http-webhook plugin.
+// User secrets are never sent to the remote endpoint.
+type httpExecutionPayload struct {
+	PluginRunID string                 `json:"plugin_run_id"`
+	PluginName  string                 `json:"plugin_name"`
+	UserID      uint                   `json:"user_id"`
+	Settings    map[string]interface{} `json:"settings"`
+}
+
+// HTTPExecutor runs http-webhook plugins: it POSTs the request as JSON and
+// treats a 2xx JSON response body as the run output.
+type HTTPExecutor struct {
+	client *http.Client
+}
+
+// NewHTTPExecutor creates an HTTPExecutor. Per-request timeouts come from the manifest.
+func NewHTTPExecutor() *HTTPExecutor {
+	return &HTTPExecutor{client: &http.Client{}}
+}
+
+// Execute implements Executor.
+func (e *HTTPExecutor) Execute(ctx context.Context, req ExecutionRequest) (*RunResult, error) {
+	cfg := req.Plugin.HTTP
+	if cfg == nil || cfg.URL == "" {
+		return nil, fmt.Errorf("plugin %s: http runtime requires http.url", req.Plugin.Name)
+	}
+
+	timeout := defaultHTTPTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(httpExecutionPayload{
+		PluginRunID: req.PluginRunID,
+		PluginName:  req.Plugin.Name,
+		UserID:      req.UserID,
+		Settings:    req.Settings,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range cfg.Headers {
+		value, err := headerValue(v, req.Secrets)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: header %s: %w", req.Plugin.Name, k, err)
+		}
+		httpReq.Header.Set(k, value)
+	}
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &RunResult{
+			Status: PluginRunStatusFailed,
+			Error:  fmt.Sprintf("webhook returned status %d: %s", resp.StatusCode, truncate(string(respBody), 200)),
+		}, nil
+	}
+
+	return &RunResult{Status: PluginRunStatusCompleted, Output: string(respBody)}, nil
+}
+
+// headerValue replaces ${secrets.<slot>} references in v with the run's
+// secret slot values. A reference to a slot the user has not filled in is an
+// error rather than an empty credential.
+func headerValue(v string, secrets map[string]string) (string, error) {
+	var missing string
+	out := headerSecretRef.ReplaceAllStringFunc(v, func(ref string) string {
+		name := headerSecretRef.FindStringSubmatch(ref)[1]
+		value, ok := secrets[SecretSlotPrefix+name]
+		if !ok || value == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("secret %q is not set", missing)
+	}
+	return out, nil
+}
+
+// checkHTTPHeaders verifies that every ${secrets.<slot>} reference in
+// http.headers names a secret slot the manifest declares.
+func checkHTTPHeaders(meta *PluginMetadata) error {
+	if meta.HTTP == nil {
+		return nil
+	}
+	for k, v := range meta.HTTP.Headers {
+		for _, m := range headerSecretRef.FindAllStringSubmatch(v, -1) {
+			if _, ok := meta.SecretSlot(m[1]); !ok {
+				return fmt.Errorf("http.headers %s references undeclared secret slot %q", k, m[1])
+			}
+		}
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes for log and error messages.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NativeFunc is the signature of a go-native plugin. It receives the plugin's
+// settings and the user's injected secrets and returns the JSON run output.
+type NativeFunc func(ctx context.Context, settings map[string]interface{}, secrets map[string]string) (json.RawMessage, error)
+
+var (
+	nativeMu    sync.RWMutex
+	nativeFuncs = make(map[string]NativeFunc)
+)
+
+// RegisterNative makes a compiled-in Go function available to plugins with
+// runtime: go-native under the given plugin name. Typically called from init().
+// Registering the same name twice panics, mirroring database/sql.Register.
+func RegisterNative(name string, fn NativeFunc) {
+	nativeMu.Lock()
+	defer nativeMu.Unlock()
+	if fn == nil {
+		panic("plugins: RegisterNative func is nil for " + name)
+	}
+	if _, dup := nativeFuncs[name]; dup {
+		panic("plugins: RegisterNative called twice for " + name)
+	}
+	nativeFuncs[name] = fn
+}
+
+// lookupNative returns the registered function for a plugin name.
+func lookupNative(name string) (NativeFunc, bool) {
+	nativeMu.RLock()
+	defer nativeMu.RUnlock()
+	fn, ok := nativeFuncs[name]
+	return fn, ok
+}
+
+// userLocationKey is the context key for the user's timezone.
+type userLocationKey struct{}
+
+// WithUserLocation returns a copy of ctx carrying the user's timezone, as
+// NativeExecutor passes it to go-native plugins.
+func WithUserLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, userLocationKey{}, loc)
+}
+
+// UserLocation returns the timezone of the user a go-native plugin is running
+// for, as set by WithUserLocation, or UTC.
+func UserLocation(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(userLocationKey{}).(*time.Location); ok {
+		return loc
+	}
+	return time.UTC
+}
+
+// NativeExecutor runs go-native plugins in-process via functions registered
+// with RegisterNative. An error from the function fails the run.
+type NativeExecutor struct{}
+
+// NewNativeExecutor creates a NativeExecutor.
+func NewNativeExecutor() *NativeExecutor {
+	return &NativeExecutor{}
+}
+
+// Execute implements Executor.
+func (e *NativeExecutor) Execute(ctx context.Context, req ExecutionRequest) (*RunResult, error) {
+	fn, ok := lookupNative(req.Plugin.Name)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s: no go-native implementation registered", req.Plugin.Name)
+	}
+
+	if req.Location != nil {
+		ctx = WithUserLocation(ctx, req.Location)
+	}
+	output, err := fn(ctx, req.Settings, req.Secrets)
+	if err != nil {
+		return &RunResult{Status: PluginRunStatusFailed, Error: err.Error()}, nil
+	}
+	return &RunResult{Status: PluginRunStatusCompleted, Output: string(output)}, nil
+}
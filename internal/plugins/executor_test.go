@@ -0,0 +1,194 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTTPExecutor(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEY", "server-secret")
+
+	var gotBody httpExecutionPayload
+	var gotHeader http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if r.Header.Get("X-Fail") != "" {
+			http.Error(w, "upstream down", http.StatusBadGateway)
+			return
+		}
+		io.WriteString(w, `{"summary":"ok","sections":[]}`)
+	}))
+	defer srv.Close()
+
+	meta := &PluginMetadata{
+		Name:    "weather-watch",
+		Runtime: RuntimeHTTPWebhook,
+		Secrets: []SecretSlot{{Name: "weather_token", Label: "Token"}},
+		HTTP: &HTTPRuntimeConfig{
+			URL: srv.URL,
+			Headers: map[string]string{
+				"Authorization": "Bearer ${secrets.weather_token}",
+				"X-Literal":     "${ENCRYPTION_KEY} $ENCRYPTION_KEY",
+			},
+		},
+	}
+	req := ExecutionRequest{
+		PluginRunID: "run-1",
+		Plugin:      meta,
+		UserID:      7,
+		Settings:    map[string]interface{}{"city": "Boston"},
+		Secrets:     map[string]string{"_llm_api_key": "sk-user", SecretSlotPrefix + "weather_token": "tok-123"},
+	}
+
+	result, err := NewHTTPExecutor().Execute(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != PluginRunStatusCompleted || result.Output != `{"summary":"ok","sections":[]}` {
+		t.Errorf("result = %+v", result)
+	}
+	if got := gotHeader.Get("Authorization"); got != "Bearer tok-123" {
+		t.Errorf("Authorization = %q, want the secret slot value", got)
+	}
+	if got := gotHeader.Get("X-Literal"); got != "${ENCRYPTION_KEY} $ENCRYPTION_KEY" {
+		t.Errorf("X-Literal = %q, want it sent literally", got)
+	}
+	if gotBody.PluginRunID != "run-1" || gotBody.UserID != 7 || gotBody.Settings["city"] != "Boston" {
+		t.Errorf("payload = %+v", gotBody)
+	}
+	if gotHeader.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q", gotHeader.Get("Content-Type"))
+	}
+
+	// Non-2xx responses fail the run rather than erroring.
+	meta.HTTP.Headers["X-Fail"] = "1"
+	result, err = NewHTTPExecutor().Execute(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != PluginRunStatusFailed || !strings.Contains(result.Error, "502") {
+		t.Errorf("result = %+v, want failed with status 502", result)
+	}
+
+	// A header referencing an unset slot is not sent with an empty credential.
+	delete(req.Secrets, SecretSlotPrefix+"weather_token")
+	if _, err := NewHTTPExecutor().Execute(context.Background(), req); err == nil || !strings.Contains(err.Error(), "weather_token") {
+		t.Errorf("err = %v, want missing secret error", err)
+	}
+}
+
+func TestCheckHTTPHeaders(t *testing.T) {
+	meta := &PluginMetadata{
+		Secrets: []SecretSlot{{Name: "token", Label: "Token"}},
+		HTTP:    &HTTPRuntimeConfig{URL: "https://example.com", Headers: map[string]string{"Authorization": "Bearer ${secrets.token}"}},
+	}
+	if err := checkHTTPHeaders(meta); err != nil {
+		t.Errorf("declared slot: %v", err)
+	}
+	meta.HTTP.Headers["X-Other"] = "${secrets.other}"
+	if err := checkHTTPHeaders(meta); err == nil {
+		t.Error("expected an error for an undeclared slot")
+	}
+}
+
+func TestExecExecutor(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("requires /bin/sh")
+	}
+	t.Setenv("DATABASE_URL", "postgres://server")
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"input=$(cat)\n" +
+		"printf '{\"input\":%s,\"database_url\":\"%s\",\"pwd\":\"%s\"}' \"$input\" \"$DATABASE_URL\" \"$(pwd)\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := &PluginMetadata{
+		Name:    "local-script",
+		Runtime: RuntimeExec,
+		Exec:    &ExecRuntimeConfig{Command: []string{"./run.sh"}},
+		Dir:     dir,
+	}
+	result, err := NewExecExecutor().Execute(context.Background(), ExecutionRequest{
+		PluginRunID: "run-1",
+		Plugin:      meta,
+		UserID:      3,
+		Settings:    map[string]interface{}{"topic": "science"},
+		Secrets:     map[string]string{"_llm_api_key": "sk-user"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != PluginRunStatusCompleted {
+		t.Fatalf("result = %+v", result)
+	}
+	var out struct {
+		Input       execPayload `json:"input"`
+		DatabaseURL string      `json:"database_url"`
+		Pwd         string      `json:"pwd"`
+	}
+	if err := json.Unmarshal([]byte(result.Output), &out); err != nil {
+		t.Fatalf("output %q: %v", result.Output, err)
+	}
+	if out.Input.PluginRunID != "run-1" || out.Input.Settings["topic"] != "science" || out.Input.Secrets["_llm_api_key"] != "sk-user" {
+		t.Errorf("stdin payload = %+v", out.Input)
+	}
+	if out.DatabaseURL != "" {
+		t.Errorf("server environment leaked into plugin: DATABASE_URL=%q", out.DatabaseURL)
+	}
+	if resolved, _ := filepath.EvalSymlinks(dir); out.Pwd != dir && out.Pwd != resolved {
+		t.Errorf("working directory = %q, want %q", out.Pwd, dir)
+	}
+}
+
+func TestExecExecutorFailures(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("requires /bin/sh")
+	}
+	run := func(command []string, timeout int) *RunResult {
+		t.Helper()
+		meta := &PluginMetadata{
+			Name: "local-script",
+			Exec: &ExecRuntimeConfig{Command: command, TimeoutSeconds: timeout},
+			Dir:  t.TempDir(),
+		}
+		result, err := NewExecExecutor().Execute(context.Background(), ExecutionRequest{PluginRunID: "run-1", Plugin: meta})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if r := run([]string{"sh", "-c", "echo 'bad topic' >&2; exit 2"}, 0); r.Status != PluginRunStatusFailed || r.Error != "bad topic" {
+		t.Errorf("non-zero exit: %+v", r)
+	}
+	if r := run([]string{"sh", "-c", "exec sleep 5"}, 1); r.Status != PluginRunStatusFailed || !strings.Contains(r.Error, "timed out") {
+		t.Errorf("timeout: %+v", r)
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	for _, tc := range []struct{ dir, name, want string }{
+		{"/plugins/x", "./run.sh", "/plugins/x/run.sh"},
+		{"/plugins/x", "bin/fetch", "/plugins/x/bin/fetch"},
+		{"/plugins/x", "python3", "python3"},
+		{"/plugins/x", "/usr/bin/env", "/usr/bin/env"},
+		{"", "./run.sh", "./run.sh"},
+	} {
+		if got := resolveCommand(tc.dir, tc.name); got != tc.want {
+			t.Errorf("resolveCommand(%q, %q) = %q, want %q", tc.dir, tc.name, got, tc.want)
+		}
+	}
+}
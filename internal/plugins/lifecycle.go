@@ -0,0 +1,228 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/pluginoutput"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ErrTransitionRejected is returned by TransitionRun when the run is not in a
+// state from which the requested transition is allowed (or does not exist).
+// Callers treat it as a no-op: the rejection has already been recorded.
+var ErrTransitionRejected = errors.New("plugin run transition rejected")
+
+// runTransitions defines the PluginRun state machine as target status → the set
+// of statuses it may be entered from:
+//
+//	pending → processing → completed | failed | cancelled | timed_out
+//	pending → failed | cancelled
+//
+// completed, failed, cancelled and timed_out are terminal.
+var runTransitions = map[string][]string{
+	PluginRunStatusProcessing: {PluginRunStatusPending},
+	PluginRunStatusCompleted:  {PluginRunStatusProcessing},
+	PluginRunStatusFailed:     {PluginRunStatusPending, PluginRunStatusProcessing},
+	PluginRunStatusCancelled:  {PluginRunStatusPending, PluginRunStatusProcessing},
+	PluginRunStatusTimedOut:   {PluginRunStatusProcessing},
+}
+
+// CanTransition reports whether a PluginRun may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, allowed := range runTransitions[to] {
+		if allowed == from {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminalRunStatus reports whether no further transitions are possible from status.
+func IsTerminalRunStatus(status string) bool {
+	switch status {
+	case PluginRunStatusCompleted, PluginRunStatusFailed, PluginRunStatusCancelled, PluginRunStatusTimedOut:
+		return true
+	}
+	return false
+}
+
+// TransitionRun moves the PluginRun identified by pluginRunID to status `to`,
+// applying the extra column updates in the same statement.
+//
+// The update is conditional (WHERE status IN <allowed predecessors>) so concurrent
+// or duplicate callers cannot overwrite a run that has already moved on. When no
+// row matches, the rejection is recorded in plugin_run_transition_rejections and
+// ErrTransitionRejected is returned. source identifies the caller for debugging.
+// Successful transitions into a terminal status are reported to RunObservers
+// and settle any runs waiting on this one's shared result.
+func TransitionRun(db *gorm.DB, pluginRunID, to, source string, updates map[string]interface{}) error {
+	from, ok := runTransitions[to]
+	if !ok {
+		return fmt.Errorf("plugins: unknown target run status %q", to)
+	}
+
+	values := make(map[string]interface{}, len(updates)+1)
+	for k, v := range updates {
+		values[k] = v
+	}
+	values["status"] = to
+
+	result := db.Model(&PluginRun{}).
+		Where("plugin_run_id = ? AND status IN ?", pluginRunID, from).
+		Updates(values)
+	if result.Error != nil {
+		return fmt.Errorf("plugins: transition run %s to %s: %w", pluginRunID, to, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		if IsTerminalRunStatus(to) {
+			notifyRunObservers(pluginRunID, to)
+			settleSharedRuns(db, pluginRunID, source)
+		}
+		return nil
+	}
+
+	// Nothing matched — find out why so the rejection is useful when debugging.
+	var current PluginRun
+	currentStatus := ""
+	reason := "plugin run not found"
+	if err := db.Select("status").Where("plugin_run_id = ?", pluginRunID).First(&current).Error; err == nil {
+		currentStatus = current.Status
+		reason = fmt.Sprintf("transition %s -> %s not allowed", currentStatus, to)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("plugins: look up run %s after rejected transition: %w", pluginRunID, err)
+	}
+
+	recordRejection(db, pluginRunID, currentStatus, to, source, reason)
+	return ErrTransitionRejected
+}
+
+// recordRejection persists a rejected transition. Failures are logged, not returned,
+// because the rejection record is diagnostic only.
+func recordRejection(db *gorm.DB, pluginRunID, from, to, source, reason string) {
+	slog.Warn("Plugin run transition rejected",
+		"plugin_run_id", pluginRunID,
+		"from_status", from,
+		"to_status", to,
+		"source", source,
+		"reason", reason,
+	)
+
+	rejection := PluginRunTransitionRejection{
+		CreatedAt:   time.Now(),
+		PluginRunID: pluginRunID,
+		FromStatus:  from,
+		ToStatus:    to,
+		Source:      source,
+		Reason:      reason,
+	}
+	if err := db.Create(&rejection).Error; err != nil {
+		slog.Error("Failed to record plugin run transition rejection",
+			"plugin_run_id", pluginRunID,
+			"error", err,
+		)
+	}
+}
+
+// ApplyRunResult records the outcome reported by an executor on its PluginRun.
+//
+// Every runtime ends here, so output handling is identical regardless of how the
+// plugin ran: completed results must carry valid JSON (stored in the JSONB output
+// column) or the run is failed instead. The transition goes through TransitionRun,
+// so duplicate or late results return ErrTransitionRejected without overwriting.
+// Returns the status that was applied.
+func ApplyRunResult(db *gorm.DB, pluginRunID string, result RunResult, source string) (string, error) {
+	updates := map[string]interface{}{
+		"completed_at": time.Now(),
+	}
+
+	var target string
+	switch result.Status {
+	case PluginRunStatusCompleted:
+		// Guard against invalid JSON before storing in JSONB column.
+		// This safety net prevents PostgreSQL from rejecting malformed payloads.
+		if !json.Valid([]byte(result.Output)) {
+			slog.Warn("Plugin result output is not valid JSON — marking run as failed",
+				"plugin_run_id", pluginRunID,
+				"source", source,
+				"output_preview", truncate(result.Output, 100),
+			)
+			target = PluginRunStatusFailed
+			updates["error_message"] = "output was not valid JSON"
+		} else {
+			target = PluginRunStatusCompleted
+			updates["output"] = datatypes.JSON(result.Output)
+		}
+	case PluginRunStatusFailed:
+		target = PluginRunStatusFailed
+		updates["error_message"] = result.Error
+	default:
+		return "", fmt.Errorf("plugins: unknown result status %q", result.Status)
+	}
+
+	if err := TransitionRun(db, pluginRunID, target, source, updates); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// ValidateResult checks a completed result's output against the plugin's
+// declared output schema. A mismatch turns the result into a failure carrying
+// the validation error, so ApplyRunResult records why the run was rejected.
+func ValidateResult(meta *PluginMetadata, result RunResult) RunResult {
+	if result.Status != PluginRunStatusCompleted || !json.Valid([]byte(result.Output)) {
+		return result // non-JSON output is handled by ApplyRunResult
+	}
+	if err := pluginoutput.Validate([]byte(result.Output), meta.OutputSpec()); err != nil {
+		return RunResult{Status: PluginRunStatusFailed, Error: err.Error()}
+	}
+	return result
+}
+
+// reaperSource identifies the stale-run reaper in transition rejection records.
+const reaperSource = "reaper"
+
+// ReapStaleRuns marks runs that have been processing for longer than maxAge as
+// timed_out. Returns the number of runs reaped. Each run goes through
+// TransitionRun, so observers are notified and runs waiting on a reaped shared
+// run are settled. Results that arrive for a reaped run are later rejected
+// rather than applied; a run whose result lands while reaping is left alone.
+func ReapStaleRuns(db *gorm.DB, maxAge time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-maxAge)
+	var reaped int64
+	// Runs that executed themselves go first: reaping one settles the runs
+	// waiting on its shared result, so only waiters left over are reaped after.
+	for _, waiting := range []bool{false, true} {
+		q := db.Model(&PluginRun{}).
+			Where("status = ? AND started_at < ?", PluginRunStatusProcessing, cutoff)
+		if waiting {
+			q = q.Where("shared_from_run_id <> ''")
+		} else {
+			q = q.Where("shared_from_run_id = ''")
+		}
+		var stale []string
+		if err := q.Order("id").Pluck("plugin_run_id", &stale).Error; err != nil {
+			return reaped, fmt.Errorf("plugins: reap stale runs: %w", err)
+		}
+
+		for _, id := range stale {
+			err := TransitionRun(db, id, PluginRunStatusTimedOut, reaperSource, map[string]interface{}{
+				"error_message": fmt.Sprintf("no result received within %s", maxAge),
+				"completed_at":  time.Now(),
+			})
+			switch {
+			case err == nil:
+				reaped++
+			case errors.Is(err, ErrTransitionRejected):
+				// The result arrived in the meantime.
+			default:
+				return reaped, fmt.Errorf("plugins: reap stale runs: %w", err)
+			}
+		}
+	}
+	return reaped, nil
+}
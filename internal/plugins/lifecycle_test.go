@@ -0,0 +1,165 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/pluginoutput"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testDBSeq atomic.Int64
+
+// openTestDB returns an in-memory SQLite database with the tables the
+// lifecycle code touches. Foreign keys are not created.
+func openTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:pluginstest%d?mode=memory&cache=shared", testDBSeq.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	models = append([]interface{}{&Plugin{}, &PluginRun{}, &PluginRunTransitionRejection{}}, models...)
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{PluginRunStatusPending, PluginRunStatusProcessing, true},
+		{PluginRunStatusPending, PluginRunStatusFailed, true},
+		{PluginRunStatusPending, PluginRunStatusCompleted, false},
+		{PluginRunStatusProcessing, PluginRunStatusCompleted, true},
+		{PluginRunStatusProcessing, PluginRunStatusTimedOut, true},
+		{PluginRunStatusProcessing, PluginRunStatusCancelled, true},
+		{PluginRunStatusCompleted, PluginRunStatusFailed, false},
+		{PluginRunStatusFailed, PluginRunStatusCompleted, false},
+		{PluginRunStatusTimedOut, PluginRunStatusCompleted, false},
+		{PluginRunStatusCompleted, PluginRunStatusCompleted, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestTerminalStatusesHaveNoOutgoingTransitions(t *testing.T) {
+	statuses := []string{
+		PluginRunStatusPending,
+		PluginRunStatusProcessing,
+		PluginRunStatusCompleted,
+		PluginRunStatusFailed,
+		PluginRunStatusCancelled,
+		PluginRunStatusTimedOut,
+	}
+
+	for _, from := range statuses {
+		if !IsTerminalRunStatus(from) {
+			continue
+		}
+		for _, to := range statuses {
+			if CanTransition(from, to) {
+				t.Errorf("terminal status %q allows transition to %q", from, to)
+			}
+		}
+	}
+}
+
+func TestValidateResult(t *testing.T) {
+	meta := &PluginMetadata{Name: "markets", Output: &pluginoutput.Spec{Version: pluginoutput.Version2}}
+
+	ok := RunResult{Status: PluginRunStatusCompleted, Output: `{"version":"v2","summary":"s","sections":[]}`}
+	if got := ValidateResult(meta, ok); got != ok {
+		t.Errorf("valid v2 result changed: %+v", got)
+	}
+
+	got := ValidateResult(meta, RunResult{Status: PluginRunStatusCompleted, Output: `{"summary":"s"}`})
+	if got.Status != PluginRunStatusFailed || !strings.Contains(got.Error, "declares output v2") {
+		t.Errorf("v1 output for v2 plugin = %+v, want failed", got)
+	}
+
+	// Without a manifest (unregistered plugin) v1 rules apply.
+	legacy := RunResult{Status: PluginRunStatusCompleted, Output: `{"summary":"s"}`}
+	if got := ValidateResult(nil, legacy); got != legacy {
+		t.Errorf("nil manifest result changed: %+v", got)
+	}
+}
+
+func TestReapStaleRuns(t *testing.T) {
+	db := openTestDB(t)
+
+	var mu sync.Mutex
+	notified := make(map[string]string)
+	AddRunObserver(func(pluginRunID, status string) {
+		mu.Lock()
+		defer mu.Unlock()
+		notified[pluginRunID] = status
+	})
+
+	old := time.Now().Add(-2 * time.Hour)
+	recent := time.Now()
+	runs := []PluginRun{
+		{PluginRunID: "reap-source", Status: PluginRunStatusProcessing, StartedAt: &old},
+		{PluginRunID: "reap-waiter", Status: PluginRunStatusProcessing, StartedAt: &old, SharedFromRunID: "reap-source"},
+		{PluginRunID: "reap-fresh", Status: PluginRunStatusProcessing, StartedAt: &recent},
+	}
+	for i := range runs {
+		runs[i].UserID, runs[i].PluginID = 1, 1
+		if err := db.Create(&runs[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reaped, err := ReapStaleRuns(db, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reaped != 1 {
+		t.Errorf("reaped = %d, want 1 (the waiter is settled by its source)", reaped)
+	}
+
+	status := func(id string) string {
+		var r PluginRun
+		if err := db.Where("plugin_run_id = ?", id).First(&r).Error; err != nil {
+			t.Fatal(err)
+		}
+		return r.Status
+	}
+	if got := status("reap-source"); got != PluginRunStatusTimedOut {
+		t.Errorf("source status = %q, want timed_out", got)
+	}
+	if got := status("reap-waiter"); got != PluginRunStatusFailed {
+		t.Errorf("waiter status = %q, want failed from its shared run", got)
+	}
+	if got := status("reap-fresh"); got != PluginRunStatusProcessing {
+		t.Errorf("fresh run status = %q, want processing", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if notified["reap-source"] != PluginRunStatusTimedOut || notified["reap-waiter"] != PluginRunStatusFailed {
+		t.Errorf("observers notified = %v", notified)
+	}
+
+	var rejections int64
+	db.Model(&PluginRunTransitionRejection{}).Count(&rejections)
+	if rejections != 0 {
+		t.Errorf("reaping recorded %d transition rejections, want 0", rejections)
+	}
+}
@@ -3,6 +3,7 @@ package plugins
 import (
 	"encoding/json"
 	"log"
+	"reflect"
 
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
@@ -35,11 +36,93 @@ func InitPlugins(db *gorm.DB, pluginDir string) (*Registry, error) {
 		log.Printf("Synced plugin to database: %s (version %s)", meta.Name, meta.Version)
 	}
 
+	// Mark plugins that were removed from disk since the last start.
+	if _, err := ReconcilePlugins(db, registry); err != nil {
+		log.Printf("Warning: failed to reconcile plugin availability: %v", err)
+	}
+
 	return registry, nil
 }
 
+// ReloadSummary lists the plugins a Reload changed, by name.
+type ReloadSummary struct {
+	Added   []string
+	Updated []string
+	Removed []string
+	// Kept lists plugins whose manifest no longer validates; the previously
+	// loaded version stays registered until the manifest is fixed.
+	Kept []string
+}
+
+// Changed reports whether the reload altered the registry.
+func (s ReloadSummary) Changed() bool {
+	return len(s.Added)+len(s.Updated)+len(s.Removed) > 0
+}
+
+// Reload re-discovers pluginDir, revalidates every manifest and atomically
+// swaps the result into registry. When db is non-nil, added and updated
+// plugins are re-synced to the database and removed plugins are marked
+// unavailable (their rows are kept, so user configs and run history are
+// untouched).
+//
+// On a discovery error the registry is left unchanged. Concurrent Reloads of
+// the same registry must be serialized by the caller.
+func Reload(db *gorm.DB, registry *Registry, pluginDir string) (ReloadSummary, error) {
+	var summary ReloadSummary
+
+	next, failed, err := loadRegistry(pluginDir)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, old := range registry.List() {
+		if _, ok := next.Get(old.Name); ok {
+			continue
+		}
+		if _, invalid := failed[old.Dir]; invalid && old.Dir != "" {
+			if err := next.Register(old); err == nil {
+				summary.Kept = append(summary.Kept, old.Name)
+				continue
+			}
+		}
+		summary.Removed = append(summary.Removed, old.Name)
+	}
+
+	var changed []*PluginMetadata
+	for _, meta := range next.List() {
+		old, ok := registry.Get(meta.Name)
+		switch {
+		case !ok:
+			summary.Added = append(summary.Added, meta.Name)
+			changed = append(changed, meta)
+		case old != meta && !reflect.DeepEqual(old, meta):
+			summary.Updated = append(summary.Updated, meta.Name)
+			changed = append(changed, meta)
+		}
+	}
+
+	registry.Replace(next)
+
+	if db != nil {
+		for _, meta := range changed {
+			if err := syncPluginToDB(db, meta); err != nil {
+				log.Printf("Warning: failed to sync plugin %s to database: %v", meta.Name, err)
+			}
+		}
+		if summary.Changed() {
+			if _, err := ReconcilePlugins(db, registry); err != nil {
+				log.Printf("Warning: failed to reconcile plugin availability: %v", err)
+			}
+		}
+	}
+
+	return summary, nil
+}
+
 // syncPluginToDB persists or updates a plugin's metadata in the database.
-// Uses an upsert pattern: creates if new, updates if already exists.
+// Uses an upsert pattern: creates if new, updates if already exists. The
+// version is also recorded in the plugin's version history, and user settings
+// are stamped or migrated when the version or settings schema changes.
 func syncPluginToDB(db *gorm.DB, meta *PluginMetadata) error {
 	// Marshal capabilities and default config to JSON
 	capabilitiesJSON, err := json.Marshal(meta.Capabilities)
@@ -52,6 +135,25 @@ func syncPluginToDB(db *gorm.DB, meta *PluginMetadata) error {
 		return err
 	}
 
+	requiresSecretsJSON, err := json.Marshal(append([]string{}, meta.RequiresSecrets...))
+	if err != nil {
+		return err
+	}
+	optionalSecretsJSON, err := json.Marshal(append([]string{}, meta.OptionalSecrets...))
+	if err != nil {
+		return err
+	}
+
+	secretSlotsJSON, err := json.Marshal(append([]SecretSlot{}, meta.Secrets...))
+	if err != nil {
+		return err
+	}
+
+	schemaHash, err := SettingsSchemaHash(meta)
+	if err != nil {
+		return err
+	}
+
 	// Check if plugin already exists
 	var dbPlugin Plugin
 	result := db.Where("name = ?", meta.Name).First(&dbPlugin)
@@ -76,8 +178,20 @@ func syncPluginToDB(db *gorm.DB, meta *PluginMetadata) error {
 			DefaultConfig:      datatypes.JSON(defaultConfigJSON),
 			SettingsSchemaPath: meta.SettingsSchemaPath,
 			Enabled:            true,
+			Status:             PluginStatusAvailable,
+			Deprecated:         meta.Deprecated,
+			ReplacedBy:         meta.ReplacedBy,
+			SchemaHash:         schemaHash,
+			RequiresSecrets:    datatypes.JSON(requiresSecretsJSON),
+			OptionalSecrets:    datatypes.JSON(optionalSecretsJSON),
+			Network:            meta.Network,
+			MinTier:            meta.MinTier,
+			SecretSlots:        datatypes.JSON(secretSlotsJSON),
+		}
+		if err := db.Create(&dbPlugin).Error; err != nil {
+			return err
 		}
-		return db.Create(&dbPlugin).Error
+		return recordPluginVersion(db, dbPlugin.ID, meta, schemaHash)
 	} else if result.Error != nil {
 		return result.Error
 	}
@@ -93,7 +207,27 @@ func syncPluginToDB(db *gorm.DB, meta *PluginMetadata) error {
 		"capabilities":         datatypes.JSON(capabilitiesJSON),
 		"default_config":       datatypes.JSON(defaultConfigJSON),
 		"settings_schema_path": meta.SettingsSchemaPath,
+		"deprecated":           meta.Deprecated,
+		"replaced_by":          meta.ReplacedBy,
+		"status":               PluginStatusAvailable,
+		"unavailable_since":    nil,
+		"schema_hash":          schemaHash,
+		"requires_secrets":     datatypes.JSON(requiresSecretsJSON),
+		"optional_secrets":     datatypes.JSON(optionalSecretsJSON),
+		"network":              meta.Network,
+		"min_tier":             meta.MinTier,
+		"secret_slots":         datatypes.JSON(secretSlotsJSON),
 	}
 
-	return db.Model(&dbPlugin).Updates(updates).Error
+	oldVersion, oldHash := dbPlugin.Version, dbPlugin.SchemaHash
+	if err := db.Model(&dbPlugin).Updates(updates).Error; err != nil {
+		return err
+	}
+	if err := recordPluginVersion(db, dbPlugin.ID, meta, schemaHash); err != nil {
+		return err
+	}
+	if err := upgradeUserSettings(db, dbPlugin.ID, meta, schemaHash, oldVersion, oldHash); err != nil {
+		return err
+	}
+	return revalidateUserSettings(db, dbPlugin.ID, meta)
 }
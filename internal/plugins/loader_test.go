@@ -0,0 +1,63 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeManifest(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name, "plugin.yaml"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, "alpha", "name: alpha\nversion: 1.0.0\n")
+	writeManifest(t, dir, "beta", "name: beta\nversion: 1.0.0\n")
+	writeManifest(t, dir, "gamma", "name: gamma\nversion: 1.0.0\n")
+
+	registry, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeManifest(t, dir, "alpha", "name: alpha\nversion: 1.1.0\n")            // updated
+	writeManifest(t, dir, "beta", "name: beta\nversion: 1.0.0\nbogus: true\n") // now invalid
+	if err := os.RemoveAll(filepath.Join(dir, "gamma")); err != nil {          // removed
+		t.Fatal(err)
+	}
+	writeManifest(t, dir, "delta", "name: delta\nversion: 0.1.0\n") // added
+
+	summary, err := Reload(nil, registry, dir)
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	want := ReloadSummary{Added: []string{"delta"}, Updated: []string{"alpha"}, Removed: []string{"gamma"}, Kept: []string{"beta"}}
+	// Compiled-in plugins are unchanged and never reported.
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+
+	if m, _ := registry.Get("alpha"); m == nil || m.Version != "1.1.0" {
+		t.Errorf("alpha = %+v, want version 1.1.0", m)
+	}
+	if m, _ := registry.Get("beta"); m == nil || m.Version != "1.0.0" {
+		t.Errorf("beta = %+v, want previous version kept", m)
+	}
+	if _, ok := registry.Get("gamma"); ok {
+		t.Error("gamma still registered after removal")
+	}
+
+	// A second reload with no changes is a no-op.
+	summary, err = Reload(nil, registry, dir)
+	if err != nil || summary.Changed() {
+		t.Errorf("second Reload = %+v, %v; want no changes", summary, err)
+	}
+}
@@ -4,10 +4,31 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/jimdaga/first-sip/internal/pluginoutput"
 	"gopkg.in/yaml.v3"
 )
 
+// Capabilities a plugin manifest may declare.
+const (
+	CapabilityBriefing  = "briefing"  // produces a briefing shown on a dashboard tile
+	CapabilityScheduled = "scheduled" // can run on a user's cron schedule
+)
+
+// knownCapabilities and knownTileSizes are used to validate manifests.
+var knownCapabilities = map[string]bool{
+	CapabilityBriefing:  true,
+	CapabilityScheduled: true,
+}
+
+var knownTileSizes = map[string]bool{
+	"1x1": true,
+	"2x1": true,
+	"2x2": true,
+}
+
 // PluginMetadata represents the parsed plugin.yaml manifest file.
 // All plugins must provide name and version; other fields are optional.
 type PluginMetadata struct {
@@ -21,11 +42,56 @@ type PluginMetadata struct {
 	Capabilities       []string               `yaml:"capabilities"`
 	DefaultConfig      map[string]interface{} `yaml:"default_config"`
 	SettingsSchemaPath string                 `yaml:"settings_schema_path"`
+
+	// Runtime selects the Executor that runs this plugin. Defaults to crewai-stream.
+	Runtime string             `yaml:"runtime"`
+	HTTP    *HTTPRuntimeConfig `yaml:"http"` // required for runtime: http-webhook
+	Exec    *ExecRuntimeConfig `yaml:"exec"` // required for runtime: exec
+
+	// Output declares the run output schema (version and allowed block types).
+	// Omitted means v1: summary plus HTML sections.
+	Output *pluginoutput.Spec `yaml:"output"`
+
+	// Deprecated marks a plugin that still runs but is being retired;
+	// ReplacedBy optionally names the plugin users should switch to.
+	Deprecated bool   `yaml:"deprecated"`
+	ReplacedBy string `yaml:"replaced_by"`
+
+	// RequiresSecrets lists the user API keys the plugin needs (SecretLLM,
+	// SecretTavily); it cannot be enabled until the user has saved them.
+	// OptionalSecrets are keys it uses when present. Only declared secrets
+	// are injected into its runs. Network declares that the plugin calls out
+	// to the internet; it is shown to users before they enable the plugin
+	// and required for http-webhook plugins, but runs are not sandboxed, so
+	// network: false is not enforced. MinTier is the lowest account tier
+	// allowed to enable it ("" for any).
+	RequiresSecrets []string `yaml:"requires_secrets"`
+	OptionalSecrets []string `yaml:"optional_secrets"`
+	Network         bool     `yaml:"network"`
+	MinTier         string   `yaml:"min_tier"`
+
+	// Secrets declares plugin-specific credentials users save in the
+	// plugin's settings. Values are stored encrypted per user and passed to
+	// runs under SecretSlotPrefix; required slots block enabling until set.
+	Secrets []SecretSlot `yaml:"secrets"`
+
+	// Cache lets runs with identical non-secret inputs share one result
+	// across users. Omitted means every run executes. See CacheConfig.
+	Cache *CacheConfig `yaml:"cache"`
+
+	// SettingsMigrations upgrade user settings saved under an older major
+	// version when the settings schema changes. Patch files in migrations/
+	// are merged in by LoadPluginMetadata. See MigrateSettings.
+	SettingsMigrations []SettingsMigration `yaml:"settings_migrations"`
+
+	// Dir is the plugin's directory on disk, set by DiscoverPlugins.
+	Dir string `yaml:"-"`
 }
 
 // LoadPluginMetadata reads and parses a plugin.yaml file with strict validation.
-// Unknown YAML fields are rejected (via KnownFields), and required fields are validated.
-// SchemaVersion defaults to "v1" if not provided.
+// Unknown YAML fields are rejected (via KnownFields); required fields, tile_size,
+// capabilities and runtime configuration are validated.
+// SchemaVersion defaults to "v1" and Runtime to "crewai-stream" if not provided.
 func LoadPluginMetadata(path string) (*PluginMetadata, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -45,6 +111,11 @@ func LoadPluginMetadata(path string) (*PluginMetadata, error) {
 		meta.SchemaVersion = "v1"
 	}
 
+	// Set default runtime if not provided
+	if meta.Runtime == "" {
+		meta.Runtime = RuntimeCrewAIStream
+	}
+
 	// Validate required fields
 	if meta.Name == "" {
 		return nil, fmt.Errorf("plugin metadata missing required field: name")
@@ -53,5 +124,79 @@ func LoadPluginMetadata(path string) (*PluginMetadata, error) {
 		return nil, fmt.Errorf("plugin metadata missing required field: version")
 	}
 
+	if meta.TileSize != "" && !knownTileSizes[meta.TileSize] {
+		return nil, fmt.Errorf("plugin metadata has unknown tile_size: %q (want 1x1, 2x1 or 2x2)", meta.TileSize)
+	}
+	for _, c := range meta.Capabilities {
+		if !knownCapabilities[c] {
+			return nil, fmt.Errorf("plugin metadata has unknown capability: %q", c)
+		}
+	}
+
+	// Validate runtime and its runtime-specific configuration
+	if !knownRuntimes[meta.Runtime] {
+		return nil, fmt.Errorf("plugin metadata has unknown runtime: %q", meta.Runtime)
+	}
+	if meta.Runtime == RuntimeHTTPWebhook && (meta.HTTP == nil || meta.HTTP.URL == "") {
+		return nil, fmt.Errorf("plugin metadata missing required field for runtime %s: http.url", meta.Runtime)
+	}
+	if meta.Runtime == RuntimeExec && (meta.Exec == nil || len(meta.Exec.Command) == 0) {
+		return nil, fmt.Errorf("plugin metadata missing required field for runtime %s: exec.command", meta.Runtime)
+	}
+
+	if err := checkRequirements(&meta); err != nil {
+		return nil, fmt.Errorf("plugin metadata has %w", err)
+	}
+	if err := checkSecretSlots(meta.Secrets); err != nil {
+		return nil, fmt.Errorf("plugin metadata has %w", err)
+	}
+	if err := checkHTTPHeaders(&meta); err != nil {
+		return nil, fmt.Errorf("plugin metadata has %w", err)
+	}
+	if err := checkCache(&meta); err != nil {
+		return nil, fmt.Errorf("plugin metadata has %w", err)
+	}
+
+	if meta.ReplacedBy != "" && !meta.Deprecated {
+		return nil, fmt.Errorf("plugin metadata sets replaced_by without deprecated: true")
+	}
+	if meta.ReplacedBy == meta.Name && meta.Name != "" {
+		return nil, fmt.Errorf("plugin metadata replaced_by names the plugin itself")
+	}
+
+	meta.SettingsMigrations, err = loadMigrationFiles(filepath.Dir(path), meta.SettingsMigrations)
+	if err != nil {
+		return nil, fmt.Errorf("plugin metadata has invalid settings migration file: %w", err)
+	}
+	if err := checkSettingsMigrations(meta.SettingsMigrations); err != nil {
+		return nil, fmt.Errorf("plugin metadata has invalid settings_migrations: %w", err)
+	}
+
+	if meta.Output != nil {
+		if err := meta.Output.Check(); err != nil {
+			return nil, fmt.Errorf("plugin metadata has invalid output schema: %w", err)
+		}
+	}
+
 	return &meta, nil
 }
+
+// OutputSpec returns the declared output schema, or the zero (v1) Spec.
+func (m *PluginMetadata) OutputSpec() pluginoutput.Spec {
+	if m == nil || m.Output == nil {
+		return pluginoutput.Spec{}
+	}
+	return *m.Output
+}
+
+// HumanizeName converts a kebab-case plugin name to title case for display.
+// E.g. "daily-news-digest" → "Daily News Digest".
+func HumanizeName(name string) string {
+	words := strings.Split(name, "-")
+	for i, w := range words {
+		if len(w) > 0 {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
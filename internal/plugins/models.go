@@ -16,6 +16,15 @@ const (
 	PluginRunStatusProcessing = "processing"
 	PluginRunStatusCompleted  = "completed"
 	PluginRunStatusFailed     = "failed"
+	PluginRunStatusCancelled  = "cancelled"
+	PluginRunStatusTimedOut   = "timed_out"
+)
+
+// Plugin status constants. A plugin becomes unavailable when it disappears
+// from the plugin directory; its row, user configs and run history are kept.
+const (
+	PluginStatusAvailable   = "available"
+	PluginStatusUnavailable = "unavailable"
 )
 
 // Plugin represents a discovered plugin with its metadata
@@ -32,6 +41,18 @@ type Plugin struct {
 	DefaultConfig      datatypes.JSON `gorm:"type:jsonb;column:default_config"`
 	SettingsSchemaPath string         `gorm:"column:settings_schema_path"`
 	Enabled            bool           `gorm:"default:true"`
+	Status             string         `gorm:"column:status;not null;default:'available'"`
+	UnavailableSince   *time.Time     `gorm:"column:unavailable_since"` // nil while available
+	Deprecated         bool           `gorm:"column:deprecated;not null;default:false"`
+	ReplacedBy         string         `gorm:"column:replaced_by;not null;default:''"`
+	SchemaHash         string         `gorm:"column:schema_hash;not null;default:''"` // see SettingsSchemaHash
+
+	// Requirements declared in the manifest; see PluginMetadata.
+	RequiresSecrets datatypes.JSON `gorm:"column:requires_secrets;type:jsonb;not null;default:'[]'"`
+	OptionalSecrets datatypes.JSON `gorm:"column:optional_secrets;type:jsonb;not null;default:'[]'"`
+	Network         bool           `gorm:"column:network;not null;default:false"`
+	MinTier         string         `gorm:"column:min_tier;not null;default:''"`
+	SecretSlots     datatypes.JSON `gorm:"column:secret_slots;type:jsonb;not null;default:'[]'"` // []SecretSlot
 }
 
 // UserPluginConfig stores per-user per-plugin settings including optional scheduling
@@ -43,8 +64,24 @@ type UserPluginConfig struct {
 	Enabled        bool           `gorm:"default:false"`
 	CronExpression string         `gorm:"column:cron_expression"` // nullable — empty means no schedule
 	DisplayOrder   *int           `gorm:"column:display_order"`   // nullable — nil means unordered
+	PushEnabled    bool           `gorm:"column:push_enabled"`    // Web Push when a run completes
+	WhatsNewOnly   bool           `gorm:"column:whats_new_only"`  // tile shows only what changed since the previous run
 	User           models.User    `gorm:"constraint:OnDelete:CASCADE;"`
 	Plugin         Plugin         `gorm:"constraint:OnDelete:CASCADE;"`
+
+	// SettingsVersion and SettingsSchemaHash record the plugin version and
+	// settings schema the settings were last saved (or migrated) under.
+	SettingsVersion    string `gorm:"column:settings_version;not null;default:''"`
+	SettingsSchemaHash string `gorm:"column:settings_schema_hash;not null;default:''"`
+
+	// SettingsErrors holds schema validation errors (JSON pointer → message)
+	// found when settings were re-validated at sync; NULL while valid.
+	SettingsErrors datatypes.JSON `gorm:"column:settings_errors;type:jsonb"`
+
+	// SchedulePaused stops scheduled runs while the plugin has been
+	// unavailable past the grace period; CronExpression is kept so the
+	// schedule resumes when the plugin is restored.
+	SchedulePaused bool `gorm:"column:schedule_paused;not null;default:false"`
 }
 
 // PluginRun tracks a single plugin execution
@@ -61,6 +98,41 @@ type PluginRun struct {
 	CompletedAt  *time.Time     `gorm:"column:completed_at"`
 	User         models.User    `gorm:"constraint:OnDelete:CASCADE;"`
 	Plugin       Plugin         `gorm:"constraint:OnDelete:CASCADE;"`
+
+	// PluginVersion and SchemaHash pin the exact plugin version and settings
+	// schema that produced the run.
+	PluginVersion string `gorm:"column:plugin_version;not null;default:''"`
+	SchemaHash    string `gorm:"column:schema_hash;not null;default:''"`
+
+	// InputHash identifies the run's inputs for plugins that share results
+	// (see SharedInputHash). SharedFromRunID is the plugin_run_id of the run
+	// whose output this run reused or waited on; "" when it ran itself.
+	InputHash       string `gorm:"column:input_hash;not null;default:''"`
+	SharedFromRunID string `gorm:"column:shared_from_run_id;not null;default:''"`
+}
+
+// PluginVersion is one version of a plugin seen at sync time. Rows are kept
+// after the plugin moves on, giving a version history per plugin.
+type PluginVersion struct {
+	ID            uint      `gorm:"primarykey"`
+	CreatedAt     time.Time `gorm:"not null"` // when this version was first synced
+	PluginID      uint      `gorm:"not null;uniqueIndex:uq_plugin_versions_plugin_version"`
+	Version       string    `gorm:"not null;uniqueIndex:uq_plugin_versions_plugin_version"`
+	SchemaVersion string    `gorm:"column:schema_version;not null;default:'v1'"`
+	SchemaHash    string    `gorm:"column:schema_hash;not null;default:''"`
+}
+
+// PluginRunTransitionRejection records a status transition that was refused by the
+// PluginRun state machine (duplicate results, late results for reaped runs, etc.).
+// Rows are append-only and exist purely for debugging.
+type PluginRunTransitionRejection struct {
+	ID          uint      `gorm:"primarykey"`
+	CreatedAt   time.Time `gorm:"not null"`
+	PluginRunID string    `gorm:"not null;index"`
+	FromStatus  string    `gorm:"column:from_status;not null;default:''"` // "" when the run does not exist
+	ToStatus    string    `gorm:"column:to_status;not null"`
+	Source      string    `gorm:"not null;default:''"` // e.g. "result-consumer", "worker", "reaper"
+	Reason      string    `gorm:"type:text"`
 }
 
 // cronParser is a shared parser for standard 5-field cron expressions.
@@ -3,12 +3,19 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"os"
 	"sort"
+	"sync"
 )
 
 // Registry holds discovered plugins in memory, indexed by plugin name.
 // Provides methods for registration, lookup, and listing.
+//
+// A Registry is safe for concurrent use. Replace swaps in the contents of
+// another registry atomically, so holders of the pointer (executors, the
+// result consumer) see a plugin directory reload without being rewired.
 type Registry struct {
+	mu      sync.RWMutex
 	plugins map[string]*PluginMetadata
 }
 
@@ -22,6 +29,8 @@ func NewRegistry() *Registry {
 // Register adds a plugin to the registry.
 // Returns an error if a plugin with the same name is already registered.
 func (r *Registry) Register(meta *PluginMetadata) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if _, exists := r.plugins[meta.Name]; exists {
 		return fmt.Errorf("plugin already registered: %s", meta.Name)
 	}
@@ -32,6 +41,8 @@ func (r *Registry) Register(meta *PluginMetadata) error {
 // Get retrieves a plugin by name.
 // Returns the plugin metadata and a boolean indicating if it was found.
 func (r *Registry) Get(name string) (*PluginMetadata, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	meta, ok := r.plugins[name]
 	return meta, ok
 }
@@ -39,10 +50,12 @@ func (r *Registry) Get(name string) (*PluginMetadata, bool) {
 // List returns all registered plugins as a slice, sorted by name
 // for deterministic ordering.
 func (r *Registry) List() []*PluginMetadata {
+	r.mu.RLock()
 	plugins := make([]*PluginMetadata, 0, len(r.plugins))
 	for _, meta := range r.plugins {
 		plugins = append(plugins, meta)
 	}
+	r.mu.RUnlock()
 
 	// Sort by name for deterministic ordering
 	sort.Slice(plugins, func(i, j int) bool {
@@ -54,19 +67,46 @@ func (r *Registry) List() []*PluginMetadata {
 
 // Count returns the number of registered plugins.
 func (r *Registry) Count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return len(r.plugins)
 }
 
+// Replace atomically replaces r's plugins with those of next. next must not be
+// used afterwards.
+func (r *Registry) Replace(next *Registry) {
+	next.mu.RLock()
+	plugins := next.plugins
+	next.mu.RUnlock()
+
+	r.mu.Lock()
+	r.plugins = plugins
+	r.mu.Unlock()
+}
+
 // LoadRegistry is a convenience function that discovers plugins from
-// the specified directory and registers them in a new Registry.
+// the specified directory and registers them in a new Registry, followed by
+// any compiled-in plugins (see RegisterCompiled).
 //
-// Duplicate plugin names are logged and skipped. An empty registry
+// Duplicate plugin names are logged and skipped; a directory plugin takes
+// precedence over a compiled-in plugin of the same name. An empty registry
 // is not an error (no plugins found is valid).
 func LoadRegistry(pluginDir string) (*Registry, error) {
+	registry, _, err := loadRegistry(pluginDir)
+	return registry, err
+}
+
+// loadRegistry is LoadRegistry that also returns the plugin directories whose
+// manifests failed to load (see discoverPlugins).
+func loadRegistry(pluginDir string) (*Registry, map[string]error, error) {
 	// Discover all plugins in directory
-	discovered, err := DiscoverPlugins(pluginDir)
+	discovered, failed, err := discoverPlugins(pluginDir)
 	if err != nil {
-		return nil, err
+		if !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+		// No plugin directory — compiled-in plugins are still available.
+		log.Printf("Warning: plugin directory %s does not exist", pluginDir)
 	}
 
 	// Create registry and register discovered plugins
@@ -77,6 +117,12 @@ func LoadRegistry(pluginDir string) (*Registry, error) {
 			continue
 		}
 	}
+	for _, meta := range compiledManifests() {
+		if err := registry.Register(meta); err != nil {
+			log.Printf("Warning: duplicate plugin name, skipping compiled-in %s: %v", meta.Name, err)
+			continue
+		}
+	}
 
-	return registry, nil
+	return registry, failed, nil
 }
@@ -0,0 +1,138 @@
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"gorm.io/gorm"
+)
+
+// Secrets a plugin may declare under requires_secrets. Each names a
+// user_api_keys key_type; SecretLLM is satisfied by a key for the user's
+// preferred LLM provider.
+const (
+	SecretLLM    = "llm"
+	SecretTavily = "tavily"
+)
+
+var knownSecrets = map[string]bool{
+	SecretLLM:    true,
+	SecretTavily: true,
+}
+
+// Account tiers a plugin may require with min_tier, lowest first.
+const (
+	TierFree = "free"
+	TierPro  = "pro"
+)
+
+var tierRanks = map[string]int{
+	TierFree: 0,
+	TierPro:  1,
+}
+
+// TierAtLeast reports whether tier meets minTier. An empty minTier is met by
+// every tier; unknown tiers meet only an empty minTier.
+func TierAtLeast(tier, minTier string) bool {
+	if minTier == "" {
+		return true
+	}
+	have, ok := tierRanks[tier]
+	if !ok {
+		return false
+	}
+	return have >= tierRanks[minTier]
+}
+
+// checkRequirements validates requires_secrets, optional_secrets, network and
+// min_tier.
+func checkRequirements(meta *PluginMetadata) error {
+	seen := make(map[string]bool, len(meta.RequiresSecrets)+len(meta.OptionalSecrets))
+	for _, list := range []struct {
+		field   string
+		secrets []string
+	}{
+		{"requires_secrets", meta.RequiresSecrets},
+		{"optional_secrets", meta.OptionalSecrets},
+	} {
+		for _, s := range list.secrets {
+			if !knownSecrets[s] {
+				return fmt.Errorf("unknown %s entry: %q (want llm or tavily)", list.field, s)
+			}
+			if seen[s] {
+				return fmt.Errorf("secret %q is declared more than once", s)
+			}
+			seen[s] = true
+		}
+	}
+	if meta.Runtime == RuntimeHTTPWebhook && !meta.Network {
+		return fmt.Errorf("runtime %s without network: true (it calls a remote endpoint)", meta.Runtime)
+	}
+	if meta.MinTier != "" {
+		if _, ok := tierRanks[meta.MinTier]; !ok {
+			return fmt.Errorf("unknown min_tier: %q (want free or pro)", meta.MinTier)
+		}
+	}
+	return nil
+}
+
+// DeclaredSecrets returns the required then optional secrets of the plugin,
+// the only ones injected into its runs.
+func (m *PluginMetadata) DeclaredSecrets() []string {
+	if m == nil {
+		return nil
+	}
+	return append(append([]string{}, m.RequiresSecrets...), m.OptionalSecrets...)
+}
+
+// RequiresSecret reports whether the plugin declares secret in requires_secrets.
+func (m *PluginMetadata) RequiresSecret(secret string) bool {
+	if m == nil {
+		return false
+	}
+	for _, s := range m.RequiresSecrets {
+		if s == secret {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingSecrets returns the entries of required the user has not saved an
+// API key for, in order. Key values are not loaded or decrypted.
+func MissingSecrets(db *gorm.DB, userID uint, required []string) ([]string, error) {
+	if len(required) == 0 {
+		return nil, nil
+	}
+	var user models.User
+	if err := db.Select("id", "llm_preferred_provider").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("load user %d: %w", userID, err)
+	}
+	var keys []struct {
+		KeyType  string
+		Provider string
+	}
+	if err := db.Model(&models.UserAPIKey{}).Select("key_type", "provider").
+		Where("user_id = ?", userID).Scan(&keys).Error; err != nil {
+		return nil, fmt.Errorf("load API keys for user %d: %w", userID, err)
+	}
+
+	have := make(map[string]bool)
+	for _, k := range keys {
+		switch k.KeyType {
+		case SecretLLM:
+			if k.Provider == user.LLMPreferredProvider {
+				have[SecretLLM] = true
+			}
+		default:
+			have[k.KeyType] = true
+		}
+	}
+	var missing []string
+	for _, s := range required {
+		if !have[s] {
+			missing = append(missing, s)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,62 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTierAtLeast(t *testing.T) {
+	tests := []struct {
+		tier, min string
+		want      bool
+	}{
+		{"free", "", true},
+		{"free", "pro", false},
+		{"pro", "pro", true},
+		{"pro", "free", true},
+		{"enterprise", "pro", false},
+	}
+	for _, tt := range tests {
+		if got := TierAtLeast(tt.tier, tt.min); got != tt.want {
+			t.Errorf("TierAtLeast(%q, %q) = %v, want %v", tt.tier, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestLoadPluginMetadataRequirements(t *testing.T) {
+	load := func(extra string) (*PluginMetadata, error) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "plugin.yaml")
+		if err := os.WriteFile(path, []byte("name: p\nversion: 1.0.0\n"+extra), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return LoadPluginMetadata(path)
+	}
+
+	meta, err := load("requires_secrets: [llm]\noptional_secrets: [tavily]\nnetwork: true\nmin_tier: pro\n")
+	if err != nil {
+		t.Fatalf("LoadPluginMetadata: %v", err)
+	}
+	if !meta.RequiresSecret(SecretLLM) || meta.RequiresSecret(SecretTavily) || !meta.Network || meta.MinTier != TierPro {
+		t.Errorf("requirements = %+v", meta)
+	}
+	if got := strings.Join(meta.DeclaredSecrets(), ","); got != "llm,tavily" {
+		t.Errorf("DeclaredSecrets = %s, want llm,tavily", got)
+	}
+	if _, err := load("runtime: http-webhook\nhttp: {url: \"https://example.com\"}\nnetwork: true\n"); err != nil {
+		t.Errorf("http-webhook with network: %v", err)
+	}
+
+	for _, bad := range []string{
+		"requires_secrets: [openai]\n",
+		"requires_secrets: [llm]\noptional_secrets: [llm]\n",
+		"min_tier: gold\n",
+		"runtime: http-webhook\nhttp: {url: \"https://example.com\"}\n",
+	} {
+		if _, err := load(bad); err == nil {
+			t.Errorf("LoadPluginMetadata accepted %q", bad)
+		}
+	}
+}
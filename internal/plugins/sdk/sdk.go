@@ -0,0 +1,137 @@
+// Package sdk is the Go SDK for in-process briefing plugins.
+//
+// A compiled-in plugin implements BriefingPlugin and registers itself from an
+// init function:
+//
+//	func init() { sdk.Register(&myPlugin{}) }
+//
+// Registered plugins appear in the plugin registry alongside plugin.yaml
+// plugins, are synced to the database at startup, and are executed directly by
+// the worker (runtime go-native) without Redis Streams or the CrewAI sidecar.
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/pluginoutput"
+	"github.com/jimdaga/first-sip/internal/plugins"
+)
+
+// Manifest describes a compiled-in plugin. It mirrors the fields of plugin.yaml
+// that apply to in-process plugins.
+type Manifest struct {
+	Name          string
+	Description   string
+	Owner         string
+	Version       string
+	Icon          string // emoji for the dashboard tile
+	TileSize      string // "1x1", "2x1", "2x2"
+	Capabilities  []string
+	DefaultConfig map[string]interface{}
+	Output        *pluginoutput.Spec // output schema; nil means v1
+	Deprecated    bool               // still runs, but users are told to switch
+	ReplacedBy    string             // name of the successor plugin, if any
+
+	// Requirements, as in plugin.yaml: only declared secrets are passed to Run.
+	RequiresSecrets []string // e.g. plugins.SecretLLM
+	OptionalSecrets []string
+	Network         bool
+	MinTier         string               // e.g. plugins.TierPro; "" for any tier
+	Secrets         []plugins.SecretSlot // passed to Run under plugins.SecretSlotPrefix
+	Cache           *plugins.CacheConfig // share results across users with identical settings
+}
+
+// Section is a titled block of a plugin's output. Content is rendered as HTML,
+// so plugins must escape any untrusted text they include. Blocks are typed,
+// plain-text elements and require Output.Version "v2".
+type Section struct {
+	Title   string               `json:"title"`
+	Content string               `json:"content"`
+	Blocks  []pluginoutput.Block `json:"blocks,omitempty"`
+}
+
+// Output is the result of a plugin run. It is stored as the PluginRun output and
+// rendered by the dashboard tile (Summary) and detail page (Sections).
+type Output struct {
+	Version  string    `json:"version,omitempty"` // "v2" when sections carry blocks
+	Summary  string    `json:"summary"`
+	Sections []Section `json:"sections"`
+}
+
+// BriefingPlugin is implemented by compiled-in plugins.
+type BriefingPlugin interface {
+	// Manifest returns the plugin's metadata. Name and Version are required.
+	Manifest() Manifest
+	// SettingsSchema returns the JSON Schema for the plugin's user settings,
+	// or nil if the plugin has no settings.
+	SettingsSchema() []byte
+	// Run produces the plugin output for one user. settings are the user's
+	// plugin settings; secrets are the injected user credentials (e.g. _llm_api_key,
+	// or _secret_<name> for a declared secret slot). ctx carries the user's
+	// timezone (see Location). A returned error fails the run with the error
+	// message.
+	Run(ctx context.Context, settings map[string]interface{}, secrets map[string]string) (Output, error)
+}
+
+// Location returns the timezone of the user a plugin is running for, or UTC.
+// Plugins that deal in calendar days should use it instead of time.Local.
+func Location(ctx context.Context) *time.Location {
+	return plugins.UserLocation(ctx)
+}
+
+// Register adds a compiled-in plugin to the plugin registry. It panics if the
+// manifest is invalid, the schema is not valid JSON, or the name is taken, so
+// mistakes surface at startup. Call it from an init function.
+func Register(p BriefingPlugin) {
+	if err := register(p); err != nil {
+		panic("sdk: " + err.Error())
+	}
+}
+
+// register validates p and hands it to the plugins package.
+func register(p BriefingPlugin) error {
+	m := p.Manifest()
+
+	if m.Output != nil {
+		if err := m.Output.Check(); err != nil {
+			return fmt.Errorf("plugin %s: %w", m.Name, err)
+		}
+	}
+
+	schema := p.SettingsSchema()
+	if len(schema) > 0 && !json.Valid(schema) {
+		return fmt.Errorf("plugin %s: settings schema is not valid JSON", m.Name)
+	}
+
+	meta := &plugins.PluginMetadata{
+		Name:          m.Name,
+		Description:   m.Description,
+		Owner:         m.Owner,
+		Version:       m.Version,
+		Icon:          m.Icon,
+		TileSize:      m.TileSize,
+		Capabilities:  m.Capabilities,
+		DefaultConfig: m.DefaultConfig,
+		Output:        m.Output,
+		Deprecated:    m.Deprecated,
+		ReplacedBy:    m.ReplacedBy,
+
+		RequiresSecrets: m.RequiresSecrets,
+		OptionalSecrets: m.OptionalSecrets,
+		Network:         m.Network,
+		MinTier:         m.MinTier,
+		Secrets:         m.Secrets,
+		Cache:           m.Cache,
+	}
+
+	return plugins.RegisterCompiled(meta, schema, func(ctx context.Context, settings map[string]interface{}, secrets map[string]string) (json.RawMessage, error) {
+		out, err := p.Run(ctx, settings, secrets)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(out)
+	})
+}
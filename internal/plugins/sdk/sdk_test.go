@@ -0,0 +1,115 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/pluginoutput"
+	"github.com/jimdaga/first-sip/internal/plugins"
+)
+
+// testPlugin reports the settings, secrets and timezone it was run with.
+type testPlugin struct {
+	manifest Manifest
+	schema   []byte
+	err      error
+}
+
+func (p *testPlugin) Manifest() Manifest     { return p.manifest }
+func (p *testPlugin) SettingsSchema() []byte { return p.schema }
+
+func (p *testPlugin) Run(ctx context.Context, settings map[string]interface{}, secrets map[string]string) (Output, error) {
+	if p.err != nil {
+		return Output{}, p.err
+	}
+	topic, _ := settings["topic"].(string)
+	return Output{
+		Summary:  topic + " in " + Location(ctx).String(),
+		Sections: []Section{{Title: "Secrets", Content: secrets["_llm_api_key"]}},
+	}, nil
+}
+
+func TestRegisterAndRun(t *testing.T) {
+	p := &testPlugin{
+		manifest: Manifest{Name: "sdk-test-run", Version: "1.0.0", RequiresSecrets: []string{plugins.SecretLLM}},
+		schema:   []byte(`{"type":"object"}`),
+	}
+	if err := register(p); err != nil {
+		t.Fatal(err)
+	}
+	if !plugins.IsCompiled("sdk-test-run") {
+		t.Fatal("plugin not registered as compiled")
+	}
+	if schema, ok := plugins.CompiledSettingsSchema("sdk-test-run"); !ok || string(schema) != `{"type":"object"}` {
+		t.Errorf("settings schema = %s, %v", schema, ok)
+	}
+	if err := register(p); err == nil {
+		t.Error("expected an error registering the same name twice")
+	}
+
+	loc := time.FixedZone("Test", 3*60*60)
+	req := plugins.ExecutionRequest{
+		PluginRunID: "run-1",
+		Plugin:      &plugins.PluginMetadata{Name: "sdk-test-run"},
+		Settings:    map[string]interface{}{"topic": "science"},
+		Secrets:     map[string]string{"_llm_api_key": "sk-user"},
+		Location:    loc,
+	}
+	result, err := plugins.NewNativeExecutor().Execute(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != plugins.PluginRunStatusCompleted {
+		t.Fatalf("result = %+v", result)
+	}
+	var out Output
+	if err := json.Unmarshal([]byte(result.Output), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Summary != "science in Test" || len(out.Sections) != 1 || out.Sections[0].Content != "sk-user" {
+		t.Errorf("output = %+v", out)
+	}
+
+	// Without a location the plugin runs in UTC.
+	req.Location = nil
+	result, _ = plugins.NewNativeExecutor().Execute(context.Background(), req)
+	if !strings.Contains(result.Output, "science in UTC") {
+		t.Errorf("output = %s, want UTC", result.Output)
+	}
+
+	// A returned error fails the run with its message.
+	p.err = errors.New("feed unavailable")
+	result, err = plugins.NewNativeExecutor().Execute(context.Background(), req)
+	if err != nil || result.Status != plugins.PluginRunStatusFailed || result.Error != "feed unavailable" {
+		t.Errorf("failing run = %+v, %v", result, err)
+	}
+}
+
+func TestRegisterValidates(t *testing.T) {
+	for name, m := range map[string]Manifest{
+		"missing version": {Name: "sdk-test-noversion"},
+		"bad output":      {Name: "sdk-test-output", Version: "1.0.0", Output: &pluginoutput.Spec{Version: "v9"}},
+		"bad secret":      {Name: "sdk-test-secret", Version: "1.0.0", RequiresSecrets: []string{"github"}},
+	} {
+		if err := register(&testPlugin{manifest: m}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := register(&testPlugin{manifest: Manifest{Name: "sdk-test-schema", Version: "1.0.0"}, schema: []byte(`{"type":`)}); err == nil {
+		t.Error("expected an error for an invalid settings schema")
+	}
+	if plugins.IsCompiled("sdk-test-noversion") || plugins.IsCompiled("sdk-test-schema") {
+		t.Error("invalid plugins were registered")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register did not panic on an invalid manifest")
+		}
+	}()
+	Register(&testPlugin{manifest: Manifest{Name: "sdk-test-panic"}})
+}
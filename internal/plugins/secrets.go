@@ -0,0 +1,178 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"gorm.io/gorm"
+)
+
+// SecretSlotPrefix prefixes a secret slot's name when its value is passed to
+// a run, so a slot named "github_token" arrives as "_secret_github_token".
+const SecretSlotPrefix = "_secret_"
+
+// slotNamePattern matches secret slot names; they become form field and
+// settings key suffixes.
+var slotNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// maxSecretLength bounds a saved secret value.
+const maxSecretLength = 4096
+
+// SecretSlot is a plugin-specific credential declared under `secrets:` in
+// plugin.yaml (e.g. a GitHub token). Users save a value per slot in the
+// plugin's settings; values are stored encrypted and passed only to runs of
+// the declaring plugin.
+type SecretSlot struct {
+	Name        string `yaml:"name" json:"name"`
+	Label       string `yaml:"label" json:"label"`
+	Description string `yaml:"description" json:"description,omitempty"`
+	// Pattern, if set, is a regular expression the whole value must match.
+	Pattern  string `yaml:"pattern" json:"pattern,omitempty"`
+	Required bool   `yaml:"required" json:"required"`
+}
+
+// Validate checks a value about to be saved for the slot.
+func (s SecretSlot) Validate(value string) error {
+	if value == "" {
+		return errors.New("value is empty")
+	}
+	if len(value) > maxSecretLength {
+		return fmt.Errorf("value is longer than %d characters", maxSecretLength)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(`^(?:` + s.Pattern + `)$`)
+		if err != nil {
+			return fmt.Errorf("slot pattern does not compile: %w", err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("value does not look like a valid %s", s.Label)
+		}
+	}
+	return nil
+}
+
+// checkSecretSlots validates the secrets list of a manifest.
+func checkSecretSlots(slots []SecretSlot) error {
+	seen := make(map[string]bool, len(slots))
+	for _, s := range slots {
+		if !slotNamePattern.MatchString(s.Name) {
+			return fmt.Errorf("invalid secrets name: %q (want lowercase letters, digits and underscores)", s.Name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("secret slot %q is declared more than once", s.Name)
+		}
+		seen[s.Name] = true
+		if s.Label == "" {
+			return fmt.Errorf("secret slot %q is missing a label", s.Name)
+		}
+		if s.Pattern != "" {
+			if _, err := regexp.Compile(s.Pattern); err != nil {
+				return fmt.Errorf("secret slot %q has an invalid pattern: %v", s.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SecretSlot returns the slot the plugin declares under name.
+func (m *PluginMetadata) SecretSlot(name string) (SecretSlot, bool) {
+	if m != nil {
+		for _, s := range m.Secrets {
+			if s.Name == name {
+				return s, true
+			}
+		}
+	}
+	return SecretSlot{}, false
+}
+
+// SaveUserSecret validates value against slot and upserts it for the user and
+// plugin. Encryption is handled by the UserPluginSecret BeforeSave hook.
+func SaveUserSecret(db *gorm.DB, userID, pluginID uint, slot SecretSlot, value string) error {
+	if err := slot.Validate(value); err != nil {
+		return err
+	}
+	var existing models.UserPluginSecret
+	err := db.Where("user_id = ? AND plugin_id = ? AND name = ?", userID, pluginID, slot.Name).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		secret := models.UserPluginSecret{
+			UserID:         userID,
+			PluginID:       pluginID,
+			Name:           slot.Name,
+			EncryptedValue: value,
+		}
+		if err := db.Create(&secret).Error; err != nil {
+			return fmt.Errorf("creating plugin secret: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("looking up plugin secret: %w", err)
+	}
+	existing.EncryptedValue = value
+	if err := db.Save(&existing).Error; err != nil {
+		return fmt.Errorf("updating plugin secret: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserSecret soft-deletes the user's value for a plugin's secret slot.
+func DeleteUserSecret(db *gorm.DB, userID, pluginID uint, name string) error {
+	if err := db.Where("user_id = ? AND plugin_id = ? AND name = ?", userID, pluginID, name).
+		Delete(&models.UserPluginSecret{}).Error; err != nil {
+		return fmt.Errorf("deleting plugin secret: %w", err)
+	}
+	return nil
+}
+
+// SavedSecretNames returns, per plugin ID, the slot names the user has saved
+// a value for. Values are not loaded or decrypted.
+func SavedSecretNames(db *gorm.DB, userID uint) (map[uint]map[string]bool, error) {
+	var rows []struct {
+		PluginID uint
+		Name     string
+	}
+	if err := db.Model(&models.UserPluginSecret{}).Select("plugin_id", "name").
+		Where("user_id = ?", userID).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("loading plugin secrets for user %d: %w", userID, err)
+	}
+	saved := make(map[uint]map[string]bool)
+	for _, r := range rows {
+		if saved[r.PluginID] == nil {
+			saved[r.PluginID] = make(map[string]bool)
+		}
+		saved[r.PluginID][r.Name] = true
+	}
+	return saved, nil
+}
+
+// UserSecretValues returns the user's decrypted values for the plugin's
+// declared slots, keyed by SecretSlotPrefix plus the slot name, and the
+// required slots that have no value. Values saved for slots the manifest no
+// longer declares are ignored.
+func UserSecretValues(db *gorm.DB, userID, pluginID uint, slots []SecretSlot) (map[string]string, []string, error) {
+	if len(slots) == 0 {
+		return nil, nil, nil
+	}
+	var saved []models.UserPluginSecret
+	if err := db.Where("user_id = ? AND plugin_id = ?", userID, pluginID).Find(&saved).Error; err != nil {
+		return nil, nil, fmt.Errorf("loading plugin secrets: %w", err)
+	}
+	byName := make(map[string]string, len(saved))
+	for _, s := range saved {
+		byName[s.Name] = s.EncryptedValue // AfterFind hook has already decrypted
+	}
+
+	values := make(map[string]string)
+	var missing []string
+	for _, slot := range slots {
+		if v := byName[slot.Name]; v != "" {
+			values[SecretSlotPrefix+slot.Name] = v
+		} else if slot.Required {
+			missing = append(missing, slot.Name)
+		}
+	}
+	return values, missing, nil
+}
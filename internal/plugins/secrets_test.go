@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPluginMetadataSecretSlots(t *testing.T) {
+	load := func(secrets string) (*PluginMetadata, error) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "plugin.yaml")
+		if err := os.WriteFile(path, []byte("name: p\nversion: 1.0.0\nsecrets:\n"+secrets), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return LoadPluginMetadata(path)
+	}
+
+	meta, err := load("  - name: github_token\n    label: GitHub token\n    pattern: \"gh[po]_[A-Za-z0-9]+\"\n    required: true\n")
+	if err != nil {
+		t.Fatalf("LoadPluginMetadata: %v", err)
+	}
+	slot, ok := meta.SecretSlot("github_token")
+	if !ok || !slot.Required || slot.Label != "GitHub token" {
+		t.Fatalf("SecretSlot = %+v, %v", slot, ok)
+	}
+
+	for _, bad := range []string{
+		"  - name: GitHub-Token\n    label: x\n",
+		"  - name: token\n",
+		"  - name: token\n    label: a\n  - name: token\n    label: b\n",
+		"  - name: token\n    label: x\n    pattern: \"[\"\n",
+	} {
+		if _, err := load(bad); err == nil {
+			t.Errorf("LoadPluginMetadata accepted %q", bad)
+		}
+	}
+}
+
+func TestSecretSlotValidate(t *testing.T) {
+	slot := SecretSlot{Name: "github_token", Label: "GitHub token", Pattern: "gh[po]_[A-Za-z0-9]+"}
+	if err := slot.Validate("ghp_abc123"); err != nil {
+		t.Errorf("Validate(valid) = %v", err)
+	}
+	// The pattern must match the whole value, not a substring.
+	for _, bad := range []string{"", "token ghp_abc123", "ghp_abc123!"} {
+		if err := slot.Validate(bad); err == nil {
+			t.Errorf("Validate(%q) = nil error", bad)
+		}
+	}
+	if err := (SecretSlot{Name: "url", Label: "Feed URL"}).Validate("anything"); err != nil {
+		t.Errorf("Validate without pattern = %v", err)
+	}
+}
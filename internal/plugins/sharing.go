@@ -0,0 +1,232 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Cache modes a plugin may declare under cache.mode.
+const (
+	CacheModeNone   = "none"
+	CacheModeShared = "shared"
+)
+
+// defaultCacheMaxAge applies when cache.max_age is omitted.
+const defaultCacheMaxAge = time.Hour
+
+// CacheConfig declares whether runs with identical inputs may share a result.
+//
+// With mode shared, a run whose non-secret settings and plugin version match
+// a run completed within max_age reuses that run's output, and one matching a
+// run still in flight waits for it instead of executing. Only plugins whose
+// output depends on nothing but those settings should opt in: user
+// credentials are not part of the match.
+type CacheConfig struct {
+	Mode   string `yaml:"mode"`    // CacheModeNone (default) or CacheModeShared
+	MaxAge string `yaml:"max_age"` // Go duration, e.g. "2h"; defaults to 1h
+
+	// Ignore lists settings that do not change the output (e.g. schedule
+	// preferences) and are left out of the match.
+	Ignore []string `yaml:"ignore"`
+
+	// PerDay adds the user's local date to the match, for plugins whose output
+	// depends on the day (news digests, countdowns). Users in timezones already
+	// on a different date never share a run.
+	PerDay bool `yaml:"per_day"`
+}
+
+// checkCache validates the cache block of a manifest.
+func checkCache(meta *PluginMetadata) error {
+	c := meta.Cache
+	if c == nil {
+		return nil
+	}
+	switch c.Mode {
+	case "", CacheModeNone, CacheModeShared:
+	default:
+		return fmt.Errorf("unknown cache.mode: %q (want none or shared)", c.Mode)
+	}
+	if c.MaxAge != "" {
+		d, err := time.ParseDuration(c.MaxAge)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid cache.max_age: %q (want a positive duration such as 2h)", c.MaxAge)
+		}
+	}
+	if c.Mode == CacheModeShared && len(meta.Secrets) > 0 {
+		return errors.New("cache.mode shared cannot be combined with secrets: per-user credentials make results user-specific")
+	}
+	return nil
+}
+
+// SharesResults reports whether the plugin declares cache.mode shared.
+func (m *PluginMetadata) SharesResults() bool {
+	return m != nil && m.Cache != nil && m.Cache.Mode == CacheModeShared
+}
+
+// CacheMaxAge returns how long a completed run's result may be reused.
+func (m *PluginMetadata) CacheMaxAge() time.Duration {
+	if m == nil || m.Cache == nil || m.Cache.MaxAge == "" {
+		return defaultCacheMaxAge
+	}
+	d, err := time.ParseDuration(m.Cache.MaxAge)
+	if err != nil || d <= 0 {
+		return defaultCacheMaxAge
+	}
+	return d
+}
+
+// SharedInputHash returns a hex SHA-256 identifying a run's inputs: the
+// plugin name and version plus its settings without credential keys and
+// cache.ignore entries, and with cache.per_day the date of local (the run's
+// start in the user's timezone). The selected LLM model (_llm_model) is kept,
+// since it changes the output. Settings are encoded as JSON, whose object keys
+// are sorted, so equal settings hash equally regardless of key order.
+func SharedInputHash(meta *PluginMetadata, settings map[string]interface{}, local time.Time) (string, error) {
+	ignore := make(map[string]bool)
+	if meta.Cache != nil {
+		for _, k := range meta.Cache.Ignore {
+			ignore[k] = true
+		}
+	}
+	inputs := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		if ignore[k] {
+			continue
+		}
+		if !strings.HasPrefix(k, "_") || k == "_llm_model" {
+			inputs[k] = v
+		}
+	}
+	data, err := json.Marshal(inputs)
+	if err != nil {
+		return "", fmt.Errorf("plugins: encode settings for input hash: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(meta.Name))
+	h.Write([]byte{0})
+	h.Write([]byte(meta.Version))
+	h.Write([]byte{0})
+	h.Write(data)
+	if meta.Cache != nil && meta.Cache.PerDay {
+		h.Write([]byte{0})
+		h.Write([]byte(local.Format("2006-01-02")))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FindSharedRun returns the most recent run of the plugin with the same
+// input hash that can serve the run with ID runID: one completed within
+// maxAge, or an earlier one still pending or processing that started within
+// maxAge. Completed runs are preferred. Only runs that executed themselves
+// are considered, so shared results never chain, and waiting only on earlier
+// runs means two identical runs cannot wait on each other. Returns nil when
+// there is no such run.
+func FindSharedRun(db *gorm.DB, pluginID uint, inputHash string, maxAge time.Duration, runID uint) (*PluginRun, error) {
+	if inputHash == "" {
+		return nil, nil
+	}
+	cutoff := time.Now().Add(-maxAge)
+	var runs []PluginRun
+	err := db.
+		Where("plugin_id = ? AND input_hash = ? AND shared_from_run_id = '' AND id <> ?", pluginID, inputHash, runID).
+		Where("((status = ? AND completed_at > ?) OR (status IN ? AND started_at > ? AND id < ?))",
+			PluginRunStatusCompleted, cutoff,
+			[]string{PluginRunStatusPending, PluginRunStatusProcessing}, cutoff, runID).
+		Order(gorm.Expr("status = ? DESC", PluginRunStatusCompleted)).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&runs).Error
+	if err != nil {
+		return nil, fmt.Errorf("plugins: find shared run: %w", err)
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return &runs[0], nil
+}
+
+// ShareRun serves the pending run pluginRunID from source instead of
+// executing it. A completed source's output is copied and the run completed
+// at once; otherwise the run moves to processing and waits, and is settled
+// with the source's outcome when the source finishes (see TransitionRun).
+func ShareRun(db *gorm.DB, pluginRunID string, source *PluginRun, origin string) error {
+	if err := TransitionRun(db, pluginRunID, PluginRunStatusProcessing, origin, map[string]interface{}{
+		"shared_from_run_id": source.PluginRunID,
+	}); err != nil {
+		return err
+	}
+	if source.Status == PluginRunStatusCompleted {
+		return TransitionRun(db, pluginRunID, PluginRunStatusCompleted, origin, map[string]interface{}{
+			"output":       source.Output,
+			"completed_at": time.Now(),
+		})
+	}
+
+	// The source may have finished between FindSharedRun and attaching;
+	// settle now rather than waiting for a transition that already happened.
+	var status string
+	if err := db.Model(&PluginRun{}).Select("status").
+		Where("plugin_run_id = ?", source.PluginRunID).Scan(&status).Error; err != nil {
+		return fmt.Errorf("plugins: reload shared run %s: %w", source.PluginRunID, err)
+	}
+	if IsTerminalRunStatus(status) {
+		settleSharedRuns(db, source.PluginRunID, origin)
+	}
+	return nil
+}
+
+// settleSharedRuns applies a finished run's outcome to the runs waiting on
+// it: its output when completed, otherwise a failure naming the shared run.
+// Called by TransitionRun on every terminal transition. Errors are logged;
+// waiting runs left behind are timed out by the reaper.
+func settleSharedRuns(db *gorm.DB, sourceRunID, origin string) {
+	var waiting []string
+	if err := db.Model(&PluginRun{}).
+		Where("shared_from_run_id = ? AND status = ?", sourceRunID, PluginRunStatusProcessing).
+		Pluck("plugin_run_id", &waiting).Error; err != nil {
+		slog.Error("Failed to load runs waiting on shared run",
+			"plugin_run_id", sourceRunID,
+			"error", err,
+		)
+		return
+	}
+	if len(waiting) == 0 {
+		return
+	}
+
+	var source PluginRun
+	if err := db.Select("plugin_run_id", "status", "output", "error_message").
+		Where("plugin_run_id = ?", sourceRunID).First(&source).Error; err != nil {
+		slog.Error("Failed to load shared run",
+			"plugin_run_id", sourceRunID,
+			"error", err,
+		)
+		return
+	}
+
+	for _, id := range waiting {
+		to := PluginRunStatusFailed
+		updates := map[string]interface{}{"completed_at": time.Now()}
+		if source.Status == PluginRunStatusCompleted {
+			to = PluginRunStatusCompleted
+			updates["output"] = source.Output
+		} else {
+			updates["error_message"] = fmt.Sprintf("shared run %s %s: %s", source.PluginRunID, source.Status, source.ErrorMessage)
+		}
+		if err := TransitionRun(db, id, to, origin, updates); err != nil && !errors.Is(err, ErrTransitionRejected) {
+			slog.Error("Failed to settle run waiting on shared run",
+				"plugin_run_id", id,
+				"shared_from_run_id", sourceRunID,
+				"error", err,
+			)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSharedInputHash(t *testing.T) {
+	meta := &PluginMetadata{Name: "daily-news-digest", Version: "1.0.0"}
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	hash := func(m *PluginMetadata, settings map[string]interface{}) string {
+		t.Helper()
+		h, err := SharedInputHash(m, settings, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	base := hash(meta, map[string]interface{}{
+		"topics":         []interface{}{"technology"},
+		"summary_length": "short",
+		"_llm_api_key":   "sk-alice",
+		"_llm_model":     "openai/gpt-4o",
+	})
+	same := hash(meta, map[string]interface{}{
+		"summary_length":  "short",
+		"topics":          []interface{}{"technology"},
+		"_llm_api_key":    "sk-bob",
+		"_tavily_api_key": "tvly-bob",
+		"_llm_model":      "openai/gpt-4o",
+	})
+	if base != same {
+		t.Error("hash depends on credentials or key order")
+	}
+
+	ignoring := &PluginMetadata{Name: meta.Name, Version: meta.Version, Cache: &CacheConfig{Mode: CacheModeShared, Ignore: []string{"preferred_time"}}}
+	if hash(ignoring, map[string]interface{}{"topics": []interface{}{"technology"}, "preferred_time": "06:00"}) !=
+		hash(ignoring, map[string]interface{}{"topics": []interface{}{"technology"}, "preferred_time": "07:30"}) {
+		t.Error("hash includes an ignored setting")
+	}
+
+	for name, other := range map[string]string{
+		"settings": hash(meta, map[string]interface{}{"topics": []interface{}{"sports"}, "summary_length": "short", "_llm_model": "openai/gpt-4o"}),
+		"model":    hash(meta, map[string]interface{}{"topics": []interface{}{"technology"}, "summary_length": "short", "_llm_model": "anthropic/claude"}),
+		"version":  hash(&PluginMetadata{Name: meta.Name, Version: "1.1.0"}, map[string]interface{}{"topics": []interface{}{"technology"}, "summary_length": "short", "_llm_model": "openai/gpt-4o"}),
+	} {
+		if other == base {
+			t.Errorf("hash ignores %s", name)
+		}
+	}
+}
+
+func TestSharedInputHashPerDay(t *testing.T) {
+	settings := map[string]interface{}{"topics": []interface{}{"technology"}}
+	hash := func(m *PluginMetadata, local time.Time) string {
+		t.Helper()
+		h, err := SharedInputHash(m, settings, local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	tokyo := time.FixedZone("JST", 9*60*60)
+	morning := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	daily := &PluginMetadata{Name: "daily-news-digest", Version: "1.0.0", Cache: &CacheConfig{Mode: CacheModeShared, PerDay: true}}
+	if hash(daily, morning) != hash(daily, morning.Add(3*time.Hour)) {
+		t.Error("per_day hash differs within one local day")
+	}
+	if hash(daily, morning) == hash(daily, morning.AddDate(0, 0, 1)) {
+		t.Error("per_day hash matches across days")
+	}
+	// 20:00 UTC on the 10th is already the 11th in Tokyo.
+	evening := time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+	if hash(daily, evening) == hash(daily, evening.In(tokyo)) {
+		t.Error("per_day hash ignores the user's timezone")
+	}
+
+	undated := &PluginMetadata{Name: "daily-news-digest", Version: "1.0.0", Cache: &CacheConfig{Mode: CacheModeShared}}
+	if hash(undated, morning) != hash(undated, morning.AddDate(0, 0, 1)) {
+		t.Error("hash without per_day depends on the date")
+	}
+}
+
+func TestLoadPluginMetadataCache(t *testing.T) {
+	load := func(extra string) (*PluginMetadata, error) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "plugin.yaml")
+		if err := os.WriteFile(path, []byte("name: p\nversion: 1.0.0\n"+extra), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return LoadPluginMetadata(path)
+	}
+
+	meta, err := load("cache:\n  mode: shared\n  max_age: 2h\n")
+	if err != nil {
+		t.Fatalf("LoadPluginMetadata: %v", err)
+	}
+	if !meta.SharesResults() || meta.CacheMaxAge() != 2*time.Hour {
+		t.Errorf("cache = %+v", meta.Cache)
+	}
+	if meta, _ := load(""); meta.SharesResults() || meta.CacheMaxAge() != defaultCacheMaxAge {
+		t.Error("plugins without cache share results")
+	}
+
+	for _, bad := range []string{
+		"cache:\n  mode: global\n",
+		"cache:\n  mode: shared\n  max_age: soon\n",
+		"cache:\n  mode: shared\n  max_age: -1h\n",
+		"cache:\n  mode: shared\nsecrets:\n  - name: token\n    label: Token\n",
+	} {
+		if _, err := load(bad); err == nil {
+			t.Errorf("LoadPluginMetadata accepted %q", bad)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package plugins
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jimdaga/first-sip/internal/jsonpatch"
+	"github.com/kaptinlin/jsonschema"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MajorVersion returns the major component of a MAJOR.MINOR.PATCH version
+// (an optional leading "v" is ignored). ok is false if v does not start with
+// a number.
+func MajorVersion(v string) (major int, ok bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, ".-+"); i >= 0 {
+		v = v[:i]
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// settingsSchemaBytes returns the plugin's settings schema JSON, or nil if
+// the plugin has none.
+func settingsSchemaBytes(meta *PluginMetadata) ([]byte, error) {
+	if compiled, ok := CompiledSettingsSchema(meta.Name); ok {
+		return compiled, nil
+	}
+	if meta.SettingsSchemaPath == "" || meta.Dir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(meta.Dir, meta.SettingsSchemaPath))
+	if err != nil {
+		return nil, fmt.Errorf("read settings schema: %w", err)
+	}
+	return data, nil
+}
+
+// SettingsSchemaHash returns the hex SHA-256 of the plugin's settings schema
+// with insignificant whitespace removed, or "" if the plugin has none.
+func SettingsSchemaHash(meta *PluginMetadata) (string, error) {
+	data, err := settingsSchemaBytes(meta)
+	if err != nil || len(data) == 0 {
+		return "", err
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return "", fmt.Errorf("settings schema is not valid JSON: %w", err)
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SettingsOutdated reports whether settings saved under settingsVersion and
+// settingsHash predate a major version of the plugin that changed its
+// settings schema. Unknown versions are never reported as outdated.
+func SettingsOutdated(settingsVersion, settingsHash, pluginVersion, pluginHash string) bool {
+	if settingsHash == pluginHash {
+		return false
+	}
+	saved, ok := MajorVersion(settingsVersion)
+	if !ok {
+		return false
+	}
+	current, ok := MajorVersion(pluginVersion)
+	return ok && saved < current
+}
+
+// SettingsMigration is a declarative upgrade step for user settings, declared
+// under settings_migrations in plugin.yaml or as a JSON Patch file in the
+// plugin's migrations/ directory. It applies to settings saved under a major
+// version below ToVersion's, in the order: rename, remove, set_defaults, patch.
+type SettingsMigration struct {
+	ToVersion   string                 `yaml:"to_version"`   // first version whose schema expects the migrated settings
+	Rename      map[string]string      `yaml:"rename"`       // old key → new key
+	Remove      []string               `yaml:"remove"`       // keys dropped from the schema
+	SetDefaults map[string]interface{} `yaml:"set_defaults"` // values for new keys, added when absent
+
+	// Patch is an RFC 6902 JSON Patch applied to the settings object, for
+	// changes the declarative operations cannot express (nested values,
+	// renamed enum values). Loaded from migrations/<to_version>.json.
+	Patch []jsonpatch.Operation `yaml:"patch"`
+}
+
+// settingsMigrationsDir is the plugin subdirectory holding JSON Patch
+// migration files named <to_version>.json.
+const settingsMigrationsDir = "migrations"
+
+// loadMigrationFiles reads dir/migrations/*.json and merges each patch into
+// the manifest step with the same to_version, or adds a new step. Steps are
+// returned sorted by major version.
+func loadMigrationFiles(dir string, steps []SettingsMigration) ([]SettingsMigration, error) {
+	files, err := filepath.Glob(filepath.Join(dir, settingsMigrationsDir, "*.json"))
+	if err != nil || len(files) == 0 {
+		return steps, err
+	}
+	for _, file := range files {
+		version := strings.TrimSuffix(filepath.Base(file), ".json")
+		if _, ok := MajorVersion(version); !ok {
+			return nil, fmt.Errorf("%s: file name must be a MAJOR.MINOR.PATCH version", filepath.Base(file))
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		ops, err := jsonpatch.Decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+
+		merged := false
+		for i := range steps {
+			if steps[i].ToVersion != version {
+				continue
+			}
+			if len(steps[i].Patch) > 0 {
+				return nil, fmt.Errorf("%s: to_version %s already has an inline patch", filepath.Base(file), version)
+			}
+			steps[i].Patch = ops
+			merged = true
+		}
+		if !merged {
+			steps = append(steps, SettingsMigration{ToVersion: version, Patch: ops})
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		a, _ := MajorVersion(steps[i].ToVersion)
+		b, _ := MajorVersion(steps[j].ToVersion)
+		return a < b
+	})
+	return steps, nil
+}
+
+// checkSettingsMigrations validates migration steps: each names a
+// to_version, has at least one operation, and majors strictly increase.
+func checkSettingsMigrations(steps []SettingsMigration) error {
+	prev := -1
+	for i, step := range steps {
+		major, ok := MajorVersion(step.ToVersion)
+		if !ok {
+			return fmt.Errorf("step %d: to_version %q is not MAJOR.MINOR.PATCH", i, step.ToVersion)
+		}
+		if major <= prev {
+			return fmt.Errorf("step %d: to_version %s must have a higher major version than the step before", i, step.ToVersion)
+		}
+		if len(step.Rename)+len(step.Remove)+len(step.SetDefaults)+len(step.Patch) == 0 {
+			return fmt.Errorf("step %d: no rename, remove, set_defaults or patch operations", i)
+		}
+		prev = major
+	}
+	return nil
+}
+
+// MigrateSettings applies the steps needed to bring settings saved under
+// fromVersion up to toVersion. It returns the migrated copy and the
+// to_version of the last step applied ("" if none); settings is not
+// modified. Unknown versions apply no steps. A patch that fails to apply
+// aborts the migration with an error.
+func MigrateSettings(settings map[string]interface{}, fromVersion, toVersion string, steps []SettingsMigration) (map[string]interface{}, string, error) {
+	from, okFrom := MajorVersion(fromVersion)
+	to, okTo := MajorVersion(toVersion)
+	if !okFrom || !okTo {
+		return settings, "", nil
+	}
+
+	out := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		out[k] = v
+	}
+
+	reached := ""
+	for _, step := range steps {
+		major, _ := MajorVersion(step.ToVersion)
+		if major <= from || major > to {
+			continue
+		}
+
+		olds := make([]string, 0, len(step.Rename))
+		for old := range step.Rename {
+			olds = append(olds, old)
+		}
+		sort.Strings(olds)
+		for _, old := range olds {
+			if v, ok := out[old]; ok {
+				delete(out, old)
+				out[step.Rename[old]] = v
+			}
+		}
+		for _, key := range step.Remove {
+			delete(out, key)
+		}
+		for key, v := range step.SetDefaults {
+			if _, ok := out[key]; !ok {
+				out[key] = v
+			}
+		}
+		if len(step.Patch) > 0 {
+			patched, err := jsonpatch.Apply(out, step.Patch)
+			if err != nil {
+				return nil, "", fmt.Errorf("migration to %s: %w", step.ToVersion, err)
+			}
+			obj, ok := patched.(map[string]interface{})
+			if !ok {
+				return nil, "", fmt.Errorf("migration to %s: patch did not produce an object", step.ToVersion)
+			}
+			out = obj
+		}
+		reached = step.ToVersion
+	}
+	return out, reached, nil
+}
+
+// recordPluginVersion adds the plugin's current version to its version
+// history, refreshing the schema hash if the version was seen before.
+func recordPluginVersion(db *gorm.DB, pluginID uint, meta *PluginMetadata, schemaHash string) error {
+	row := PluginVersion{
+		PluginID:      pluginID,
+		Version:       meta.Version,
+		SchemaVersion: meta.SchemaVersion,
+		SchemaHash:    schemaHash,
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "plugin_id"}, {Name: "version"}},
+		DoUpdates: clause.AssignmentColumns([]string{"schema_version", "schema_hash"}),
+	}).Create(&row).Error
+}
+
+// upgradeUserSettings runs after a plugin's row moves from oldVersion and
+// oldHash to meta's version and schemaHash. Configs that predate settings
+// versioning are stamped with the previous version, and configs saved under
+// an older major version are migrated with the manifest's settings_migrations.
+// Configs no step applies to keep their stamp, so SettingsOutdated flags them.
+func upgradeUserSettings(db *gorm.DB, pluginID uint, meta *PluginMetadata, schemaHash, oldVersion, oldHash string) error {
+	if oldHash == "" && oldVersion == meta.Version {
+		oldHash = schemaHash // first sync since settings were versioned; the schema is unchanged
+	}
+	if err := db.Model(&UserPluginConfig{}).
+		Where("plugin_id = ? AND settings_version = ''", pluginID).
+		Updates(map[string]interface{}{
+			"settings_version":     oldVersion,
+			"settings_schema_hash": oldHash,
+		}).Error; err != nil {
+		return fmt.Errorf("stamp settings version: %w", err)
+	}
+
+	if len(meta.SettingsMigrations) == 0 || oldHash == schemaHash {
+		return nil
+	}
+
+	var configs []UserPluginConfig
+	if err := db.Where("plugin_id = ? AND settings_schema_hash <> ?", pluginID, schemaHash).
+		Find(&configs).Error; err != nil {
+		return fmt.Errorf("load configs to migrate: %w", err)
+	}
+
+	current, _ := MajorVersion(meta.Version)
+	migrated := 0
+	for _, cfg := range configs {
+		if !SettingsOutdated(cfg.SettingsVersion, cfg.SettingsSchemaHash, meta.Version, schemaHash) {
+			continue
+		}
+		var settings map[string]interface{}
+		if len(cfg.Settings) > 0 {
+			if err := json.Unmarshal(cfg.Settings, &settings); err != nil {
+				log.Printf("Warning: plugin %s: config %d has unreadable settings, not migrated: %v", meta.Name, cfg.ID, err)
+				continue
+			}
+		}
+		next, reached, err := MigrateSettings(settings, cfg.SettingsVersion, meta.Version, meta.SettingsMigrations)
+		if err != nil {
+			log.Printf("Warning: plugin %s: config %d not migrated: %v", meta.Name, cfg.ID, err)
+			continue
+		}
+		if reached == "" {
+			continue
+		}
+		raw, err := json.Marshal(next)
+		if err != nil {
+			return fmt.Errorf("marshal migrated settings: %w", err)
+		}
+		// Steps that stop short of the current major leave the config flagged.
+		version, hash := reached, cfg.SettingsSchemaHash
+		if r, _ := MajorVersion(reached); r == current {
+			version, hash = meta.Version, schemaHash
+		}
+		if err := db.Model(&UserPluginConfig{}).Where("id = ?", cfg.ID).Updates(map[string]interface{}{
+			"settings":             datatypes.JSON(raw),
+			"settings_version":     version,
+			"settings_schema_hash": hash,
+		}).Error; err != nil {
+			return fmt.Errorf("save migrated settings for config %d: %w", cfg.ID, err)
+		}
+		migrated++
+	}
+	if migrated > 0 {
+		log.Printf("Migrated settings for %d config(s) of plugin %s to version %s", migrated, meta.Name, meta.Version)
+	}
+	return nil
+}
+
+// revalidateUserSettings checks every saved config of the plugin against its
+// current settings schema and records the validation errors on the config,
+// so the settings page can point users at fields that need fixing. Configs
+// that validate have their errors cleared.
+func revalidateUserSettings(db *gorm.DB, pluginID uint, meta *PluginMetadata) error {
+	data, err := settingsSchemaBytes(meta)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return db.Model(&UserPluginConfig{}).
+			Where("plugin_id = ? AND settings_errors IS NOT NULL", pluginID).
+			Update("settings_errors", nil).Error
+	}
+	schema, err := jsonschema.NewCompiler().Compile(data)
+	if err != nil {
+		log.Printf("Warning: plugin %s: settings schema does not compile, saved settings not re-validated: %v", meta.Name, err)
+		return nil
+	}
+
+	var configs []UserPluginConfig
+	if err := db.Where("plugin_id = ? AND settings IS NOT NULL", pluginID).Find(&configs).Error; err != nil {
+		return fmt.Errorf("load configs to validate: %w", err)
+	}
+
+	invalid := 0
+	for _, cfg := range configs {
+		var settings map[string]interface{}
+		if err := json.Unmarshal(cfg.Settings, &settings); err != nil {
+			settings = nil
+		}
+		var errs map[string]string
+		if result := schema.Validate(settings); !result.IsValid() {
+			errs = result.DetailedErrors()
+			invalid++
+		}
+		var stored map[string]string
+		if len(cfg.SettingsErrors) > 0 {
+			_ = json.Unmarshal(cfg.SettingsErrors, &stored)
+		}
+		if reflect.DeepEqual(errs, stored) {
+			continue
+		}
+		var value interface{}
+		if errs != nil {
+			raw, err := json.Marshal(errs)
+			if err != nil {
+				return fmt.Errorf("marshal settings errors: %w", err)
+			}
+			value = datatypes.JSON(raw)
+		}
+		if err := db.Model(&UserPluginConfig{}).Where("id = ?", cfg.ID).
+			Update("settings_errors", value).Error; err != nil {
+			return fmt.Errorf("save settings errors for config %d: %w", cfg.ID, err)
+		}
+	}
+	if invalid > 0 {
+		log.Printf("Warning: plugin %s: %d config(s) have settings that no longer match the schema", meta.Name, invalid)
+	}
+	return nil
+}
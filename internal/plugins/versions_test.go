@@ -0,0 +1,121 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMajorVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"1.0.0", 1, true},
+		{"v2.3.4", 2, true},
+		{"10.0.0-beta.1", 10, true},
+		{"3", 3, true},
+		{"", 0, false},
+		{"latest", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := MajorVersion(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("MajorVersion(%q) = %d, %v; want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSettingsOutdated(t *testing.T) {
+	tests := []struct {
+		name                               string
+		savedVersion, savedHash, ver, hash string
+		want                               bool
+	}{
+		{"same schema", "1.0.0", "a", "2.0.0", "a", false},
+		{"minor bump", "1.0.0", "a", "1.4.0", "b", false},
+		{"major bump", "1.2.0", "a", "2.0.0", "b", true},
+		{"unknown saved version", "", "", "2.0.0", "b", false},
+	}
+	for _, tt := range tests {
+		if got := SettingsOutdated(tt.savedVersion, tt.savedHash, tt.ver, tt.hash); got != tt.want {
+			t.Errorf("%s: SettingsOutdated = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMigrateSettings(t *testing.T) {
+	steps := []SettingsMigration{
+		{ToVersion: "2.0.0", Rename: map[string]string{"topics": "interests"}, Remove: []string{"legacy"}},
+		{ToVersion: "3.0.0", SetDefaults: map[string]interface{}{"summary_length": "standard", "interests": []string{"ignored"}}},
+	}
+	in := map[string]interface{}{"topics": []string{"tech"}, "legacy": true}
+
+	got, reached, err := MigrateSettings(in, "1.0.0", "3.1.0", steps)
+	want := map[string]interface{}{"interests": []string{"tech"}, "summary_length": "standard"}
+	if err != nil || !reflect.DeepEqual(got, want) || reached != "3.0.0" {
+		t.Errorf("MigrateSettings 1→3 = %v, %q, %v; want %v, 3.0.0", got, reached, err, want)
+	}
+	if _, ok := in["interests"]; ok {
+		t.Error("MigrateSettings modified its input")
+	}
+
+	if _, reached, _ := MigrateSettings(in, "1.0.0", "2.5.0", steps); reached != "2.0.0" {
+		t.Errorf("MigrateSettings 1→2 reached %q, want 2.0.0", reached)
+	}
+	if _, reached, _ := MigrateSettings(in, "3.0.0", "3.1.0", steps); reached != "" {
+		t.Errorf("MigrateSettings 3→3 reached %q, want none", reached)
+	}
+
+	if err := checkSettingsMigrations([]SettingsMigration{steps[1], steps[0]}); err == nil {
+		t.Error("checkSettingsMigrations accepted descending versions")
+	}
+	if err := checkSettingsMigrations([]SettingsMigration{{ToVersion: "2.0.0"}}); err == nil {
+		t.Error("checkSettingsMigrations accepted a step with no operations")
+	}
+}
+
+func TestMigrateSettingsPatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "migrations"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "migrations", name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("3.0.0.json", `[{"op":"test","path":"/length","value":"long"},{"op":"replace","path":"/length","value":"detailed"}]`)
+	write("2.0.0.json", `[{"op":"move","from":"/topics","path":"/interests"}]`)
+
+	steps, err := loadMigrationFiles(dir, []SettingsMigration{{ToVersion: "2.0.0", Remove: []string{"legacy"}}})
+	if err != nil {
+		t.Fatalf("loadMigrationFiles: %v", err)
+	}
+	if len(steps) != 2 || steps[0].ToVersion != "2.0.0" || len(steps[0].Patch) != 1 || steps[1].ToVersion != "3.0.0" {
+		t.Fatalf("loadMigrationFiles = %+v, want patch merged into 2.0.0 and a 3.0.0 step", steps)
+	}
+	if err := checkSettingsMigrations(steps); err != nil {
+		t.Fatalf("checkSettingsMigrations: %v", err)
+	}
+
+	in := map[string]interface{}{"topics": []interface{}{"tech"}, "legacy": true, "length": "long"}
+	got, reached, err := MigrateSettings(in, "1.0.0", "3.0.0", steps)
+	want := map[string]interface{}{"interests": []interface{}{"tech"}, "length": "detailed"}
+	if err != nil || !reflect.DeepEqual(got, want) || reached != "3.0.0" {
+		t.Errorf("MigrateSettings = %v, %q, %v; want %v, 3.0.0", got, reached, err, want)
+	}
+
+	// A failing test op aborts the migration.
+	if _, _, err := MigrateSettings(map[string]interface{}{"length": "short"}, "2.0.0", "3.0.0", steps); err == nil {
+		t.Error("MigrateSettings with a failing patch returned nil error")
+	}
+
+	write("latest.json", `[]`)
+	if _, err := loadMigrationFiles(dir, nil); err == nil {
+		t.Error("loadMigrationFiles accepted a file not named after a version")
+	}
+}
@@ -0,0 +1,275 @@
+// Package plugintest replays recorded plugin results through the path live
+// results take — the worker's plugin:execute handler (API key and secret
+// injection, run creation, result sharing), a request handed to the
+// executor, and the result applied by streams.HandlePluginResult — against an
+// in-memory SQLite database, so plugin output can be checked in CI without
+// Redis, Postgres or an LLM.
+//
+// A plugin keeps its recordings in testdata/fixtures/<case>.json inside its
+// directory:
+//
+//	{
+//	  "settings": {"topics": ["technology"]},
+//	  "status": "completed",
+//	  "output": {"summary": "...", "sections": [...]},
+//	  "error": ""
+//	}
+//
+// output may also be the JSON-encoded string the sidecar publishes, so a
+// message copied from the plugin:results stream can be saved as is.
+package plugintest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jimdaga/first-sip/internal/apikeys"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/streams"
+	"github.com/jimdaga/first-sip/internal/worker"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// FixturesDir and SnapshotsDir are relative to a plugin's directory.
+const (
+	FixturesDir  = "testdata/fixtures"
+	SnapshotsDir = "testdata/snapshots"
+)
+
+// SecretValue is the value Run saves for every API key and secret slot the
+// plugin declares, so tests can check what reached the executor.
+const SecretValue = "plugintest-secret"
+
+// LLM preference Run saves for the user; the worker sends it as _llm_model.
+const (
+	LLMProvider = "openai"
+	LLMModel    = "gpt-4o-mini"
+)
+
+// Fixture is one recorded sidecar result and the settings that produced it.
+type Fixture struct {
+	Name     string                 `json:"-"` // file name without .json
+	Settings map[string]interface{} `json:"settings"`
+	Status   string                 `json:"status"`
+	Output   json.RawMessage        `json:"output"`
+	Error    string                 `json:"error"`
+}
+
+// LoadFixtures reads the fixtures of the plugin in pluginDir, sorted by name.
+// A plugin without a fixtures directory has none.
+func LoadFixtures(pluginDir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(pluginDir, FixturesDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	fixtures := make([]Fixture, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("plugintest: %s: %w", path, err)
+		}
+		if f.Status != plugins.PluginRunStatusCompleted && f.Status != plugins.PluginRunStatusFailed {
+			return nil, fmt.Errorf("plugintest: %s: status must be completed or failed, got %q", path, f.Status)
+		}
+		f.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
+
+// Result returns the fixture as the sidecar would publish it for a run.
+func (f Fixture) Result(pluginRunID string) streams.PluginResult {
+	output := string(f.Output)
+	var s string
+	if json.Unmarshal(f.Output, &s) == nil {
+		output = s // recorded stream message: output is a JSON-encoded string
+	}
+	return streams.PluginResult{
+		PluginRunID: pluginRunID,
+		Status:      f.Status,
+		Output:      output,
+		Error:       f.Error,
+	}
+}
+
+// FakeExecutor stands in for the plugin's runtime executor. It records each
+// request as the sidecar would receive it and, like the real executor,
+// returns no result; Replay later delivers the fixture's result for every
+// recorded request.
+type FakeExecutor struct {
+	fixture Fixture
+
+	mu       sync.Mutex
+	requests []streams.PluginRequest
+	replayed int
+}
+
+// NewFakeExecutor returns a FakeExecutor that answers every request with f.
+func NewFakeExecutor(f Fixture) *FakeExecutor {
+	return &FakeExecutor{fixture: f}
+}
+
+// Execute implements plugins.Executor. Secrets are merged back into settings,
+// as streams.CrewAIExecutor does before publishing.
+func (e *FakeExecutor) Execute(ctx context.Context, req plugins.ExecutionRequest) (*plugins.RunResult, error) {
+	settings := make(map[string]interface{}, len(req.Settings)+len(req.Secrets))
+	for k, v := range req.Settings {
+		settings[k] = v
+	}
+	for k, v := range req.Secrets {
+		settings[k] = v
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests = append(e.requests, streams.PluginRequest{
+		PluginRunID: req.PluginRunID,
+		PluginName:  req.Plugin.Name,
+		UserID:      req.UserID,
+		Settings:    settings,
+	})
+	return nil, nil
+}
+
+// Requests returns the requests received so far.
+func (e *FakeExecutor) Requests() []streams.PluginRequest {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]streams.PluginRequest(nil), e.requests...)
+}
+
+// Replay passes the fixture's result for each request not yet replayed to
+// handle, typically streams.HandlePluginResult.
+func (e *FakeExecutor) Replay(handle func(streams.PluginResult) error) error {
+	e.mu.Lock()
+	pending := e.requests[e.replayed:]
+	e.replayed = len(e.requests)
+	e.mu.Unlock()
+
+	for _, req := range pending {
+		if err := handle(e.fixture.Result(req.PluginRunID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var dbSeq atomic.Int64
+
+// OpenDB opens a fresh in-memory SQLite database with the tables the worker
+// reads and writes when running a plugin. Foreign keys are not created.
+func OpenDB() (*gorm.DB, error) {
+	dsn := fmt.Sprintf("file:plugintest%d?mode=memory&cache=shared", dbSeq.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("plugintest: open database: %w", err)
+	}
+	if err := db.AutoMigrate(
+		&models.AccountTier{}, &models.User{}, &models.UserAPIKey{}, &models.UserPluginSecret{},
+		&plugins.Plugin{}, &plugins.PluginRun{}, &plugins.PluginRunTransitionRejection{},
+	); err != nil {
+		return nil, fmt.Errorf("plugintest: create tables: %w", err)
+	}
+	return db, nil
+}
+
+// Run executes the plugin for userID with the fixture's settings through
+// worker.ExecutePlugin, with a FakeExecutor serving the plugin's runtime,
+// then replays the recorded result through streams.HandlePluginResult,
+// validating it against the output schema meta declares. The user is given
+// SecretValue for every API key and secret slot the plugin declares. It
+// returns the user's new run and the request the executor received, which
+// is empty when the worker served the run from another user's shared run.
+func Run(db *gorm.DB, meta *plugins.PluginMetadata, userID uint, f Fixture) (*plugins.PluginRun, streams.PluginRequest, error) {
+	var dbPlugin plugins.Plugin
+	if err := db.Where(plugins.Plugin{Name: meta.Name}).
+		Attrs(plugins.Plugin{Version: meta.Version, Icon: meta.Icon, TileSize: meta.TileSize}).
+		FirstOrCreate(&dbPlugin).Error; err != nil {
+		return nil, streams.PluginRequest{}, fmt.Errorf("plugintest: create plugin: %w", err)
+	}
+	if err := seedUser(db, meta, userID, dbPlugin.ID); err != nil {
+		return nil, streams.PluginRequest{}, err
+	}
+
+	registry := plugins.NewRegistry()
+	if err := registry.Register(meta); err != nil {
+		return nil, streams.PluginRequest{}, err
+	}
+	executor := NewFakeExecutor(f)
+	executors := plugins.NewDefaultExecutors(registry)
+	executors.Register(meta.Runtime, executor)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := worker.ExecutePlugin(context.Background(), logger, db, executors, dbPlugin.ID, userID, meta.Name, f.Settings); err != nil {
+		return nil, streams.PluginRequest{}, fmt.Errorf("plugintest: execute: %w", err)
+	}
+	var run plugins.PluginRun
+	if err := db.Where("plugin_id = ? AND user_id = ?", dbPlugin.ID, userID).Order("id DESC").First(&run).Error; err != nil {
+		return nil, streams.PluginRequest{}, fmt.Errorf("plugintest: load run: %w", err)
+	}
+
+	if err := executor.Replay(streams.HandlePluginResult(db, registry)); err != nil {
+		return nil, streams.PluginRequest{}, err
+	}
+	if err := db.First(&run, run.ID).Error; err != nil {
+		return nil, streams.PluginRequest{}, fmt.Errorf("plugintest: reload run: %w", err)
+	}
+
+	var req streams.PluginRequest
+	if requests := executor.Requests(); len(requests) > 0 {
+		req = requests[len(requests)-1]
+	}
+	return &run, req, nil
+}
+
+// seedUser creates userID if needed and saves SecretValue for the API keys
+// and secret slots meta declares.
+func seedUser(db *gorm.DB, meta *plugins.PluginMetadata, userID, pluginID uint) error {
+	user := models.User{
+		Model:                gorm.Model{ID: userID},
+		Email:                fmt.Sprintf("user%d@plugintest.invalid", userID),
+		LLMPreferredProvider: LLMProvider,
+		LLMPreferredModel:    LLMModel,
+	}
+	if err := db.FirstOrCreate(&user).Error; err != nil {
+		return fmt.Errorf("plugintest: create user: %w", err)
+	}
+	for _, secret := range meta.DeclaredSecrets() {
+		provider := secret
+		if secret == plugins.SecretLLM {
+			provider = user.LLMPreferredProvider
+		}
+		if err := apikeys.SaveKey(db, userID, secret, provider, SecretValue); err != nil {
+			return fmt.Errorf("plugintest: save %s key: %w", secret, err)
+		}
+	}
+	for _, slot := range meta.Secrets {
+		secret := models.UserPluginSecret{UserID: userID, PluginID: pluginID, Name: slot.Name}
+		if err := db.Where(secret).Assign(models.UserPluginSecret{EncryptedValue: SecretValue}).
+			FirstOrCreate(&secret).Error; err != nil {
+			return fmt.Errorf("plugintest: save secret %s: %w", slot.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+// Package pluginwatch hot-reloads the plugin directory. It watches PLUGIN_DIR
+// for manifest and schema changes, reloads the shared plugins.Registry in
+// place (see plugins.Reload), and publishes a reload event on Redis so other
+// web and worker replicas reload too.
+package pluginwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Channel is the Redis pub/sub channel carrying reload events.
+const Channel = "plugins:reload"
+
+// defaultDebounce coalesces the burst of events an editor save or a ConfigMap
+// update produces into one reload.
+const defaultDebounce = 500 * time.Millisecond
+
+// Event is published after a replica reloads a changed plugin directory.
+type Event struct {
+	Origin  string    `json:"origin"` // replica that detected the change
+	Added   []string  `json:"added,omitempty"`
+	Updated []string  `json:"updated,omitempty"`
+	Removed []string  `json:"removed,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Watcher reloads one registry from one plugin directory.
+type Watcher struct {
+	db       *gorm.DB
+	registry *plugins.Registry
+	dir      string
+	rdb      *redis.Client // nil: no cross-replica events
+	origin   string
+	debounce time.Duration
+
+	// afterReload, when set, is called with the summary of each reload
+	// triggered by a filesystem change. Used by tests.
+	afterReload func(plugins.ReloadSummary)
+
+	mu sync.Mutex // serializes reloads
+}
+
+// New returns a Watcher for pluginDir that is not yet watching. When redisURL
+// is set, reloads are announced to other replicas.
+func New(redisURL string, db *gorm.DB, registry *plugins.Registry, pluginDir string) (*Watcher, error) {
+	host, _ := os.Hostname()
+	w := &Watcher{
+		db:       db,
+		registry: registry,
+		dir:      pluginDir,
+		origin:   fmt.Sprintf("%s-%d", host, os.Getpid()),
+		debounce: defaultDebounce,
+	}
+	if redisURL != "" {
+		opts, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("pluginwatch: parse redis URL: %w", err)
+		}
+		w.rdb = redis.NewClient(opts)
+	}
+	return w, nil
+}
+
+// Start watches pluginDir and, when redisURL is set, subscribes to reload
+// events from other replicas. The returned stop function ends both.
+func Start(redisURL string, db *gorm.DB, registry *plugins.Registry, pluginDir string) (stop func(), err error) {
+	w, err := New(redisURL, db, registry, pluginDir)
+	if err != nil {
+		return nil, err
+	}
+	return w.Watch()
+}
+
+// Watch starts watching the plugin directory and subscribing to reload
+// events. The returned stop function ends both and closes the Redis client.
+func (w *Watcher) Watch() (stop func(), err error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("pluginwatch: create watcher: %w", err)
+	}
+	if err := w.addWatches(fsw); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go w.watch(ctx, fsw)
+	if w.rdb != nil {
+		go w.subscribe(ctx)
+	}
+
+	slog.Info("Plugin directory watcher started", "dir", w.dir)
+
+	return func() {
+		cancel()
+		fsw.Close()
+		if w.rdb != nil {
+			w.rdb.Close()
+		}
+	}, nil
+}
+
+// addWatches watches the plugin directory and each plugin subdirectory, where
+// plugin.yaml and the settings schema live. fsnotify is not recursive.
+func (w *Watcher) addWatches(fsw *fsnotify.Watcher) error {
+	if err := fsw.Add(w.dir); err != nil {
+		return fmt.Errorf("pluginwatch: watch %s: %w", w.dir, err)
+	}
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("pluginwatch: read %s: %w", w.dir, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			w.addDir(fsw, filepath.Join(w.dir, e.Name()))
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) addDir(fsw *fsnotify.Watcher, dir string) {
+	if err := fsw.Add(dir); err != nil {
+		slog.Warn("pluginwatch: failed to watch plugin directory", "dir", dir, "error", err)
+	}
+}
+
+// watch debounces filesystem events into reloads.
+func (w *Watcher) watch(ctx context.Context, fsw *fsnotify.Watcher) {
+	var timer *time.Timer
+	fire := make(chan struct{}, 1)
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			// A new plugin directory: watch it so edits inside are seen.
+			if ev.Has(fsnotify.Create) && filepath.Dir(ev.Name) == filepath.Clean(w.dir) {
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					w.addDir(fsw, ev.Name)
+				}
+			}
+			if ev.Has(fsnotify.Chmod) || !w.relevant(ev.Name) {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(w.debounce, func() {
+					select {
+					case fire <- struct{}{}:
+					default:
+					}
+				})
+			} else {
+				timer.Reset(w.debounce)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("pluginwatch: watcher error", "error", err)
+		case <-fire:
+			summary, err := w.reload(true)
+			if err != nil {
+				slog.Error("pluginwatch: reload failed", "dir", w.dir, "error", err)
+				continue
+			}
+			if summary.Changed() {
+				w.publish(ctx, summary)
+			}
+			if w.afterReload != nil {
+				w.afterReload(summary)
+			}
+		}
+	}
+}
+
+// relevant reports whether a change to path can affect the registry: entries
+// directly in the plugin directory (plugins added, removed or renamed, and
+// ConfigMap symlink swaps), plugin.yaml, and JSON schema files. Editor swap
+// files, crew sources and other files inside a plugin are ignored.
+func (w *Watcher) relevant(path string) bool {
+	if filepath.Dir(path) == filepath.Clean(w.dir) {
+		return true
+	}
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, ".") {
+		return false
+	}
+	return base == "plugin.yaml" || filepath.Ext(base) == ".json"
+}
+
+// Reload re-syncs the plugin directory now, as after a detected change, and
+// announces the result to other replicas. Used after installing a bundle.
+func (w *Watcher) Reload(ctx context.Context) (plugins.ReloadSummary, error) {
+	summary, err := w.reload(true)
+	if err != nil {
+		return summary, err
+	}
+	if summary.Changed() {
+		w.publish(ctx, summary)
+	}
+	return summary, nil
+}
+
+// subscribe reloads on events published by other replicas. Those replicas
+// have already re-synced the database, so only the registry is reloaded.
+func (w *Watcher) subscribe(ctx context.Context) {
+	sub := w.rdb.Subscribe(ctx, Channel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var ev Event
+			if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+				slog.Warn("pluginwatch: ignoring malformed reload event", "error", err)
+				continue
+			}
+			if ev.Origin == w.origin {
+				continue
+			}
+			if _, err := w.reload(false); err != nil {
+				slog.Error("pluginwatch: reload on event failed", "origin", ev.Origin, "error", err)
+			}
+		}
+	}
+}
+
+// reload runs plugins.Reload, re-syncing the database when sync is true.
+func (w *Watcher) reload(sync bool) (plugins.ReloadSummary, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	db := w.db
+	if !sync {
+		db = nil
+	}
+	summary, err := plugins.Reload(db, w.registry, w.dir)
+	if err != nil {
+		return summary, err
+	}
+	if summary.Changed() || len(summary.Kept) > 0 {
+		slog.Info("Plugin registry reloaded",
+			"added", summary.Added,
+			"updated", summary.Updated,
+			"removed", summary.Removed,
+			"kept_invalid", summary.Kept,
+			"plugins", w.registry.Count(),
+		)
+	}
+	return summary, nil
+}
+
+// publish announces a local reload to the other replicas.
+func (w *Watcher) publish(ctx context.Context, summary plugins.ReloadSummary) {
+	if w.rdb == nil {
+		return
+	}
+	payload, err := json.Marshal(Event{
+		Origin:  w.origin,
+		Added:   summary.Added,
+		Updated: summary.Updated,
+		Removed: summary.Removed,
+		At:      time.Now().UTC(),
+	})
+	if err != nil {
+		return
+	}
+	if err := w.rdb.Publish(ctx, Channel, payload).Err(); err != nil {
+		slog.Warn("pluginwatch: failed to publish reload event", "error", err)
+	}
+}
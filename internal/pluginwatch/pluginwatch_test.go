@@ -0,0 +1,112 @@
+package pluginwatch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchReloadsOnManifestChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "alpha", "plugin.yaml"), "name: alpha\nversion: 1.0.0\nsettings_schema_path: settings.schema.json\n")
+	writeFile(t, filepath.Join(dir, "alpha", "settings.schema.json"), `{"type":"object"}`)
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&plugins.Plugin{}, &plugins.PluginVersion{}, &plugins.UserPluginConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := plugins.LoadRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New("", db, registry, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.debounce = 100 * time.Millisecond
+	reloads := make(chan plugins.ReloadSummary, 10)
+	w.afterReload = func(s plugins.ReloadSummary) { reloads <- s }
+	stop, err := w.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	next := func(what string) plugins.ReloadSummary {
+		t.Helper()
+		select {
+		case s := <-reloads:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: no reload", what)
+			return plugins.ReloadSummary{}
+		}
+	}
+	none := func(what string) {
+		t.Helper()
+		select {
+		case s := <-reloads:
+			t.Errorf("%s: unexpected reload %+v", what, s)
+		case <-time.After(5 * w.debounce):
+		}
+	}
+
+	// Files that cannot change the registry are ignored.
+	writeFile(t, filepath.Join(dir, "alpha", "README.md"), "notes")
+	writeFile(t, filepath.Join(dir, "alpha", "crew.py"), "print('hi')")
+	writeFile(t, filepath.Join(dir, "alpha", ".plugin.yaml.swp"), "swap")
+	none("non-manifest files")
+
+	// A burst of saves is debounced into one reload that re-syncs the database.
+	for _, v := range []string{"1.0.1", "1.0.2", "1.1.0"} {
+		writeFile(t, filepath.Join(dir, "alpha", "plugin.yaml"), "name: alpha\nversion: "+v+"\nsettings_schema_path: settings.schema.json\n")
+	}
+	if s := next("manifest edits"); !reflect.DeepEqual(s.Updated, []string{"alpha"}) {
+		t.Errorf("summary = %+v, want alpha updated", s)
+	}
+	none("after debounced reload")
+	if m, _ := registry.Get("alpha"); m == nil || m.Version != "1.1.0" {
+		t.Errorf("registry alpha = %+v, want version 1.1.0", m)
+	}
+	var row plugins.Plugin
+	if err := db.Where("name = ?", "alpha").First(&row).Error; err != nil || row.Version != "1.1.0" {
+		t.Errorf("database alpha = %+v, %v; want version 1.1.0", row, err)
+	}
+
+	// Schema files are watched too.
+	writeFile(t, filepath.Join(dir, "alpha", "settings.schema.json"), `{"type":"object","properties":{}}`)
+	next("schema edit")
+
+	// A new plugin directory is picked up and synced.
+	writeFile(t, filepath.Join(dir, "beta", "plugin.yaml"), "name: beta\nversion: 0.1.0\n")
+	if s := next("new plugin"); !reflect.DeepEqual(s.Added, []string{"beta"}) {
+		t.Errorf("summary = %+v, want beta added", s)
+	}
+	if err := db.Where("name = ?", "beta").First(&plugins.Plugin{}).Error; err != nil {
+		t.Errorf("beta not synced: %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+// Package rundiff compares the outputs of two runs of the same plugin section
+// by section, so news- and status-type plugins can show what changed since the
+// previous run. It has no internal imports and doubles as the view model for
+// the diff templates.
+package rundiff
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Status classifies a section or item in a Diff.
+type Status string
+
+const (
+	StatusAdded     Status = "added"
+	StatusRemoved   Status = "removed"
+	StatusChanged   Status = "changed"
+	StatusUnchanged Status = "unchanged"
+)
+
+// maxKeyLen bounds the "key: value" prefix used to pair changed items, so a
+// sentence that merely contains a colon is not treated as a key.
+const maxKeyLen = 60
+
+// Section is one titled section of a run's output (sections[] in
+// plugin_runs.output).
+type Section struct {
+	Title   string
+	Content string
+}
+
+// Item is one line of a section. Previous is set for changed items.
+type Item struct {
+	Text     string
+	Previous string
+	Status   Status
+}
+
+// SectionDiff is the comparison of one section title across two runs.
+type SectionDiff struct {
+	Title  string
+	Status Status
+	Items  []Item
+}
+
+// Diff is the section-by-section comparison of two runs.
+type Diff struct {
+	Sections []SectionDiff
+}
+
+// HasChanges reports whether any section differs between the two runs.
+func (d Diff) HasChanges() bool {
+	for _, s := range d.Sections {
+		if s.Status != StatusUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// OnlyNew returns the added and changed items, dropping removed and unchanged
+// ones and any section left empty. Used by the "what's new" tile mode.
+func (d Diff) OnlyNew() Diff {
+	var out Diff
+	for _, s := range d.Sections {
+		if s.Status == StatusRemoved || s.Status == StatusUnchanged {
+			continue
+		}
+		kept := SectionDiff{Title: s.Title, Status: s.Status}
+		for _, it := range s.Items {
+			if it.Status == StatusAdded || it.Status == StatusChanged {
+				kept.Items = append(kept.Items, it)
+			}
+		}
+		if len(kept.Items) > 0 {
+			out.Sections = append(out.Sections, kept)
+		}
+	}
+	return out
+}
+
+// Compare diffs prev against curr. Sections are matched by title
+// (case-insensitive) and listed in curr order, followed by sections that only
+// exist in prev. Within a section, items are matched by exact text; an added
+// and a removed item sharing a "key:" prefix (e.g. "API: degraded" vs
+// "API: operational") are reported as one changed item.
+func Compare(prev, curr []Section) Diff {
+	prevByTitle := make(map[string]Section, len(prev))
+	for _, s := range prev {
+		prevByTitle[titleKey(s.Title)] = s
+	}
+
+	var d Diff
+	seen := make(map[string]bool, len(curr))
+	for _, s := range curr {
+		k := titleKey(s.Title)
+		seen[k] = true
+		old, ok := prevByTitle[k]
+		if !ok {
+			d.Sections = append(d.Sections, SectionDiff{
+				Title:  s.Title,
+				Status: StatusAdded,
+				Items:  withStatus(Items(s.Content), StatusAdded),
+			})
+			continue
+		}
+		d.Sections = append(d.Sections, compareSection(s.Title, Items(old.Content), Items(s.Content)))
+	}
+	for _, s := range prev {
+		if seen[titleKey(s.Title)] {
+			continue
+		}
+		seen[titleKey(s.Title)] = true
+		d.Sections = append(d.Sections, SectionDiff{
+			Title:  s.Title,
+			Status: StatusRemoved,
+			Items:  withStatus(Items(s.Content), StatusRemoved),
+		})
+	}
+	return d
+}
+
+func compareSection(title string, prev, curr []string) SectionDiff {
+	// Multiset of previous lines so repeated items are matched one-for-one.
+	remaining := make(map[string]int, len(prev))
+	for _, p := range prev {
+		remaining[p]++
+	}
+	var unmatchedCurr []int
+	items := make([]Item, len(curr))
+	for i, c := range curr {
+		if remaining[c] > 0 {
+			remaining[c]--
+			items[i] = Item{Text: c, Status: StatusUnchanged}
+			continue
+		}
+		unmatchedCurr = append(unmatchedCurr, i)
+	}
+
+	var removed []string
+	for _, p := range prev {
+		if remaining[p] > 0 {
+			remaining[p]--
+			removed = append(removed, p)
+		}
+	}
+
+	// Pair leftovers that share a key prefix as changed items.
+	paired := make([]bool, len(removed))
+	for _, i := range unmatchedCurr {
+		items[i] = Item{Text: curr[i], Status: StatusAdded}
+		key := itemKey(curr[i])
+		if key == "" {
+			continue
+		}
+		for j, r := range removed {
+			if !paired[j] && itemKey(r) == key {
+				paired[j] = true
+				items[i] = Item{Text: curr[i], Previous: r, Status: StatusChanged}
+				break
+			}
+		}
+	}
+	for j, r := range removed {
+		if !paired[j] {
+			items = append(items, Item{Text: r, Status: StatusRemoved})
+		}
+	}
+
+	sd := SectionDiff{Title: title, Status: StatusUnchanged, Items: items}
+	for _, it := range items {
+		if it.Status != StatusUnchanged {
+			sd.Status = StatusChanged
+			break
+		}
+	}
+	return sd
+}
+
+// listMarker matches Markdown bullet and ordered-list prefixes.
+var listMarker = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])\s+`)
+
+// htmlBreak matches tags that start a new line of text: line breaks and the
+// opening or closing tags of block elements.
+var htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</?(?:p|div|li|ul|ol|h[1-6]|tr|blockquote|pre)(?:\s[^>]*)?>`)
+
+// htmlTag matches any remaining tag.
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// Items splits section content into comparable lines of plain text: one per
+// non-blank line, with list markers stripped and whitespace collapsed.
+// Section content is HTML, so line breaks and block elements end a line,
+// other tags are dropped and entities are decoded; plain text and Markdown
+// split on newlines.
+func Items(content string) []string {
+	content = htmlBreak.ReplaceAllString(content, "\n")
+	content = html.UnescapeString(htmlTag.ReplaceAllString(content, ""))
+
+	var items []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		line = listMarker.ReplaceAllString(line, "")
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+func itemKey(item string) string {
+	i := strings.Index(item, ":")
+	if i <= 0 || i > maxKeyLen || strings.HasPrefix(item[i:], "://") {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(item[:i]))
+}
+
+func titleKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+func withStatus(texts []string, status Status) []Item {
+	items := make([]Item, len(texts))
+	for i, t := range texts {
+		items[i] = Item{Text: t, Status: status}
+	}
+	return items
+}
+
+// Comparison is a Diff together with the two runs it compares, for the plugin
+// detail page's Changes tab.
+type Comparison struct {
+	CurrentRunID  string
+	CurrentLabel  string // e.g. "March 2, 2026 at 7:00 AM"
+	PreviousRunID string // empty when the current run is the first completed one
+	PreviousLabel string
+	Diff          Diff
+}
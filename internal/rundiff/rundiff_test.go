@@ -0,0 +1,105 @@
+package rundiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestItems(t *testing.T) {
+	got := Items("- First  item\n\n* Second\n1. Third\n   plain line  \n")
+	want := []string{"First item", "Second", "Third", "plain line"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Items = %q, want %q", got, want)
+	}
+}
+
+func TestItemsHTML(t *testing.T) {
+	// As produced by the n8n converter and crew plugins.
+	news := `<a href="https://example.com/a" target="_blank"><strong>Rates held</strong></a><br>Fed keeps rates &amp; signals cuts<br><br><strong>Chips rally</strong>`
+	got := Items(news)
+	want := []string{"Rates held", "Fed keeps rates & signals cuts", "Chips rally"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Items(news) = %q, want %q", got, want)
+	}
+
+	got = Items("<p>Intro</p><ul><li>API: <em>operational</em></li><li class=\"x\">DB: degraded</li></ul>")
+	want = []string{"Intro", "API: operational", "DB: degraded"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Items(list) = %q, want %q", got, want)
+	}
+
+	d := Compare(
+		[]Section{{Title: "News", Content: "<strong>Rates held</strong><br><strong>Old story</strong>"}},
+		[]Section{{Title: "News", Content: "<strong>Rates held</strong><br><strong>New story</strong>"}},
+	)
+	if len(d.Sections) != 1 || len(d.Sections[0].Items) != 3 {
+		t.Fatalf("Compare = %+v, want one section with 3 items", d)
+	}
+	for _, it := range d.Sections[0].Items {
+		if it.Text == "New story" && it.Status != StatusAdded || it.Text == "Old story" && it.Status != StatusRemoved {
+			t.Errorf("item %q has status %s", it.Text, it.Status)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	prev := []Section{
+		{Title: "Status", Content: "- API: operational\n- DB: operational"},
+		{Title: "Headlines", Content: "- Old story\n- Shared story"},
+		{Title: "Weather", Content: "Sunny"},
+	}
+	curr := []Section{
+		{Title: "headlines", Content: "- Shared story\n- New story"},
+		{Title: "Status", Content: "- API: degraded\n- DB: operational"},
+		{Title: "Markets", Content: "- Stocks up"},
+	}
+	d := Compare(prev, curr)
+
+	want := Diff{Sections: []SectionDiff{
+		{Title: "headlines", Status: StatusChanged, Items: []Item{
+			{Text: "Shared story", Status: StatusUnchanged},
+			{Text: "New story", Status: StatusAdded},
+			{Text: "Old story", Status: StatusRemoved},
+		}},
+		{Title: "Status", Status: StatusChanged, Items: []Item{
+			{Text: "API: degraded", Previous: "API: operational", Status: StatusChanged},
+			{Text: "DB: operational", Status: StatusUnchanged},
+		}},
+		{Title: "Markets", Status: StatusAdded, Items: []Item{
+			{Text: "Stocks up", Status: StatusAdded},
+		}},
+		{Title: "Weather", Status: StatusRemoved, Items: []Item{
+			{Text: "Sunny", Status: StatusRemoved},
+		}},
+	}}
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("Compare =\n%+v\nwant\n%+v", d, want)
+	}
+	if !d.HasChanges() {
+		t.Error("HasChanges = false, want true")
+	}
+
+	onlyNew := d.OnlyNew()
+	wantNew := Diff{Sections: []SectionDiff{
+		{Title: "headlines", Status: StatusChanged, Items: []Item{{Text: "New story", Status: StatusAdded}}},
+		{Title: "Status", Status: StatusChanged, Items: []Item{{Text: "API: degraded", Previous: "API: operational", Status: StatusChanged}}},
+		{Title: "Markets", Status: StatusAdded, Items: []Item{{Text: "Stocks up", Status: StatusAdded}}},
+	}}
+	if !reflect.DeepEqual(onlyNew, wantNew) {
+		t.Errorf("OnlyNew =\n%+v\nwant\n%+v", onlyNew, wantNew)
+	}
+}
+
+func TestCompareUnchangedAndURLs(t *testing.T) {
+	same := []Section{{Title: "News", Content: "- https://a.example/1"}}
+	if d := Compare(same, same); d.HasChanges() {
+		t.Errorf("identical runs reported changes: %+v", d)
+	}
+
+	// URLs must not be paired as "changed" on their scheme prefix.
+	d := Compare(same, []Section{{Title: "News", Content: "- https://b.example/2"}})
+	items := d.Sections[0].Items
+	if len(items) != 2 || items[0].Status != StatusAdded || items[1].Status != StatusRemoved {
+		t.Errorf("URL items = %+v, want one added and one removed", items)
+	}
+}
@@ -0,0 +1,257 @@
+// Package search implements Postgres full-text search over a user's plugin
+// runs and legacy briefings, backed by the generated search_vector columns.
+package search
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/searchvm"
+	"gorm.io/gorm"
+)
+
+// PageSize is the number of results per page.
+const PageSize = 10
+
+// Status filter values.
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// failedRunStatuses are the run statuses shown under the "failed" filter.
+var failedRunStatuses = []string{"failed", "timed_out", "cancelled"}
+
+// Snippet highlight markers. ts_headline does not escape its input, so matches
+// are marked with these sentinels and converted to <mark> after escaping.
+const (
+	markStart = "⟦"
+	markStop  = "⟧"
+)
+
+// headlineOptions configures ts_headline snippets.
+var headlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop +
+	", MaxWords=30, MinWords=12, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// ErrInvalidFilter is returned by ParseParams for malformed form values.
+var ErrInvalidFilter = errors.New("search: invalid filter")
+
+// Params is a parsed search request.
+type Params struct {
+	Query  string
+	Plugin string
+	From   *time.Time // inclusive
+	To     *time.Time // exclusive
+	Status string
+	Page   int
+}
+
+// ParseParams reads q, plugin, from, to, status and page from form values.
+// Dates are YYYY-MM-DD in loc; "to" is inclusive of the whole day.
+func ParseParams(v url.Values, loc *time.Location) (Params, searchvm.Filters, error) {
+	f := searchvm.Filters{
+		Query:  strings.TrimSpace(v.Get("q")),
+		Plugin: strings.TrimSpace(v.Get("plugin")),
+		From:   v.Get("from"),
+		To:     v.Get("to"),
+		Status: v.Get("status"),
+	}
+	p := Params{Query: f.Query, Plugin: f.Plugin, Status: f.Status}
+
+	if len(p.Query) > 200 {
+		return p, f, fmt.Errorf("%w: query too long", ErrInvalidFilter)
+	}
+	if p.Status != "" && p.Status != StatusCompleted && p.Status != StatusFailed {
+		return p, f, fmt.Errorf("%w: status", ErrInvalidFilter)
+	}
+	if f.From != "" {
+		t, err := time.ParseInLocation("2006-01-02", f.From, loc)
+		if err != nil {
+			return p, f, fmt.Errorf("%w: from date", ErrInvalidFilter)
+		}
+		p.From = &t
+	}
+	if f.To != "" {
+		t, err := time.ParseInLocation("2006-01-02", f.To, loc)
+		if err != nil {
+			return p, f, fmt.Errorf("%w: to date", ErrInvalidFilter)
+		}
+		t = t.AddDate(0, 0, 1)
+		p.To = &t
+	}
+	if page, err := strconv.Atoi(v.Get("page")); err == nil && page > 0 {
+		p.Page = page
+	}
+	return p, f, nil
+}
+
+// row is the scan type for the union query.
+type row struct {
+	Kind         string
+	ID           uint
+	PluginName   string
+	Icon         string
+	Status       string
+	OccurredAt   time.Time
+	Summary      string
+	ErrorMessage string
+	Snippet      string
+}
+
+// snippetSource is the plain text ts_headline runs over: every string value
+// of the JSON document with HTML tags removed.
+func snippetSource(column string) string {
+	return fmt.Sprintf(`regexp_replace(COALESCE((SELECT string_agg(v #>> '{}', ' ') FROM jsonb_path_query(COALESCE(%s, '{}'::jsonb), 'strict $.** ? (@.type() == "string")') v), ''), '<[^>]*>', ' ', 'g')`, column)
+}
+
+// Search returns one page of results for userID, best matches first when a
+// query is given and newest first otherwise. hasMore reports a further page.
+func Search(db *gorm.DB, userID uint, p Params, loc *time.Location) ([]searchvm.Result, bool, error) {
+	var rows []row
+	if err := buildQuery(db, userID, p).Scan(&rows).Error; err != nil {
+		return nil, false, fmt.Errorf("search: query: %w", err)
+	}
+
+	hasMore := len(rows) > PageSize
+	if hasMore {
+		rows = rows[:PageSize]
+	}
+
+	results := make([]searchvm.Result, 0, len(rows))
+	for _, r := range rows {
+		res := searchvm.Result{
+			Kind:        r.Kind,
+			PluginName:  r.PluginName,
+			DisplayName: plugins.HumanizeName(r.PluginName),
+			Icon:        r.Icon,
+			Failed:      r.Status != StatusCompleted,
+			DateLabel:   r.OccurredAt.In(loc).Format("Jan 2, 2006 · 3:04 PM"),
+			Summary:     r.Summary,
+			Snippet:     highlight(r.Snippet),
+			URL:         "/plugins/" + r.PluginName,
+		}
+		if r.Kind == "briefing" {
+			res.URL = "/history"
+		}
+		if res.Failed && res.Summary == "" {
+			res.Summary = r.ErrorMessage
+		}
+		results = append(results, res)
+	}
+	return results, hasMore, nil
+}
+
+// buildQuery builds the union query for one page of p. Both sources are
+// scoped to userID.
+func buildQuery(db *gorm.DB, userID uint, p Params) *gorm.DB {
+	runs := db.Table("plugin_runs pr").
+		Joins("JOIN plugins p ON p.id = pr.plugin_id").
+		Where("pr.user_id = ? AND pr.deleted_at IS NULL", userID)
+	legacy := db.Table("briefings b").
+		Where("b.user_id = ? AND b.deleted_at IS NULL", userID).
+		Where("NOT EXISTS (SELECT 1 FROM plugin_runs m WHERE m.plugin_run_id = '" + models.LegacyBriefingRunIDPrefix + "' || b.id::text)")
+
+	switch p.Status {
+	case StatusCompleted:
+		runs = runs.Where("pr.status = ?", StatusCompleted)
+		legacy = legacy.Where("b.status = ?", StatusCompleted)
+	case StatusFailed:
+		runs = runs.Where("pr.status IN ?", failedRunStatuses)
+		legacy = legacy.Where("b.status = ?", StatusFailed)
+	default:
+		runs = runs.Where("pr.status IN ?", append([]string{StatusCompleted}, failedRunStatuses...))
+		legacy = legacy.Where("b.status IN ?", []string{StatusCompleted, StatusFailed})
+	}
+
+	runDate := "COALESCE(pr.completed_at, pr.created_at)"
+	briefingDate := "COALESCE(b.generated_at, b.created_at)"
+	if p.From != nil {
+		runs = runs.Where(runDate+" >= ?", *p.From)
+		legacy = legacy.Where(briefingDate+" >= ?", *p.From)
+	}
+	if p.To != nil {
+		runs = runs.Where(runDate+" < ?", *p.To)
+		legacy = legacy.Where(briefingDate+" < ?", *p.To)
+	}
+
+	includeLegacy := true
+	if p.Plugin != "" {
+		runs = runs.Where("p.name = ?", p.Plugin)
+		includeLegacy = p.Plugin == models.LegacyBriefingPluginName
+	}
+
+	runSelect := "'run' AS kind, pr.id, p.name AS plugin_name, p.icon, pr.status, " + runDate + " AS occurred_at, " +
+		"COALESCE(pr.output->>'summary', '') AS summary, COALESCE(pr.error_message, '') AS error_message"
+	briefingSelect := "'briefing' AS kind, b.id, '" + models.LegacyBriefingPluginName + "' AS plugin_name, '' AS icon, b.status, " + briefingDate + " AS occurred_at, " +
+		"'' AS summary, COALESCE(b.error_message, '') AS error_message"
+
+	order := "occurred_at DESC, kind, id DESC"
+	if p.Query != "" {
+		query := "websearch_to_tsquery('english', ?)"
+		runs = runs.Where("pr.search_vector @@ "+query, p.Query).
+			Select(runSelect+", ts_rank(pr.search_vector, "+query+") AS rank, ts_headline('english', "+snippetSource("pr.output")+", "+query+", ?) AS snippet",
+				p.Query, p.Query, headlineOptions)
+		legacy = legacy.Where("b.search_vector @@ "+query, p.Query).
+			Select(briefingSelect+", ts_rank(b.search_vector, "+query+") AS rank, ts_headline('english', "+snippetSource("b.content")+", "+query+", ?) AS snippet",
+				p.Query, p.Query, headlineOptions)
+		order = "rank DESC, " + order
+	} else {
+		runs = runs.Select(runSelect + ", 0::real AS rank, '' AS snippet")
+		legacy = legacy.Select(briefingSelect + ", 0::real AS rank, '' AS snippet")
+	}
+
+	if includeLegacy {
+		return db.Raw("SELECT * FROM (? UNION ALL ?) results ORDER BY "+order+" LIMIT ? OFFSET ?",
+			runs, legacy, PageSize+1, p.Page*PageSize)
+	}
+	return db.Raw("SELECT * FROM (?) results ORDER BY "+order+" LIMIT ? OFFSET ?",
+		runs, PageSize+1, p.Page*PageSize)
+}
+
+// NextURL builds the URL of the page after p. v holds the submitted form
+// values, which are carried over so the next page keeps the same filters.
+func NextURL(basePath string, v url.Values, p Params) string {
+	next := url.Values{}
+	for k, vals := range v {
+		next[k] = append([]string(nil), vals...)
+	}
+	next.Set("page", strconv.Itoa(p.Page+1))
+	return basePath + "?" + next.Encode()
+}
+
+// highlight turns a ts_headline snippet into safe HTML: entities left by the
+// stripped plugin HTML are decoded, everything is escaped, and the sentinel
+// markers become <mark> tags.
+func highlight(snippet string) string {
+	if snippet == "" {
+		return ""
+	}
+	s := html.EscapeString(html.UnescapeString(strings.Join(strings.Fields(snippet), " ")))
+	s = strings.ReplaceAll(s, markStart, "<mark>")
+	return strings.ReplaceAll(s, markStop, "</mark>")
+}
+
+// PluginOptions lists the plugins the user has history for, for the plugin filter.
+func PluginOptions(db *gorm.DB, userID uint) []searchvm.PluginOption {
+	var names []string
+	db.Raw(`
+		SELECT DISTINCT p.name FROM plugins p
+		JOIN plugin_runs pr ON pr.plugin_id = p.id AND pr.user_id = ? AND pr.deleted_at IS NULL
+		UNION
+		SELECT ? FROM briefings WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY 1
+	`, userID, models.LegacyBriefingPluginName, userID).Scan(&names)
+
+	opts := make([]searchvm.PluginOption, 0, len(names))
+	for _, n := range names {
+		opts = append(opts, searchvm.PluginOption{Name: n, DisplayName: plugins.HumanizeName(n)})
+	}
+	return opts
+}
@@ -0,0 +1,151 @@
+package search
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"the ⟦market⟧ rallied", "the <mark>market</mark> rallied"},
+		{"<script> ⟦x⟧", "&lt;script&gt; <mark>x</mark>"},
+		{"AT&amp;T  \n ⟦earnings⟧", "AT&amp;T <mark>earnings</mark>"},
+	}
+	for _, tt := range tests {
+		if got := highlight(tt.in); got != tt.want {
+			t.Errorf("highlight(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+
+	p, f, err := ParseParams(url.Values{
+		"q":      {"  rates  "},
+		"plugin": {"daily-news"},
+		"from":   {"2026-03-01"},
+		"to":     {"2026-03-02"},
+		"status": {"completed"},
+		"page":   {"2"},
+	}, loc)
+	if err != nil {
+		t.Fatalf("ParseParams: %v", err)
+	}
+	if p.Query != "rates" || f.Query != "rates" || p.Plugin != "daily-news" || p.Page != 2 {
+		t.Errorf("params = %+v", p)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, loc); !p.From.Equal(want) {
+		t.Errorf("from = %v, want %v", p.From, want)
+	}
+	if want := time.Date(2026, 3, 3, 0, 0, 0, 0, loc); !p.To.Equal(want) {
+		t.Errorf("to = %v, want %v (exclusive end of day)", p.To, want)
+	}
+
+	for _, bad := range []url.Values{
+		{"status": {"pending"}},
+		{"from": {"03/01/2026"}},
+		{"to": {"yesterday"}},
+	} {
+		if _, _, err := ParseParams(bad, loc); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("ParseParams(%v) error = %v, want ErrInvalidFilter", bad, err)
+		}
+	}
+}
+
+func TestNextURLRoundTrip(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	form := url.Values{
+		"q":      {"rates & <inflation>"},
+		"plugin": {"daily-news"},
+		"from":   {"2026-03-01"},
+		"to":     {"2026-03-02"},
+		"status": {"failed"},
+	}
+	p, f, err := ParseParams(form, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for page := 1; page <= 2; page++ {
+		u, err := url.Parse(NextURL("/api/history/search", form, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Path != "/api/history/search" {
+			t.Errorf("path = %q", u.Path)
+		}
+		next, nextFilters, err := ParseParams(u.Query(), loc)
+		if err != nil {
+			t.Fatalf("ParseParams(%s): %v", u, err)
+		}
+		want := p
+		want.Page = page
+		if !reflect.DeepEqual(next, want) || !reflect.DeepEqual(nextFilters, f) {
+			t.Errorf("page %d = %+v, %+v; want %+v, %+v", page, next, nextFilters, want, f)
+		}
+		p, form = next, u.Query()
+	}
+	if form.Get("page") != "2" || len(form["page"]) != 1 {
+		t.Errorf("page values = %v, want a single 2", form["page"])
+	}
+}
+
+// dryRunDB returns a Postgres-dialect DB that builds SQL without connecting.
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=none"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestBuildQueryScopesToUser(t *testing.T) {
+	db := dryRunDB(t)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	userFilter := regexp.MustCompile(`\b(pr|b)\.user_id = (\d+)`)
+
+	for name, p := range map[string]Params{
+		"timeline":      {},
+		"query":         {Query: "rates", Status: StatusCompleted, From: &from, Page: 3},
+		"legacy plugin": {Plugin: "daily-briefing"},
+		"other plugin":  {Query: "rates", Plugin: "daily-news"},
+	} {
+		stmt := buildQuery(db, 42, p).Find(&[]row{}).Statement
+		sql := db.Dialector.Explain(stmt.SQL.String(), stmt.Vars...)
+
+		scoped := map[string]bool{}
+		for _, m := range userFilter.FindAllStringSubmatch(sql, -1) {
+			if m[2] != "42" {
+				t.Errorf("%s: filters on %s, want user 42", name, m[0])
+			}
+			scoped[m[1]] = true
+		}
+		if !scoped["pr"] {
+			t.Errorf("%s: plugin runs not scoped to the user:\n%s", name, sql)
+		}
+		if strings.Contains(sql, "briefings b") && !scoped["b"] {
+			t.Errorf("%s: briefings not scoped to the user:\n%s", name, sql)
+		}
+		if !strings.Contains(sql, "pr.deleted_at IS NULL") {
+			t.Errorf("%s: deleted runs not excluded", name)
+		}
+	}
+}
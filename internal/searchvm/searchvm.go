@@ -0,0 +1,39 @@
+// Package searchvm contains view model types for history search.
+// It is a leaf package (no internal imports) so that the templates package can
+// import it without creating an import cycle with the search package.
+package searchvm
+
+// PluginOption is one entry in the plugin filter.
+type PluginOption struct {
+	Name        string
+	DisplayName string
+}
+
+// Filters holds the submitted search form values, echoed back into the form.
+type Filters struct {
+	Query  string
+	Plugin string
+	From   string // YYYY-MM-DD, user's timezone
+	To     string // YYYY-MM-DD, inclusive
+	Status string // "", "completed" or "failed"
+
+	PluginOptions []PluginOption
+}
+
+// Active reports whether any filter or query is set.
+func (f Filters) Active() bool {
+	return f.Query != "" || f.Plugin != "" || f.From != "" || f.To != "" || f.Status != ""
+}
+
+// Result is one matching plugin run or legacy briefing.
+type Result struct {
+	Kind        string // "run" or "briefing"
+	PluginName  string
+	DisplayName string
+	Icon        string
+	Failed      bool
+	DateLabel   string // formatted in the user's timezone
+	Summary     string
+	Snippet     string // HTML-escaped text with <mark> around matches; empty without a query
+	URL         string
+}
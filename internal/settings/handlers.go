@@ -10,7 +10,9 @@ import (
 
 	"github.com/a-h/templ"
 	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/channels"
 	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/digest"
 	"github.com/jimdaga/first-sip/internal/models"
 	"github.com/jimdaga/first-sip/internal/plugins"
 	"github.com/jimdaga/first-sip/internal/settingsvm"
@@ -132,6 +134,33 @@ func TogglePluginHandler(db *gorm.DB, pluginDir string, tierService *tiers.TierS
 			targetEnabled = !config.Enabled
 		}
 
+		// Plugins removed from the plugin directory can be disabled but not enabled.
+		if targetEnabled {
+			var plugin plugins.Plugin
+			if err := db.Select("id", "status").First(&plugin, pluginID).Error; err != nil {
+				c.Status(http.StatusNotFound)
+				return
+			}
+			if plugin.Status == plugins.PluginStatusUnavailable {
+				c.String(http.StatusConflict, "Plugin is no longer available")
+				return
+			}
+		}
+
+		// Plugins with unmet requirements (API keys, tier) cannot be enabled;
+		// re-render the row with the toggle disabled and the reason shown.
+		if targetEnabled {
+			vm, err := BuildSinglePluginSettingsViewModel(db, user.ID, pluginID, pluginDir, nil, nil, false)
+			if err != nil {
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+			if vm.EnableBlockedReason != "" {
+				render(c, templates.PluginAccordionRow(*vm))
+				return
+			}
+		}
+
 		// If enabling, check tier limit.
 		if targetEnabled {
 			canEnable, _, err := tierService.CanEnablePlugin(user.ID)
@@ -341,6 +370,10 @@ func SaveSettingsHandler(db *gorm.DB, pluginDir string, tierService *tiers.TierS
 				}
 			}
 			config.Settings = settingsJSON
+			// Settings validated against the current schema are up to date.
+			config.SettingsVersion = plugin.Version
+			config.SettingsSchemaHash = plugin.SchemaHash
+			config.SettingsErrors = nil
 			if cronExpression != "" {
 				config.CronExpression = cronExpression
 			}
@@ -384,6 +417,43 @@ func SaveSettingsHandler(db *gorm.DB, pluginDir string, tierService *tiers.TierS
 			}
 		}
 
+		// Delivery channel routing (only present when the user has channels).
+		if rawForm.Get("channels_submitted") == "true" {
+			var channelIDs []uint
+			for _, v := range rawForm["channel_ids"] {
+				if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+					channelIDs = append(channelIDs, uint(id))
+				}
+			}
+			if err := channels.SetPluginChannels(db, user.ID, pluginID, channelIDs); err != nil {
+				slog.Error("settings: failed to save plugin channels", "user_id", user.ID, "plugin_id", pluginID, "error", err)
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Web Push opt-in (only present when the user has a subscribed device).
+		if rawForm.Get("push_submitted") == "true" {
+			if err := db.Model(&plugins.UserPluginConfig{}).
+				Where("user_id = ? AND plugin_id = ? AND deleted_at IS NULL", user.ID, pluginID).
+				Update("push_enabled", rawForm.Get("push_enabled") == "true").Error; err != nil {
+				slog.Error("settings: failed to save push preference", "user_id", user.ID, "plugin_id", pluginID, "error", err)
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// "Only show what's new" tile mode.
+		if rawForm.Get("tile_mode_submitted") == "true" {
+			if err := db.Model(&plugins.UserPluginConfig{}).
+				Where("user_id = ? AND plugin_id = ? AND deleted_at IS NULL", user.ID, pluginID).
+				Update("whats_new_only", rawForm.Get("whats_new_only") == "true").Error; err != nil {
+				slog.Error("settings: failed to save tile mode", "user_id", user.ID, "plugin_id", pluginID, "error", err)
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+		}
+
 		// Success: re-render with SaveSuccess=true for "Saved ✓" feedback, keep expanded.
 		vm, err := BuildSinglePluginSettingsViewModel(db, user.ID, pluginID, pluginDir, nil, nil, true)
 		if err != nil {
@@ -480,6 +550,10 @@ func RunNowHandler(db *gorm.DB) gin.HandlerFunc {
 			c.String(http.StatusNotFound, "Plugin not found")
 			return
 		}
+		if plugin.Status == plugins.PluginStatusUnavailable {
+			c.String(http.StatusConflict, "Plugin is no longer available")
+			return
+		}
 
 		// Unmarshal saved settings.
 		var settingsMap map[string]interface{}
@@ -502,8 +576,9 @@ func RunNowHandler(db *gorm.DB) gin.HandlerFunc {
 }
 
 // AccountSettingsPageHandler returns a Gin handler for GET /settings/account.
-// Renders the account settings page with the user's timezone picker.
-func AccountSettingsPageHandler(db *gorm.DB) gin.HandlerFunc {
+// Renders the account settings page with the user's timezone picker, email digest
+// preferences and, when vapidPublicKey is set, the Web Push opt-in.
+func AccountSettingsPageHandler(db *gorm.DB, vapidPublicKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, err := getAuthUser(c, db)
 		if err != nil {
@@ -512,7 +587,7 @@ func AccountSettingsPageHandler(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		sidebarPlugins := dashboard.GetSidebarPlugins(db, user.ID)
-		render(c, templates.AccountSettingsPage(user.Name, user.Timezone, sidebarPlugins))
+		render(c, templates.AccountSettingsPage(user.Name, user.Timezone, user.EmailDigestEnabled, user.PreferredBriefingTime, vapidPublicKey, sidebarPlugins))
 	}
 }
 
@@ -553,6 +628,48 @@ func SaveTimezoneHandler(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// SaveEmailDigestHandler returns a Gin handler for POST /api/user/settings/email-digest.
+// Saves the digest opt-in and PreferredBriefingTime ("HH:MM", user's timezone).
+func SaveEmailDigestHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		briefingTime := c.PostForm("preferred_briefing_time")
+		if _, err := time.Parse("15:04", briefingTime); err != nil {
+			c.Header("Content-Type", "text/html")
+			c.String(http.StatusBadRequest, `<span class="settings-field-error">Enter a time as HH:MM.</span>`)
+			return
+		}
+		enabled := c.PostForm("email_digest_enabled") == "true"
+
+		if enabled {
+			if _, err := digest.EnsureUnsubscribeToken(db, user); err != nil {
+				slog.Error("settings: failed to create unsubscribe token", "user_id", user.ID, "error", err)
+				c.Header("Content-Type", "text/html")
+				c.String(http.StatusInternalServerError, `<span class="settings-field-error">Failed to save email digest.</span>`)
+				return
+			}
+		}
+
+		if err := db.Model(user).Updates(map[string]interface{}{
+			"email_digest_enabled":    enabled,
+			"preferred_briefing_time": briefingTime,
+		}).Error; err != nil {
+			slog.Error("settings: failed to save email digest", "user_id", user.ID, "error", err)
+			c.Header("Content-Type", "text/html")
+			c.String(http.StatusInternalServerError, `<span class="settings-field-error">Failed to save email digest.</span>`)
+			return
+		}
+
+		c.Header("Content-Type", "text/html")
+		c.String(http.StatusOK, `<span class="settings-save-success">Email digest saved</span>`)
+	}
+}
+
 // ProNotifyHandler returns a Gin handler for POST /api/pro/notify.
 // Logs the submitted email address and returns a thank-you HTML fragment.
 // No DB persistence needed for scaffolding phase.
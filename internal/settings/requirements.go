@@ -0,0 +1,134 @@
+package settings
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/settingsvm"
+	"gorm.io/gorm"
+)
+
+// secretLabels are the display names of the secrets plugins can require.
+var secretLabels = map[string]string{
+	plugins.SecretLLM:    "LLM API key",
+	plugins.SecretTavily: "Tavily API key",
+}
+
+// missingSecretSet returns the known secrets the user has not saved a key
+// for. Errors are logged and treated as nothing missing, so the settings
+// page still renders.
+func missingSecretSet(db *gorm.DB, userID uint) map[string]bool {
+	missing, err := plugins.MissingSecrets(db, userID, []string{plugins.SecretLLM, plugins.SecretTavily})
+	if err != nil {
+		slog.Warn("settings: failed to check API keys", "user_id", userID, "error", err)
+		return nil
+	}
+	set := make(map[string]bool, len(missing))
+	for _, s := range missing {
+		set[s] = true
+	}
+	return set
+}
+
+// applyRequirements fills the plugin's declared requirements and, when one
+// is unmet, the reason it cannot be enabled. tierName is the user's account
+// tier, or "" if it could not be determined.
+func applyRequirements(vm *PluginSettingsViewModel, row pluginRow, tierName string, missingSecrets map[string]bool) {
+	var secrets []string
+	if len(row.RequiresSecrets) > 0 {
+		_ = json.Unmarshal(row.RequiresSecrets, &secrets)
+	}
+
+	var optional []string
+	if len(row.OptionalSecrets) > 0 {
+		_ = json.Unmarshal(row.OptionalSecrets, &optional)
+	}
+
+	var unmet []string
+	for _, s := range secrets {
+		req := secretRequirement(s, missingSecrets)
+		if !req.Met {
+			unmet = append(unmet, req.Label)
+		}
+		vm.Requirements = append(vm.Requirements, req)
+	}
+	for _, s := range optional {
+		req := secretRequirement(s, missingSecrets)
+		req.Label += " (optional)"
+		vm.Requirements = append(vm.Requirements, req)
+	}
+	if row.Network {
+		vm.Requirements = append(vm.Requirements, settingsvm.Requirement{Label: "Internet access", Met: true})
+	}
+	if row.MinTier != "" {
+		req := settingsvm.Requirement{
+			Label: plugins.HumanizeName(row.MinTier) + " plan",
+			// An unknown tier fails open, like the plugin-limit check.
+			Met: tierName == "" || plugins.TierAtLeast(tierName, row.MinTier),
+		}
+		if !req.Met {
+			req.Link = "/pro"
+			vm.EnableBlockedReason = "Requires the " + req.Label + "."
+		}
+		vm.Requirements = append(vm.Requirements, req)
+	}
+	if len(unmet) > 0 && vm.EnableBlockedReason == "" {
+		vm.EnableBlockedReason = "Add your " + strings.Join(unmet, " and ") + " under API Keys to enable this plugin."
+	}
+}
+
+func secretRequirement(secret string, missingSecrets map[string]bool) settingsvm.Requirement {
+	label, ok := secretLabels[secret]
+	if !ok {
+		label = secret + " API key"
+	}
+	req := settingsvm.Requirement{Label: label, Met: !missingSecrets[secret]}
+	if !req.Met {
+		req.Link = "/settings/api-keys"
+	}
+	return req
+}
+
+// savedSecretSlots returns the secret slot names the user has saved, per
+// plugin ID. Errors are logged and treated as nothing saved.
+func savedSecretSlots(db *gorm.DB, userID uint) map[uint]map[string]bool {
+	saved, err := plugins.SavedSecretNames(db, userID)
+	if err != nil {
+		slog.Warn("settings: failed to check plugin secrets", "user_id", userID, "error", err)
+		return nil
+	}
+	return saved
+}
+
+// applySecretSlots fills the plugin's declared secret slots and lists them
+// as requirements. A required slot without a saved value blocks enabling,
+// unless an earlier requirement already does.
+func applySecretSlots(vm *PluginSettingsViewModel, row pluginRow, saved map[string]bool) {
+	var slots []plugins.SecretSlot
+	if len(row.SecretSlots) > 0 {
+		_ = json.Unmarshal(row.SecretSlots, &slots)
+	}
+
+	var unmet []string
+	for _, slot := range slots {
+		vm.SecretSlots = append(vm.SecretSlots, settingsvm.SecretSlot{
+			Name:        slot.Name,
+			Label:       slot.Label,
+			Description: slot.Description,
+			Required:    slot.Required,
+			Saved:       saved[slot.Name],
+		})
+		req := settingsvm.Requirement{Label: slot.Label, Met: saved[slot.Name] || !slot.Required}
+		if !slot.Required {
+			req.Label += " (optional)"
+		} else if !req.Met {
+			unmet = append(unmet, slot.Label)
+		}
+		vm.Requirements = append(vm.Requirements, req)
+	}
+	if len(unmet) > 0 && vm.EnableBlockedReason == "" {
+		vm.EnableBlockedReason = "Add your " + strings.Join(unmet, " and ") + " under Credentials to enable this plugin."
+	}
+}
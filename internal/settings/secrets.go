@@ -0,0 +1,129 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/templates"
+	"gorm.io/gorm"
+)
+
+// loadSecretSlots returns the secret slots the plugin declared at its last sync.
+func loadSecretSlots(db *gorm.DB, pluginID uint) ([]plugins.SecretSlot, error) {
+	var p plugins.Plugin
+	if err := db.Select("id", "secret_slots").First(&p, pluginID).Error; err != nil {
+		return nil, fmt.Errorf("settings: load plugin %d: %w", pluginID, err)
+	}
+	var slots []plugins.SecretSlot
+	if len(p.SecretSlots) > 0 {
+		if err := json.Unmarshal(p.SecretSlots, &slots); err != nil {
+			return nil, fmt.Errorf("settings: decode secret slots of plugin %d: %w", pluginID, err)
+		}
+	}
+	return slots, nil
+}
+
+// SaveSecretsHandler returns a Gin handler for POST /api/settings/:pluginID/secrets.
+// Each declared slot is read from the secret_<name> form field; blank fields
+// keep the saved value. Nothing is saved unless every non-blank value passes
+// its slot's validation.
+func SaveSecretsHandler(db *gorm.DB, pluginDir string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		pluginID, err := parsePluginID(c)
+		if err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		slots, err := loadSecretSlots(db, pluginID)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		values := make(map[string]string)
+		slotErrors := make(map[string]string)
+		for _, slot := range slots {
+			value := strings.TrimSpace(c.PostForm("secret_" + slot.Name))
+			if value == "" {
+				continue
+			}
+			if err := slot.Validate(value); err != nil {
+				slotErrors[slot.Name] = err.Error()
+				continue
+			}
+			values[slot.Name] = value
+		}
+
+		if len(slotErrors) == 0 {
+			for _, slot := range slots {
+				value, ok := values[slot.Name]
+				if !ok {
+					continue
+				}
+				if err := plugins.SaveUserSecret(db, user.ID, pluginID, slot, value); err != nil {
+					slog.Error("settings: failed to save plugin secret", "user_id", user.ID, "plugin_id", pluginID, "slot", slot.Name, "error", err)
+					c.Status(http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
+		vm, err := BuildSinglePluginSettingsViewModel(db, user.ID, pluginID, pluginDir, nil, nil, false)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		vm.ForceExpanded = true
+		vm.SecretsSaved = len(slotErrors) == 0 && len(values) > 0
+		for i := range vm.SecretSlots {
+			vm.SecretSlots[i].Error = slotErrors[vm.SecretSlots[i].Name]
+		}
+
+		render(c, templates.PluginAccordionRow(*vm))
+	}
+}
+
+// DeleteSecretHandler returns a Gin handler for POST /api/settings/:pluginID/secrets/:name/delete.
+// Removes the user's saved value for one secret slot.
+func DeleteSecretHandler(db *gorm.DB, pluginDir string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		pluginID, err := parsePluginID(c)
+		if err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if err := plugins.DeleteUserSecret(db, user.ID, pluginID, c.Param("name")); err != nil {
+			slog.Error("settings: failed to delete plugin secret", "user_id", user.ID, "plugin_id", pluginID, "slot", c.Param("name"), "error", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		vm, err := BuildSinglePluginSettingsViewModel(db, user.ID, pluginID, pluginDir, nil, nil, false)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		vm.ForceExpanded = true
+
+		render(c, templates.PluginAccordionRow(*vm))
+	}
+}
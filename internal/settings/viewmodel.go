@@ -13,6 +13,8 @@ import (
 
 	"github.com/kaptinlin/jsonschema"
 	cron "github.com/robfig/cron/v3"
+	"github.com/jimdaga/first-sip/internal/channels"
+	"github.com/jimdaga/first-sip/internal/models"
 	"github.com/jimdaga/first-sip/internal/plugins"
 	"github.com/jimdaga/first-sip/internal/settingsvm"
 	"github.com/jimdaga/first-sip/internal/tiers"
@@ -52,6 +54,21 @@ type pluginRow struct {
 	Settings           []byte
 	CronExpression     string
 	Timezone           string // sourced from users.timezone via JOIN
+	PushEnabled        bool
+	WhatsNewOnly       bool
+	PluginStatus       string
+	Deprecated         bool
+	ReplacedBy         string
+	PluginVersion      string
+	SchemaHash         string
+	SettingsVersion    string
+	SettingsSchemaHash string
+	SettingsErrors     []byte
+	RequiresSecrets    []byte
+	OptionalSecrets    []byte
+	Network            bool
+	MinTier            string
+	SecretSlots        []byte
 }
 
 // BuildTierInfo builds a TierInfo for the given user by querying the TierService.
@@ -101,7 +118,22 @@ func BuildPluginSettingsViewModels(db *gorm.DB, userID uint, pluginDir string, t
 			COALESCE(upc.enabled, false)           AS enabled,
 			upc.settings,
 			COALESCE(upc.cron_expression, '')      AS cron_expression,
-			COALESCE(u.timezone, 'UTC')            AS timezone
+			COALESCE(u.timezone, 'UTC')            AS timezone,
+			COALESCE(upc.push_enabled, false)      AS push_enabled,
+			COALESCE(upc.whats_new_only, false)    AS whats_new_only,
+			p.status                               AS plugin_status,
+			p.deprecated,
+			p.replaced_by,
+			p.version                              AS plugin_version,
+			p.schema_hash,
+			COALESCE(upc.settings_version, '')     AS settings_version,
+			COALESCE(upc.settings_schema_hash, '') AS settings_schema_hash,
+			upc.settings_errors,
+			p.requires_secrets,
+			p.optional_secrets,
+			p.network,
+			p.min_tier,
+			p.secret_slots
 		FROM plugins p
 		LEFT JOIN user_plugin_configs upc
 			ON p.id = upc.plugin_id
@@ -109,12 +141,18 @@ func BuildPluginSettingsViewModels(db *gorm.DB, userID uint, pluginDir string, t
 			AND upc.deleted_at IS NULL
 		LEFT JOIN users u ON u.id = ?
 		WHERE p.deleted_at IS NULL
+		  AND (p.status = 'available' OR upc.enabled = true)
 		ORDER BY p.name ASC
 	`, userID, userID).Scan(&rows).Error
 	if err != nil {
 		return nil, fmt.Errorf("settings: query plugins: %w", err)
 	}
 
+	userChannels, routes := loadChannelRoutes(db, userID)
+	pushDevices := hasPushDevices(db, userID)
+	missingSecrets := missingSecretSet(db, userID)
+	savedSlots := savedSecretSlots(db, userID)
+
 	vms := make([]PluginSettingsViewModel, 0, len(rows))
 	for _, row := range rows {
 		// Parse saved settings JSON.
@@ -136,7 +174,7 @@ func BuildPluginSettingsViewModels(db *gorm.DB, userID uint, pluginDir string, t
 		var fields []FieldViewModel
 		hasRequired := false
 		if schema != nil {
-			fields = schemaToFields(schema, savedSettings, nil, nil)
+			fields = schemaToFields(schema, savedSettings, nil, storedSettingsErrors(row))
 			// Check if any fields are required.
 			for _, f := range fields {
 				if f.Required {
@@ -152,7 +190,7 @@ func BuildPluginSettingsViewModels(db *gorm.DB, userID uint, pluginDir string, t
 		vm := PluginSettingsViewModel{
 			PluginID:          row.PluginID,
 			PluginName:        row.PluginName,
-			DisplayName:       humanizePluginName(row.PluginName),
+			DisplayName:       plugins.HumanizeName(row.PluginName),
 			Description:       row.Description,
 			Icon:              row.Icon,
 			Enabled:           row.Enabled,
@@ -163,6 +201,13 @@ func BuildPluginSettingsViewModels(db *gorm.DB, userID uint, pluginDir string, t
 			CronExpression:    row.CronExpression,
 			IsFreeUser:        tierInfo.TierName == "free",
 		}
+		vm.Channels = channelOptions(userChannels, routes, row.PluginID)
+		vm.PushAvailable = pushDevices
+		vm.PushEnabled = row.PushEnabled
+		vm.WhatsNewOnly = row.WhatsNewOnly
+		applyLifecycle(&vm, row)
+		applyRequirements(&vm, row, tierInfo.TierName, missingSecrets)
+		applySecretSlots(&vm, row, savedSlots[row.PluginID])
 		// Disable the toggle for non-enabled plugins when user is at the plugin limit.
 		if tierInfo.AtPluginLimit && !row.Enabled {
 			vm.IsDisabledByTier = true
@@ -194,7 +239,22 @@ func BuildSinglePluginSettingsViewModel(
 			COALESCE(upc.enabled, false)           AS enabled,
 			upc.settings,
 			COALESCE(upc.cron_expression, '')      AS cron_expression,
-			COALESCE(u.timezone, 'UTC')            AS timezone
+			COALESCE(u.timezone, 'UTC')            AS timezone,
+			COALESCE(upc.push_enabled, false)      AS push_enabled,
+			COALESCE(upc.whats_new_only, false)    AS whats_new_only,
+			p.status                               AS plugin_status,
+			p.deprecated,
+			p.replaced_by,
+			p.version                              AS plugin_version,
+			p.schema_hash,
+			COALESCE(upc.settings_version, '')     AS settings_version,
+			COALESCE(upc.settings_schema_hash, '') AS settings_schema_hash,
+			upc.settings_errors,
+			p.requires_secrets,
+			p.optional_secrets,
+			p.network,
+			p.min_tier,
+			p.secret_slots
 		FROM plugins p
 		LEFT JOIN user_plugin_configs upc
 			ON p.id = upc.plugin_id
@@ -229,6 +289,9 @@ func BuildSinglePluginSettingsViewModel(
 	var fields []FieldViewModel
 	hasRequired := false
 	if schema != nil {
+		if submittedValues == nil && fieldErrors == nil {
+			fieldErrors = storedSettingsErrors(row)
+		}
 		fields = schemaToFields(schema, savedSettings, submittedValues, fieldErrors)
 		for _, f := range fields {
 			if f.Required {
@@ -243,7 +306,7 @@ func BuildSinglePluginSettingsViewModel(
 	vm := &PluginSettingsViewModel{
 		PluginID:          row.PluginID,
 		PluginName:        row.PluginName,
-		DisplayName:       humanizePluginName(row.PluginName),
+		DisplayName:       plugins.HumanizeName(row.PluginName),
 		Description:       row.Description,
 		Icon:              row.Icon,
 		Enabled:           row.Enabled,
@@ -254,9 +317,99 @@ func BuildSinglePluginSettingsViewModel(
 		CronExpression:    row.CronExpression,
 		SaveSuccess:       saveSuccess,
 	}
+	userChannels, routes := loadChannelRoutes(db, userID)
+	vm.Channels = channelOptions(userChannels, routes, row.PluginID)
+	vm.PushAvailable = hasPushDevices(db, userID)
+	vm.PushEnabled = row.PushEnabled
+	vm.WhatsNewOnly = row.WhatsNewOnly
+	applyLifecycle(vm, row)
+	tierName := ""
+	if tier, err := tiers.New(db).GetUserTier(userID); err == nil {
+		tierName = tier.Name
+	}
+	applyRequirements(vm, row, tierName, missingSecretSet(db, userID))
+	applySecretSlots(vm, row, savedSecretSlots(db, userID)[row.PluginID])
 	return vm, nil
 }
 
+// applyLifecycle copies the plugin's availability, deprecation and version
+// into vm, flagging settings saved under an older major version.
+func applyLifecycle(vm *PluginSettingsViewModel, row pluginRow) {
+	vm.Unavailable = row.PluginStatus == plugins.PluginStatusUnavailable
+	vm.Deprecated = row.Deprecated
+	if row.ReplacedBy != "" {
+		vm.ReplacedBy = plugins.HumanizeName(row.ReplacedBy)
+	}
+	vm.PluginVersion = row.PluginVersion
+	if len(row.Settings) > 0 && plugins.SettingsOutdated(row.SettingsVersion, row.SettingsSchemaHash, row.PluginVersion, row.SchemaHash) {
+		vm.SettingsOutdatedVersion = row.SettingsVersion
+	}
+	vm.SettingsInvalid = len(storedSettingsErrors(row)) > 0
+}
+
+// storedSettingsErrors returns the validation errors recorded when the
+// plugin's schema changed under the user's saved settings, keyed by JSON
+// pointer like the errors from validateAndGetFieldErrors.
+func storedSettingsErrors(row pluginRow) map[string]string {
+	if len(row.SettingsErrors) == 0 {
+		return nil
+	}
+	var errs map[string]string
+	if err := json.Unmarshal(row.SettingsErrors, &errs); err != nil {
+		return nil
+	}
+	return errs
+}
+
+// hasPushDevices reports whether the user has subscribed at least one browser
+// to Web Push; the per-plugin opt-in is hidden otherwise.
+func hasPushDevices(db *gorm.DB, userID uint) bool {
+	var n int64
+	db.Model(&models.PushSubscription{}).Where("user_id = ?", userID).Count(&n)
+	return n > 0
+}
+
+// loadChannelRoutes returns the user's notification channels and, per plugin
+// ID, the set of channel IDs routed to it. Errors yield empty results so the
+// settings page still renders.
+func loadChannelRoutes(db *gorm.DB, userID uint) ([]models.NotificationChannel, map[uint]map[uint]bool) {
+	userChannels, err := channels.ListChannels(db, userID)
+	if err != nil || len(userChannels) == 0 {
+		return nil, nil
+	}
+	var routes []models.NotificationChannelPlugin
+	db.Where("user_id = ?", userID).Find(&routes)
+
+	byPlugin := make(map[uint]map[uint]bool)
+	for _, r := range routes {
+		if byPlugin[r.PluginID] == nil {
+			byPlugin[r.PluginID] = make(map[uint]bool)
+		}
+		byPlugin[r.PluginID][r.NotificationChannelID] = true
+	}
+	return userChannels, byPlugin
+}
+
+// channelOptions builds the delivery-channel checkboxes for one plugin.
+func channelOptions(userChannels []models.NotificationChannel, routes map[uint]map[uint]bool, pluginID uint) []settingsvm.ChannelOption {
+	if len(userChannels) == 0 {
+		return nil
+	}
+	opts := make([]settingsvm.ChannelOption, 0, len(userChannels))
+	for _, ch := range userChannels {
+		label := plugins.HumanizeName(ch.Kind)
+		if ch.Name != "" {
+			label = ch.Name + " (" + label + ")"
+		}
+		opts = append(opts, settingsvm.ChannelOption{
+			ID:       ch.ID,
+			Label:    label,
+			Selected: routes[pluginID][ch.ID],
+		})
+	}
+	return opts
+}
+
 // getPluginStatus queries plugin runs to compute status for a single plugin.
 // Returns nil if there are no runs yet.
 func getPluginStatus(db *gorm.DB, userID, pluginID uint, cronExpr, timezone string) *PluginStatusViewModel {
@@ -272,8 +425,8 @@ func getPluginStatus(db *gorm.DB, userID, pluginID uint, cronExpr, timezone stri
 
 	// Recent failed runs (last 5).
 	var failedRuns []plugins.PluginRun
-	db.Where("user_id = ? AND plugin_id = ? AND status = ? AND deleted_at IS NULL",
-		userID, pluginID, plugins.PluginRunStatusFailed).
+	db.Where("user_id = ? AND plugin_id = ? AND status IN ? AND deleted_at IS NULL",
+		userID, pluginID, []string{plugins.PluginRunStatusFailed, plugins.PluginRunStatusTimedOut}).
 		Order("created_at DESC").Limit(5).Find(&failedRuns)
 
 	errors := make([]ErrorEntry, 0, len(failedRuns))
@@ -286,7 +439,7 @@ func getPluginStatus(db *gorm.DB, userID, pluginID uint, cronExpr, timezone stri
 
 	// Compute health color.
 	health := "green"
-	if latestRun.Status == plugins.PluginRunStatusFailed {
+	if latestRun.Status == plugins.PluginRunStatusFailed || latestRun.Status == plugins.PluginRunStatusTimedOut {
 		health = "red"
 	} else if len(failedRuns) > 0 {
 		health = "yellow"
@@ -329,15 +482,11 @@ func computeNextRun(cronExpr, timezone string) *time.Time {
 }
 
 // loadPluginSchema reads and compiles the JSON Schema for a plugin.
-// Returns nil, nil if schemaRelPath is empty (plugin has no schema).
+// Returns nil, nil if the plugin has no schema.
 func loadPluginSchema(pluginDir, pluginName, schemaRelPath string) (*jsonschema.Schema, error) {
-	if schemaRelPath == "" {
-		return nil, nil
-	}
-	fullPath := filepath.Join(pluginDir, pluginName, schemaRelPath)
-	data, err := os.ReadFile(fullPath)
-	if err != nil {
-		return nil, fmt.Errorf("read schema %s: %w", fullPath, err)
+	data, fullPath, err := readPluginSchema(pluginDir, pluginName, schemaRelPath)
+	if err != nil || data == nil {
+		return nil, err
 	}
 	compiler := jsonschema.NewCompiler()
 	// SetPreserveExtra(true) enables x- extension fields to land in schema.Extra.
@@ -350,6 +499,24 @@ func loadPluginSchema(pluginDir, pluginName, schemaRelPath string) (*jsonschema.
 	return schema, nil
 }
 
+// readPluginSchema returns the raw schema bytes and a description of where they
+// came from. Compiled-in plugins serve their schema from memory; directory
+// plugins read schemaRelPath under pluginDir. Returns nil data if there is no schema.
+func readPluginSchema(pluginDir, pluginName, schemaRelPath string) ([]byte, string, error) {
+	if compiled, ok := plugins.CompiledSettingsSchema(pluginName); ok {
+		return compiled, "compiled-in plugin " + pluginName, nil
+	}
+	if schemaRelPath == "" {
+		return nil, "", nil
+	}
+	fullPath := filepath.Join(pluginDir, pluginName, schemaRelPath)
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fullPath, fmt.Errorf("read schema %s: %w", fullPath, err)
+	}
+	return data, fullPath, nil
+}
+
 // schemaToFields converts a compiled *jsonschema.Schema into a flat []FieldViewModel slice.
 // Keys are sorted for deterministic ordering (Pitfall 6).
 // Value priority: submittedValues > savedSettings > schema default.
@@ -550,18 +717,6 @@ func validateSingleField(schema *jsonschema.Schema, fieldKey string, rawValue st
 	return ""
 }
 
-// humanizePluginName converts a kebab-case plugin name to a title-case display name.
-// E.g. "daily-news-digest" → "Daily News Digest".
-func humanizePluginName(name string) string {
-	words := strings.Split(name, "-")
-	for i, w := range words {
-		if len(w) > 0 {
-			words[i] = strings.ToUpper(w[:1]) + w[1:]
-		}
-	}
-	return strings.Join(words, " ")
-}
-
 // labelFromSchema returns the display label for a field.
 // Uses schema.Title if non-empty, otherwise humanizes the key.
 func labelFromSchema(key string, s *jsonschema.Schema) string {
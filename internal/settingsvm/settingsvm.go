@@ -81,4 +81,70 @@ type PluginSettingsViewModel struct {
 	IsDisabledByTier  bool   // true for non-enabled plugins when user is at plugin limit
 	FrequencyError    string // set when save is rejected for frequency violation
 	IsFreeUser        bool   // true when user's tier is "free" — drives Pro hints in template
+
+	// Channels lists the user's chat channels; Selected ones receive this plugin's output.
+	Channels []ChannelOption
+
+	// PushAvailable is true when the user has a Web Push device; PushEnabled
+	// is the per-plugin opt-in for a notification when a run completes.
+	PushAvailable bool
+	PushEnabled   bool
+
+	// WhatsNewOnly switches the dashboard tile to show only the items added or
+	// changed since the previous run.
+	WhatsNewOnly bool
+
+	// Unavailable is true when the plugin was removed from the plugin
+	// directory; it can be disabled but not enabled. Deprecated plugins still
+	// run, and ReplacedBy is the display name of their successor, if any.
+	Unavailable bool
+	Deprecated  bool
+	ReplacedBy  string
+
+	// PluginVersion is the installed plugin version. SettingsOutdatedVersion
+	// is set to the version the user's settings were saved under when a newer
+	// major version changed the settings schema and no migration upgraded them.
+	PluginVersion           string
+	SettingsOutdatedVersion string
+
+	// SettingsInvalid is set when the saved settings failed re-validation
+	// against the current schema at plugin sync; the offending fields carry
+	// the errors.
+	SettingsInvalid bool
+
+	// Requirements lists what the plugin declares it needs (API keys,
+	// internet access, a minimum tier). EnableBlockedReason is set when an
+	// unmet requirement stops the user from enabling the plugin.
+	Requirements        []Requirement
+	EnableBlockedReason string
+
+	// SecretSlots are the plugin's own credentials (plugin.yaml `secrets:`),
+	// saved from a separate form. SecretsSaved drives its "Saved ✓" state.
+	SecretSlots  []SecretSlot
+	SecretsSaved bool
+}
+
+// Requirement is one declared plugin requirement on the settings page.
+type Requirement struct {
+	Label string
+	Met   bool
+	Link  string // where to satisfy an unmet requirement, e.g. /settings/api-keys
+}
+
+// SecretSlot is one plugin-declared secret in the credentials form. The
+// value is never rendered; Saved reports whether the user has set one.
+type SecretSlot struct {
+	Name        string
+	Label       string
+	Description string
+	Required    bool
+	Saved       bool
+	Error       string // inline error from the last save
+}
+
+// ChannelOption is a notification channel checkbox in a plugin's settings form.
+type ChannelOption struct {
+	ID       uint
+	Label    string // e.g. "#morning (Slack)"
+	Selected bool
 }
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jimdaga/first-sip/internal/plugins"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
@@ -123,7 +124,7 @@ func (c *ResultConsumer) Close() error {
 
 // StartResultConsumer is a convenience function that starts the result consumer
 // in a background goroutine and returns a stop function
-func StartResultConsumer(redisURL string, db *gorm.DB) (stop func(), err error) {
+func StartResultConsumer(redisURL string, db *gorm.DB, registry *plugins.Registry) (stop func(), err error) {
 	consumer, err := NewResultConsumer(redisURL, "go-worker-1")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create result consumer: %w", err)
@@ -133,7 +134,7 @@ func StartResultConsumer(redisURL string, db *gorm.DB) (stop func(), err error)
 
 	// Start consumer in background goroutine
 	go func() {
-		if err := consumer.ConsumeResults(ctx, HandlePluginResult(db)); err != nil {
+		if err := consumer.ConsumeResults(ctx, HandlePluginResult(db, registry)); err != nil {
 			if err != context.Canceled {
 				slog.Error("Result consumer stopped with error", "error", err)
 			}
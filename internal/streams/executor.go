@@ -0,0 +1,52 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jimdaga/first-sip/internal/plugins"
+)
+
+// CrewAIExecutor runs crewai-stream plugins by publishing the request to the
+// plugin:requests stream. It is asynchronous: the result arrives later on
+// plugin:results and is applied by HandlePluginResult.
+type CrewAIExecutor struct {
+	publisher *Publisher
+}
+
+// NewCrewAIExecutor creates a CrewAIExecutor that publishes through publisher.
+func NewCrewAIExecutor(publisher *Publisher) *CrewAIExecutor {
+	return &CrewAIExecutor{publisher: publisher}
+}
+
+// Saturated implements plugins.Saturable using the sidecar queue depth.
+func (e *CrewAIExecutor) Saturated(ctx context.Context) (bool, int64, error) {
+	return e.publisher.Saturated(ctx)
+}
+
+// Execute implements plugins.Executor. The sidecar expects credentials as
+// underscore-prefixed settings keys, so secrets are merged back in.
+func (e *CrewAIExecutor) Execute(ctx context.Context, req plugins.ExecutionRequest) (*plugins.RunResult, error) {
+	settings := make(map[string]interface{}, len(req.Settings)+len(req.Secrets))
+	for k, v := range req.Settings {
+		settings[k] = v
+	}
+	for k, v := range req.Secrets {
+		settings[k] = v
+	}
+
+	_, err := e.publisher.PublishPluginRequest(ctx, PluginRequest{
+		PluginRunID: req.PluginRunID,
+		PluginName:  req.Plugin.Name,
+		UserID:      req.UserID,
+		Settings:    settings,
+	})
+	if errors.Is(err, ErrQueueSaturated) {
+		return nil, fmt.Errorf("%w: %w", plugins.ErrExecutorSaturated, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
@@ -1,74 +1,86 @@
 package streams
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"time"
 
 	"github.com/jimdaga/first-sip/internal/plugins"
-	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
+// resultSource identifies the result consumer in transition rejection records.
+const resultSource = "result-consumer"
+
 // HandlePluginResult returns a handler function that updates PluginRun records
-// based on stream results
-func HandlePluginResult(db *gorm.DB) func(PluginResult) error {
+// based on stream results.
+//
+// Results are applied through the PluginRun state machine, so handling is
+// idempotent: a duplicate or late result for a run that is already terminal
+// (completed, failed, cancelled, timed_out) is rejected, recorded, and ACKed
+// rather than overwriting the run.
+//
+// Accepted terminal transitions notify plugins run observers, which is how
+// user webhooks, chat channels and Web Push notifications are triggered.
+//
+// Completed output is validated against the output schema the plugin declares
+// in plugin.yaml (see plugins.ValidateResult); output that does not match
+// fails the run. registry may be nil, in which case only v1 rules apply.
+func HandlePluginResult(db *gorm.DB, registry *plugins.Registry) func(PluginResult) error {
 	return func(result PluginResult) error {
-		var pluginRun plugins.PluginRun
-
-		// Find PluginRun by PluginRunID field (not GORM ID)
-		if err := db.Where("plugin_run_id = ?", result.PluginRunID).First(&pluginRun).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return fmt.Errorf("plugin run not found: %s", result.PluginRunID)
-			}
-			return fmt.Errorf("failed to find plugin run: %w", err)
+		runResult := plugins.ValidateResult(pluginForRun(db, registry, result.PluginRunID), plugins.RunResult{
+			Status: result.Status,
+			Output: result.Output,
+			Error:  result.Error,
+		})
+		if runResult.Status != result.Status {
+			slog.Warn("Plugin result rejected by output schema",
+				"plugin_run_id", result.PluginRunID,
+				"error", runResult.Error,
+			)
 		}
 
-		// Update based on status
-		now := time.Now()
-		updates := map[string]interface{}{
-			"completed_at": now,
+		status, err := plugins.ApplyRunResult(db, result.PluginRunID, runResult, resultSource)
+		if errors.Is(err, plugins.ErrTransitionRejected) {
+			// Already recorded by TransitionRun; ACK so the message is not redelivered.
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update plugin run: %w", err)
 		}
 
-		if result.Status == "completed" {
-			// Guard against invalid JSON before storing in JSONB column.
-			// After the sidecar fix, all output should be valid JSON.
-			// This safety net prevents PostgreSQL from rejecting malformed payloads.
-			if !json.Valid([]byte(result.Output)) {
-				slog.Warn("Plugin result output is not valid JSON — marking run as failed",
-					"plugin_run_id", result.PluginRunID,
-					"output_preview", result.Output[:min(len(result.Output), 100)],
-				)
-				updates["status"] = plugins.PluginRunStatusFailed
-				updates["error_message"] = "output was not valid JSON"
-			} else {
-				updates["status"] = plugins.PluginRunStatusCompleted
-				updates["output"] = datatypes.JSON(result.Output)
-
-				slog.Info("Plugin run completed",
-					"plugin_run_id", result.PluginRunID,
-					"status", "completed",
-				)
-			}
-		} else if result.Status == "failed" {
-			updates["status"] = plugins.PluginRunStatusFailed
-			updates["error_message"] = result.Error
-
-			slog.Error("Plugin run failed",
+		if status == plugins.PluginRunStatusCompleted {
+			slog.Info("Plugin run completed",
 				"plugin_run_id", result.PluginRunID,
-				"status", "failed",
-				"error", result.Error,
+				"status", status,
 			)
 		} else {
-			return fmt.Errorf("unknown status: %s", result.Status)
+			slog.Error("Plugin run failed",
+				"plugin_run_id", result.PluginRunID,
+				"status", status,
+				"error", runResult.Error,
+			)
 		}
 
-		// Apply updates
-		if err := db.Model(&pluginRun).Updates(updates).Error; err != nil {
-			return fmt.Errorf("failed to update plugin run: %w", err)
-		}
+		return nil
+	}
+}
 
+// pluginForRun looks up the manifest of the plugin a run belongs to. Returns
+// nil when the registry is unavailable or the plugin is no longer registered.
+func pluginForRun(db *gorm.DB, registry *plugins.Registry, pluginRunID string) *plugins.PluginMetadata {
+	if registry == nil {
+		return nil
+	}
+	var name string
+	if err := db.Raw(`
+		SELECT p.name
+		FROM plugin_runs pr
+		JOIN plugins p ON p.id = pr.plugin_id
+		WHERE pr.plugin_run_id = ?
+	`, pluginRunID).Scan(&name).Error; err != nil || name == "" {
 		return nil
 	}
+	meta, _ := registry.Get(name)
+	return meta
 }
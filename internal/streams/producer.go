@@ -3,19 +3,45 @@ package streams
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// streamMaxLen caps the request stream. The publisher refuses to publish once
+// queue depth reaches maxQueueDepth, which must stay well below this value so
+// that approximate trimming never discards an unconsumed request.
+const streamMaxLen = 10000
+
+// depthCacheTTL is how long a queue depth reading is reused by Saturated. The
+// worker checks before publishing, PublishPluginRequest checks again, and the
+// dashboard checks on every render; reusing a recent reading keeps that to one
+// XINFO GROUPS per interval. Requests published within the interval can overshoot
+// maxQueueDepth slightly, which the streamMaxLen/2 clamp leaves room for.
+const depthCacheTTL = 5 * time.Second
+
+// ErrQueueSaturated is returned by PublishPluginRequest when the sidecar's
+// consumer group is too far behind to accept more work.
+var ErrQueueSaturated = errors.New("plugin request queue saturated")
+
 // Publisher publishes plugin requests to Redis Streams
 type Publisher struct {
-	rdb *redis.Client
+	rdb           *redis.Client
+	maxQueueDepth int64
+
+	mu        sync.Mutex
+	depth     int64
+	checkedAt time.Time
 }
 
-// NewPublisher creates a new Publisher instance
-func NewPublisher(redisURL string) (*Publisher, error) {
+// NewPublisher creates a new Publisher instance.
+// maxQueueDepth is the number of queued (undelivered + unacknowledged) requests
+// at which the publisher reports saturation. Values <= 0 disable the check.
+func NewPublisher(redisURL string, maxQueueDepth int64) (*Publisher, error) {
 	opts, err := redis.ParseURL(redisURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
@@ -23,11 +49,89 @@ func NewPublisher(redisURL string) (*Publisher, error) {
 
 	client := redis.NewClient(opts)
 
-	return &Publisher{rdb: client}, nil
+	if maxQueueDepth >= streamMaxLen {
+		maxQueueDepth = streamMaxLen / 2
+	}
+
+	return &Publisher{rdb: client, maxQueueDepth: maxQueueDepth}, nil
+}
+
+// QueueDepth returns the number of requests the CrewAI consumer group has not
+// finished with: entries not yet delivered (lag) plus entries delivered but not
+// yet acknowledged (pending). If the group does not exist yet, every entry in
+// the stream counts as queued.
+func (p *Publisher) QueueDepth(ctx context.Context) (int64, error) {
+	groups, err := p.rdb.XInfoGroups(ctx, StreamPluginRequests).Result()
+	if err != nil {
+		// Stream has never been written to — nothing is queued.
+		if strings.Contains(err.Error(), "no such key") {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read consumer group info: %w", err)
+	}
+
+	for _, g := range groups {
+		if g.Name != GroupCrewAIWorkers {
+			continue
+		}
+		if g.Lag >= 0 {
+			return g.Lag + g.Pending, nil
+		}
+		// Redis cannot compute lag (e.g. after trimming) — fall back to stream length.
+		break
+	}
+
+	depth, err := p.rdb.XLen(ctx, StreamPluginRequests).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stream length: %w", err)
+	}
+	return depth, nil
 }
 
-// PublishPluginRequest publishes a plugin request to the stream
+// Saturated reports whether the queue depth has reached the configured maximum.
+// Returns the observed depth alongside the verdict. A reading younger than
+// depthCacheTTL is reused instead of querying Redis again.
+func (p *Publisher) Saturated(ctx context.Context) (bool, int64, error) {
+	if p.maxQueueDepth <= 0 {
+		return false, 0, nil
+	}
+	depth, err := p.cachedQueueDepth(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	return depth >= p.maxQueueDepth, depth, nil
+}
+
+// cachedQueueDepth returns the last QueueDepth reading if it is fresh enough,
+// otherwise reads and remembers a new one. Failed reads are not cached.
+func (p *Publisher) cachedQueueDepth(ctx context.Context) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.checkedAt.IsZero() && time.Since(p.checkedAt) < depthCacheTTL {
+		return p.depth, nil
+	}
+	depth, err := p.QueueDepth(ctx)
+	if err != nil {
+		return 0, err
+	}
+	p.depth, p.checkedAt = depth, time.Now()
+	return depth, nil
+}
+
+// PublishPluginRequest publishes a plugin request to the stream.
+// Returns ErrQueueSaturated (without publishing) when the sidecar is saturated,
+// so callers can defer the request instead of having Redis trim it unconsumed.
+// The check reuses a recent Saturated reading, so a caller that already checked
+// does not pay for a second round-trip.
 func (p *Publisher) PublishPluginRequest(ctx context.Context, req PluginRequest) (string, error) {
+	saturated, depth, err := p.Saturated(ctx)
+	if err != nil {
+		return "", err
+	}
+	if saturated {
+		return "", fmt.Errorf("%w: %d requests queued", ErrQueueSaturated, depth)
+	}
+
 	payload, err := json.Marshal(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
@@ -35,7 +139,7 @@ func (p *Publisher) PublishPluginRequest(ctx context.Context, req PluginRequest)
 
 	result := p.rdb.XAdd(ctx, &redis.XAddArgs{
 		Stream: StreamPluginRequests,
-		MaxLen: 10000,
+		MaxLen: streamMaxLen,
 		Approx: true,
 		ID:     "*", // auto-generate ID
 		Values: map[string]interface{}{
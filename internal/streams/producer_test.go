@@ -0,0 +1,58 @@
+package streams
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSaturatedDisabled(t *testing.T) {
+	for _, depth := range []int64{0, -1} {
+		// No Redis client: a disabled check must not touch it.
+		p := &Publisher{maxQueueDepth: depth}
+		saturated, queued, err := p.Saturated(context.Background())
+		if saturated || queued != 0 || err != nil {
+			t.Errorf("maxQueueDepth %d: Saturated = %v, %d, %v; want false, 0, nil", depth, saturated, queued, err)
+		}
+	}
+}
+
+func TestNewPublisherClampsMaxQueueDepth(t *testing.T) {
+	for _, tc := range []struct {
+		requested, want int64
+	}{
+		{100, 100},
+		{streamMaxLen - 1, streamMaxLen - 1},
+		{streamMaxLen, streamMaxLen / 2},
+		{50000, streamMaxLen / 2},
+	} {
+		p, err := NewPublisher("redis://localhost:6379/0", tc.requested)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.maxQueueDepth != tc.want {
+			t.Errorf("NewPublisher(%d): maxQueueDepth = %d, want %d", tc.requested, p.maxQueueDepth, tc.want)
+		}
+		p.Close()
+	}
+}
+
+func TestSaturatedReusesRecentDepth(t *testing.T) {
+	// Nothing listens on port 1, so any Redis query fails.
+	p, err := NewPublisher("redis://127.0.0.1:1/0", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	p.depth, p.checkedAt = 12, time.Now()
+	saturated, depth, err := p.Saturated(context.Background())
+	if err != nil || !saturated || depth != 12 {
+		t.Errorf("fresh reading: Saturated = %v, %d, %v; want true, 12, nil", saturated, depth, err)
+	}
+
+	p.checkedAt = time.Now().Add(-depthCacheTTL)
+	if _, _, err := p.Saturated(context.Background()); err == nil {
+		t.Error("stale reading: Saturated returned no error, want a fresh Redis query")
+	}
+}
@@ -96,6 +96,16 @@ func (s *TierService) CanUseFrequency(userID uint, cronExpr string) (bool, *mode
 	return interval >= minDuration, tier, nil
 }
 
+// MeetsMinTier reports whether the user's tier satisfies a plugin's min_tier.
+// Returns (allowed bool, tier *models.AccountTier, error).
+func (s *TierService) MeetsMinTier(userID uint, minTier string) (bool, *models.AccountTier, error) {
+	tier, err := s.GetUserTier(userID)
+	if err != nil {
+		return false, nil, err
+	}
+	return plugins.TierAtLeast(tier.Name, minTier), tier, nil
+}
+
 // GetEnabledCount returns the number of currently enabled plugins for the user.
 // Used for tier UI counter display.
 func (s *TierService) GetEnabledCount(userID uint) (int, error) {
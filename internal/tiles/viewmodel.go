@@ -4,7 +4,11 @@
 // templates imported dashboard directly.
 package tiles
 
-import "time"
+import (
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/rundiff"
+)
 
 // TileViewModel holds all data needed to render a single dashboard tile.
 type TileViewModel struct {
@@ -17,7 +21,7 @@ type TileViewModel struct {
 	Enabled      bool
 
 	// Latest run data (zero values if no runs yet)
-	LatestRunStatus string     // pending/processing/completed/failed or ""
+	LatestRunStatus string     // pending/processing/completed/failed/cancelled/timed_out or ""
 	LatestRunAt     *time.Time // created_at of the latest run
 	NextRunAt       *time.Time
 	BriefingSummary string // 2-3 line summary from PluginRun.Output JSON
@@ -29,6 +33,23 @@ type TileViewModel struct {
 	LastSuccessfulContent string // Full content from last successful run
 	HasError              bool   // true if latest run is failed
 
+	// "Only show what's new" mode: WhatsNew holds the items added or changed
+	// since the previous completed run (HasPreviousRun is false for the first one).
+	WhatsNewOnly   bool
+	HasPreviousRun bool
+	WhatsNew       rundiff.Diff
+
+	// Plugin lifecycle: Unavailable plugins were removed from the plugin
+	// directory and no longer run; ScheduleOffLabel is when their schedule is
+	// (or was) paused, in the user's timezone, and ScheduleOff reports
+	// whether that has happened. Deprecated plugins still run; ReplacedBy is
+	// the display name of their successor, if any.
+	Unavailable      bool
+	ScheduleOff      bool
+	ScheduleOffLabel string
+	Deprecated       bool
+	ReplacedBy       string
+
 	// Formatted tooltip text
 	TimingTooltip string
 }
@@ -0,0 +1,159 @@
+package userwebhooks
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/dashboard"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/templates"
+	"gorm.io/gorm"
+)
+
+// render is a package-local helper for rendering Templ components in Gin handlers.
+// Duplicated from settings/handlers.go to avoid import cycles.
+func render(c *gin.Context, component templ.Component) {
+	c.Header("Content-Type", "text/html")
+	component.Render(c.Request.Context(), c.Writer)
+}
+
+// getAuthUser extracts the authenticated user from the Gin context and fetches
+// the full User record from the database.
+// Duplicated from settings/handlers.go to avoid import cycles.
+func getAuthUser(c *gin.Context, db *gorm.DB) (*models.User, error) {
+	emailVal, exists := c.Get("user_email")
+	if !exists {
+		return nil, fmt.Errorf("user_email not found in context")
+	}
+	emailStr, ok := emailVal.(string)
+	if !ok || emailStr == "" {
+		return nil, fmt.Errorf("user_email is empty")
+	}
+	var user models.User
+	if err := db.Where("email = ?", emailStr).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return &user, nil
+}
+
+// parseHookID reads the :id route parameter.
+func parseHookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// PageHandler returns a Gin handler for GET /settings/webhooks.
+// Renders the webhooks settings page with registered webhooks and recent deliveries.
+func PageHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+
+		vm := BuildViewModel(db, user.ID)
+		sidebarPlugins := dashboard.GetSidebarPlugins(db, user.ID)
+		render(c, templates.WebhooksSettingsPage(vm, sidebarPlugins))
+	}
+}
+
+// CreateHandler returns a Gin handler for POST /api/user/webhooks.
+// Registers a webhook and returns the refreshed #webhooks-section fragment,
+// including the signing secret (shown only this once).
+func CreateHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		hook, err := CreateHook(db, user.ID, c.PostForm("description"), c.PostForm("url"), c.PostFormArray("events"))
+		if err != nil {
+			slog.Warn("userwebhooks: failed to create webhook", "user_id", user.ID, "error", err)
+			vm := BuildViewModel(db, user.ID)
+			vm.Notice = err.Error()
+			vm.NoticeIsError = true
+			render(c, templates.WebhooksSection(vm))
+			return
+		}
+
+		vm := BuildViewModel(db, user.ID)
+		vm.NewSecret = hook.Secret
+		render(c, templates.WebhooksSection(vm))
+	}
+}
+
+// DeleteHandler returns a Gin handler for POST /api/user/webhooks/:id/delete.
+// Soft-deletes the webhook (scoped to the authenticated user).
+func DeleteHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		hookID, ok := parseHookID(c)
+		if !ok {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		if err := DeleteHook(db, user.ID, hookID); err != nil {
+			slog.Error("userwebhooks: failed to delete webhook", "user_id", user.ID, "webhook_id", hookID, "error", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		render(c, templates.WebhooksSection(BuildViewModel(db, user.ID)))
+	}
+}
+
+// TestHandler returns a Gin handler for POST /api/user/webhooks/:id/test.
+// Sends a test event synchronously (single attempt, no retries) so the result
+// can be shown immediately, and records it in the delivery log.
+func TestHandler(db *gorm.DB, sender *Sender) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		hookID, ok := parseHookID(c)
+		if !ok {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		hook, err := GetHook(db, user.ID, hookID)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		ev, err := NewTestEvent()
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		deliverErr := sender.Deliver(c.Request.Context(), hook, ev, 1)
+
+		vm := BuildViewModel(db, user.ID)
+		if deliverErr != nil {
+			vm.Notice = "Test event failed: " + deliverErr.Error()
+			vm.NoticeIsError = true
+		} else {
+			vm.Notice = "Test event delivered."
+		}
+		render(c, templates.WebhooksSection(vm))
+	}
+}
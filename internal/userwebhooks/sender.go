@@ -0,0 +1,219 @@
+package userwebhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/webhook"
+	"gorm.io/gorm"
+)
+
+// Headers sent with every user webhook delivery in addition to the signature
+// headers (webhook.HeaderTimestamp, webhook.HeaderSignature).
+const (
+	HeaderEvent    = "X-First-Sip-Event"
+	HeaderDelivery = "X-First-Sip-Delivery"
+)
+
+// deliveryTimeout bounds a single delivery attempt.
+const deliveryTimeout = 10 * time.Second
+
+// ErrPermanent wraps delivery failures that will not succeed on retry
+// (e.g. 4xx responses or a blocked destination).
+var ErrPermanent = errors.New("userwebhooks: permanent delivery failure")
+
+// ErrBlockedAddress is returned by clients from NewSafeClient when a request
+// resolves to a private destination.
+var ErrBlockedAddress = errors.New("destination address is not allowed")
+
+// Sender delivers signed events to user webhooks and logs every attempt in
+// webhook_deliveries.
+type Sender struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+// NewSender creates a Sender using NewSafeClient.
+func NewSender(db *gorm.DB, allowPrivateNetworks bool) *Sender {
+	return &Sender{
+		db:     db,
+		client: NewSafeClient(allowPrivateNetworks, deliveryTimeout),
+	}
+}
+
+// NewSafeClient returns an HTTP client for user-supplied URLs. Unless
+// allowPrivateNetworks is set, connections to loopback, private, link-local
+// and shared (carrier-grade NAT) addresses, including their IPv4-mapped and
+// NAT64 forms, are refused at dial time, so user URLs cannot reach internal
+// services (including via DNS rebinding). Redirects are not followed.
+func NewSafeClient(allowPrivateNetworks bool, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return ErrBlockedAddress
+			}
+			return nil
+		}
+	}
+
+	transport := &http.Transport{
+		Proxy:               nil, // never route user URLs through an environment proxy
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        20,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// Redirects are reported as the 3xx status rather than followed.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Deliver POSTs ev to hook once and records the attempt. Returns nil on a 2xx
+// response, an error wrapping ErrPermanent when retrying is pointless, and any
+// other error for transient failures that should be retried.
+func (s *Sender) Deliver(ctx context.Context, hook *models.UserWebhook, ev Event, attempt int) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("%w: marshal event: %v", ErrPermanent, err)
+	}
+
+	start := time.Now()
+	statusCode, deliverErr := s.post(ctx, hook, ev, body)
+
+	record := models.WebhookDelivery{
+		CreatedAt:     start,
+		UserWebhookID: hook.ID,
+		EventID:       ev.ID,
+		EventType:     ev.Type,
+		Attempt:       attempt,
+		StatusCode:    statusCode,
+		Success:       deliverErr == nil,
+		DurationMs:    int(time.Since(start).Milliseconds()),
+	}
+	if deliverErr != nil {
+		record.ErrorMessage = deliverErr.Error()
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		slog.Error("Failed to record webhook delivery",
+			"webhook_id", hook.ID,
+			"event_id", ev.ID,
+			"error", err,
+		)
+	}
+
+	return deliverErr
+}
+
+// post performs the signed request and classifies the outcome.
+func (s *Sender) post(ctx context.Context, hook *models.UserWebhook, ev Event, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "First-Sip-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, ev.Type)
+	req.Header.Set(HeaderDelivery, ev.ID)
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(hook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrBlockedAddress) {
+			return 0, fmt.Errorf("%w: %v", ErrPermanent, err)
+		}
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return resp.StatusCode, nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	default:
+		return resp.StatusCode, fmt.Errorf("%w: endpoint returned status %d", ErrPermanent, resp.StatusCode)
+	}
+}
+
+// blockedNets are ranges outside net.IP's classifications that still reach
+// internal services: shared address space (carrier-grade NAT, also used for
+// cloud metadata and VPN overlays), IETF protocol assignments, benchmarking
+// and reserved space.
+var blockedNets = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+)
+
+// nat64Nets are NAT64 prefixes whose last four bytes embed an IPv4 address.
+var nat64Nets = mustParseCIDRs(
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// isPrivateIP reports whether ip is loopback, private, link-local (including
+// cloud metadata at 169.254.169.254), multicast, unspecified or in
+// blockedNets. IPv4-mapped and NAT64 addresses are judged by the IPv4
+// address they embed.
+func isPrivateIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4 // also unwraps ::ffff:a.b.c.d
+	} else if len(ip) == net.IPv6len {
+		for _, n := range nat64Nets {
+			if n.Contains(ip) {
+				return isPrivateIP(net.IP(ip[12:16]))
+			}
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
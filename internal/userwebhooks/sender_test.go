@@ -0,0 +1,180 @@
+package userwebhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/webhook"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	for _, tc := range []struct {
+		ip      string
+		private bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"100.100.100.200", true},
+		{"192.0.0.192", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"64:ff9b::a9fe:a9fe", true}, // NAT64 169.254.169.254
+		{"64:ff9b::7f00:1", true},    // NAT64 127.0.0.1
+		{"64:ff9b:1::a00:1", true},   // local-use NAT64 10.0.0.1
+		{"8.8.8.8", false},
+		{"100.128.0.1", false},
+		{"192.0.2.1", false},
+		{"::ffff:8.8.8.8", false},
+		{"64:ff9b::808:808", false}, // NAT64 8.8.8.8
+		{"2606:4700:4700::1111", false},
+	} {
+		ip := net.ParseIP(tc.ip)
+		if ip == nil {
+			t.Fatalf("bad test address %q", tc.ip)
+		}
+		if got := isPrivateIP(ip); got != tc.private {
+			t.Errorf("isPrivateIP(%s) = %v, want %v", tc.ip, got, tc.private)
+		}
+	}
+}
+
+func TestSafeClientRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := NewSafeClient(false, time.Second).Get(srv.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("loopback request: err = %v, want ErrBlockedAddress", err)
+	}
+
+	resp, err := NewSafeClient(true, time.Second).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("allowPrivateNetworks: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestSafeClientDoesNotFollowRedirects(t *testing.T) {
+	var followed atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			followed.Store(true)
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	resp, err := NewSafeClient(true, time.Second).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || followed.Load() {
+		t.Errorf("status = %d, followed = %v; want the 302 reported", resp.StatusCode, followed.Load())
+	}
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.WebhookDelivery{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestDeliverSignsAndClassifiesFailures(t *testing.T) {
+	var status atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := webhook.Verify("whsec_test", r.Header.Get(webhook.HeaderTimestamp), r.Header.Get(webhook.HeaderSignature), body, webhook.DefaultSignatureTolerance, time.Now())
+		if err != nil {
+			t.Errorf("request signature invalid: %v", err)
+		}
+		if r.Header.Get(HeaderEvent) != models.WebhookEventTest || r.Header.Get(HeaderDelivery) != "evt-1" {
+			t.Errorf("event headers = %q, %q", r.Header.Get(HeaderEvent), r.Header.Get(HeaderDelivery))
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	db := openTestDB(t)
+	sender := NewSender(db, true)
+	hook := &models.UserWebhook{Model: gorm.Model{ID: 4}, URL: srv.URL, Secret: "whsec_test"}
+	ev := Event{ID: "evt-1", Type: models.WebhookEventTest, Data: []byte(`{}`)}
+
+	for i, tc := range []struct {
+		status    int
+		ok        bool
+		permanent bool
+	}{
+		{http.StatusServiceUnavailable, false, false},
+		{http.StatusTooManyRequests, false, false},
+		{http.StatusNotFound, false, true},
+		{http.StatusFound, false, true},
+		{http.StatusNoContent, true, false},
+	} {
+		status.Store(int32(tc.status))
+		err := sender.Deliver(context.Background(), hook, ev, i+1)
+		if (err == nil) != tc.ok || errors.Is(err, ErrPermanent) != tc.permanent {
+			t.Errorf("status %d: err = %v, want ok %v, permanent %v", tc.status, err, tc.ok, tc.permanent)
+		}
+	}
+
+	var log []models.WebhookDelivery
+	if err := db.Order("id").Find(&log).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 5 {
+		t.Fatalf("recorded %d deliveries, want 5", len(log))
+	}
+	first, last := log[0], log[4]
+	if first.Attempt != 1 || first.StatusCode != http.StatusServiceUnavailable || first.Success || first.ErrorMessage == "" {
+		t.Errorf("first delivery = %+v", first)
+	}
+	if last.Attempt != 5 || last.StatusCode != http.StatusNoContent || !last.Success || last.UserWebhookID != 4 || last.EventID != "evt-1" {
+		t.Errorf("last delivery = %+v", last)
+	}
+}
+
+func TestDeliverBlockedDestinationIsPermanent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer srv.Close()
+
+	db := openTestDB(t)
+	hook := &models.UserWebhook{Model: gorm.Model{ID: 1}, URL: srv.URL, Secret: "whsec_test"}
+	err := NewSender(db, false).Deliver(context.Background(), hook, Event{ID: "evt-1", Type: models.WebhookEventTest}, 1)
+	if !errors.Is(err, ErrPermanent) {
+		t.Errorf("err = %v, want ErrPermanent", err)
+	}
+}
@@ -0,0 +1,263 @@
+// Package userwebhooks manages user-registered outbound webhooks: CRUD, event
+// payloads, and signed delivery with an attempt log.
+package userwebhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/gorm"
+)
+
+// MaxWebhooksPerUser caps how many webhooks a single user may register.
+const MaxWebhooksPerUser = 5
+
+// Event is the JSON document POSTed to user webhooks.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// runEventData is the data payload of run.completed and run.failed events.
+type runEventData struct {
+	PluginRunID  string          `json:"plugin_run_id"`
+	PluginName   string          `json:"plugin_name"`
+	Status       string          `json:"status"`
+	Summary      string          `json:"summary,omitempty"`
+	Output       json.RawMessage `json:"output,omitempty"`
+	ErrorMessage string          `json:"error_message,omitempty"`
+	StartedAt    *time.Time      `json:"started_at,omitempty"`
+	CompletedAt  *time.Time      `json:"completed_at,omitempty"`
+}
+
+// briefingEventData is the data payload of briefing.ready events.
+type briefingEventData struct {
+	BriefingID  uint            `json:"briefing_id"`
+	GeneratedAt *time.Time      `json:"generated_at,omitempty"`
+	Content     json.RawMessage `json:"content"`
+}
+
+// newEvent wraps data in an Event envelope with a fresh ID.
+func newEvent(eventType string, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("userwebhooks: marshal %s event: %w", eventType, err)
+	}
+	return Event{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      raw,
+	}, nil
+}
+
+// NewRunEvent builds a run.completed or run.failed event for run. run.Plugin
+// must be loaded so the plugin name can be included.
+func NewRunEvent(eventType string, run *plugins.PluginRun) (Event, error) {
+	data := runEventData{
+		PluginRunID:  run.PluginRunID,
+		PluginName:   run.Plugin.Name,
+		Status:       run.Status,
+		ErrorMessage: run.ErrorMessage,
+		StartedAt:    run.StartedAt,
+		CompletedAt:  run.CompletedAt,
+	}
+	if len(run.Output) > 0 {
+		data.Output = json.RawMessage(run.Output)
+		var out struct {
+			Summary string `json:"summary"`
+		}
+		if json.Unmarshal(run.Output, &out) == nil {
+			data.Summary = out.Summary
+		}
+	}
+	return newEvent(eventType, data)
+}
+
+// NewBriefingEvent builds a briefing.ready event for a completed briefing.
+func NewBriefingEvent(b *models.Briefing) (Event, error) {
+	content := json.RawMessage(b.Content)
+	if len(content) == 0 {
+		content = json.RawMessage("null")
+	}
+	return newEvent(models.WebhookEventBriefingReady, briefingEventData{
+		BriefingID:  b.ID,
+		GeneratedAt: b.GeneratedAt,
+		Content:     content,
+	})
+}
+
+// NewTestEvent builds the event sent by the "Send test event" button.
+func NewTestEvent() (Event, error) {
+	return newEvent(models.WebhookEventTest, map[string]string{
+		"message": "This is a test event from First Sip.",
+	})
+}
+
+// RunEventType maps a terminal PluginRun status to its webhook event type.
+// Returns "" for statuses that do not produce an event (e.g. cancelled).
+func RunEventType(status string) string {
+	switch status {
+	case plugins.PluginRunStatusCompleted:
+		return models.WebhookEventRunCompleted
+	case plugins.PluginRunStatusFailed, plugins.PluginRunStatusTimedOut:
+		return models.WebhookEventRunFailed
+	}
+	return ""
+}
+
+// ValidateURL checks that rawURL is an absolute http(s) URL. Whether the host
+// may resolve to a private address is enforced at delivery time by the Sender.
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("enter a valid URL")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("URL must start with https:// or http://")
+	}
+	return nil
+}
+
+// CreateHook registers a webhook for a user with a newly generated signing
+// secret. Returns the created webhook; its Secret field holds the plaintext
+// secret so it can be shown to the user once.
+func CreateHook(db *gorm.DB, userID uint, description, rawURL string, events []string) (*models.UserWebhook, error) {
+	if err := ValidateURL(rawURL); err != nil {
+		return nil, err
+	}
+	subscribed := filterEventTypes(events)
+	if len(subscribed) == 0 {
+		return nil, fmt.Errorf("select at least one event")
+	}
+
+	var count int64
+	if err := db.Model(&models.UserWebhook{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("userwebhooks: count webhooks: %w", err)
+	}
+	if count >= MaxWebhooksPerUser {
+		return nil, fmt.Errorf("you can register up to %d webhooks", MaxWebhooksPerUser)
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("userwebhooks: generate secret: %w", err)
+	}
+
+	hook := models.UserWebhook{
+		UserID:      userID,
+		Description: truncate(strings.TrimSpace(description), 100),
+		URL:         strings.TrimSpace(rawURL),
+		Secret:      secret,
+		Events:      strings.Join(subscribed, ","),
+	}
+	if err := db.Create(&hook).Error; err != nil {
+		return nil, fmt.Errorf("userwebhooks: create webhook: %w", err)
+	}
+	// BeforeSave encrypted the in-memory fields; restore plaintext for the caller.
+	hook.URL = strings.TrimSpace(rawURL)
+	hook.Secret = secret
+	return &hook, nil
+}
+
+// DeleteHook soft-deletes a webhook, scoped to the owning user.
+func DeleteHook(db *gorm.DB, userID, hookID uint) error {
+	result := db.Where("id = ? AND user_id = ?", hookID, userID).Delete(&models.UserWebhook{})
+	if result.Error != nil {
+		return fmt.Errorf("userwebhooks: delete webhook: %w", result.Error)
+	}
+	return nil
+}
+
+// GetHook returns a webhook by ID. userID scopes the lookup to the owning user;
+// pass 0 for internal lookups (e.g. the delivery worker).
+func GetHook(db *gorm.DB, userID, hookID uint) (*models.UserWebhook, error) {
+	q := db.Where("id = ?", hookID)
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	var hook models.UserWebhook
+	if err := q.First(&hook).Error; err != nil {
+		return nil, fmt.Errorf("userwebhooks: fetch webhook: %w", err)
+	}
+	return &hook, nil
+}
+
+// ListHooks returns all webhooks for a user, oldest first.
+func ListHooks(db *gorm.DB, userID uint) ([]models.UserWebhook, error) {
+	var hooks []models.UserWebhook
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&hooks).Error; err != nil {
+		return nil, fmt.Errorf("userwebhooks: list webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// SubscribedHooks returns the user's webhooks subscribed to eventType.
+func SubscribedHooks(db *gorm.DB, userID uint, eventType string) ([]models.UserWebhook, error) {
+	hooks, err := ListHooks(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	subscribed := hooks[:0]
+	for _, h := range hooks {
+		if h.Subscribes(eventType) {
+			subscribed = append(subscribed, h)
+		}
+	}
+	return subscribed, nil
+}
+
+// RecentDeliveries returns up to limit most recent delivery attempts for a webhook.
+func RecentDeliveries(db *gorm.DB, hookID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := db.Where("user_webhook_id = ?", hookID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("userwebhooks: list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// filterEventTypes keeps only known, de-duplicated subscribable event types.
+func filterEventTypes(events []string) []string {
+	seen := make(map[string]bool, len(events))
+	var out []string
+	for _, known := range models.WebhookEventTypes {
+		for _, e := range events {
+			if e == known && !seen[e] {
+				seen[e] = true
+				out = append(out, e)
+			}
+		}
+	}
+	return out
+}
+
+// generateSecret returns a random signing secret with a recognisable prefix.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
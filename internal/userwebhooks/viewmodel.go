@@ -0,0 +1,75 @@
+package userwebhooks
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/userwebhooksvm"
+	"gorm.io/gorm"
+)
+
+// recentDeliveryLimit is the number of delivery attempts shown per webhook.
+const recentDeliveryLimit = 5
+
+// eventLabels maps event types to human-readable labels.
+var eventLabels = map[string]string{
+	models.WebhookEventRunCompleted:  "Plugin run completed",
+	models.WebhookEventRunFailed:     "Plugin run failed",
+	models.WebhookEventBriefingReady: "Daily briefing ready",
+	models.WebhookEventTest:          "Test event",
+}
+
+// BuildViewModel constructs a WebhooksPageViewModel for the given user.
+func BuildViewModel(db *gorm.DB, userID uint) userwebhooksvm.WebhooksPageViewModel {
+	hooks, _ := ListHooks(db, userID)
+
+	vm := userwebhooksvm.WebhooksPageViewModel{
+		Webhooks:    make([]userwebhooksvm.WebhookViewModel, 0, len(hooks)),
+		AtLimit:     len(hooks) >= MaxWebhooksPerUser,
+		MaxWebhooks: MaxWebhooksPerUser,
+	}
+	for _, t := range models.WebhookEventTypes {
+		vm.EventOptions = append(vm.EventOptions, userwebhooksvm.EventOption{Type: t, Label: eventLabels[t]})
+	}
+
+	for _, h := range hooks {
+		hvm := userwebhooksvm.WebhookViewModel{
+			ID:          h.ID,
+			Description: h.Description,
+			MaskedURL:   maskURL(h.URL),
+		}
+		for _, e := range strings.Split(h.Events, ",") {
+			if label, ok := eventLabels[e]; ok {
+				hvm.Events = append(hvm.Events, label)
+			}
+		}
+		deliveries, _ := RecentDeliveries(db, h.ID, recentDeliveryLimit)
+		for _, d := range deliveries {
+			hvm.Deliveries = append(hvm.Deliveries, userwebhooksvm.DeliveryViewModel{
+				EventType:  d.EventType,
+				When:       d.CreatedAt.Format("Jan 2, 3:04 PM"),
+				Attempt:    d.Attempt,
+				StatusCode: d.StatusCode,
+				Success:    d.Success,
+				Error:      d.ErrorMessage,
+			})
+		}
+		vm.Webhooks = append(vm.Webhooks, hvm)
+	}
+
+	return vm
+}
+
+// maskURL reduces a webhook URL to scheme://host/… for display.
+func maskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	masked := u.Scheme + "://" + u.Host
+	if u.Path != "" && u.Path != "/" {
+		masked += "/…"
+	}
+	return masked
+}
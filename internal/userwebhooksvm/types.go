@@ -0,0 +1,44 @@
+// Package userwebhooksvm contains view model types for the webhooks settings page.
+// It is a leaf package (no internal imports) so that the templates package can
+// import it without creating an import cycle with the userwebhooks package.
+package userwebhooksvm
+
+// DeliveryViewModel is the display model for one delivery attempt.
+type DeliveryViewModel struct {
+	EventType  string
+	When       string // formatted timestamp
+	Attempt    int
+	StatusCode int
+	Success    bool
+	Error      string
+}
+
+// WebhookViewModel is the display model for a registered webhook.
+// The URL is masked to scheme + host — full URLs may embed credentials.
+type WebhookViewModel struct {
+	ID          uint
+	Description string
+	MaskedURL   string
+	Events      []string
+	Deliveries  []DeliveryViewModel
+}
+
+// EventOption is a subscribable event type with its display label.
+type EventOption struct {
+	Type  string
+	Label string
+}
+
+// WebhooksPageViewModel is the view model passed to the WebhooksSettingsPage template.
+type WebhooksPageViewModel struct {
+	Webhooks     []WebhookViewModel
+	EventOptions []EventOption
+	AtLimit      bool
+	MaxWebhooks  int
+
+	// NewSecret is shown once, immediately after a webhook is created.
+	NewSecret string
+	// Notice is a one-off status message (e.g. test event result).
+	Notice        string
+	NoticeIsError bool
+}
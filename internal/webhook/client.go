@@ -4,35 +4,78 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 )
 
-// Client handles communication with the n8n webhook for briefing generation
+// Retry defaults for outbound requests. Only network errors and 5xx responses
+// are retried; 4xx responses are returned immediately.
+const (
+	defaultMaxAttempts = 3
+	defaultBaseBackoff = 500 * time.Millisecond
+	maxBackoff         = 10 * time.Second
+)
+
+// maxResponseBytes bounds how much of a webhook response is read.
+const maxResponseBytes = 1 << 20
+
+// Client handles communication with the n8n webhook for briefing generation.
+//
+// Requests are signed with HMAC-SHA256 over the timestamp and body (see Sign),
+// retried with exponential backoff on 5xx and network errors, and guarded by a
+// per-endpoint circuit breaker. Responses are validated against the briefing
+// content schema before being decoded.
 type Client struct {
-	baseURL    string
-	secret     string
-	httpClient *http.Client
-	stubMode   bool
+	baseURL     string
+	secret      string
+	httpClient  *http.Client
+	stubMode    bool
+	maxAttempts int
+	baseBackoff time.Duration
+	now         func() time.Time
+
+	// legacySecretHeader also sends the secret in HeaderLegacySecret.
+	legacySecretHeader bool
+
+	breakersMu sync.Mutex
+	breakers   map[string]*circuitBreaker
 }
 
 // NewClient creates a new webhook client with the given configuration
 func NewClient(baseURL, secret string, stubMode bool) *Client {
 	return &Client{
-		baseURL:    baseURL,
-		secret:     secret,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
-		stubMode:   stubMode,
+		baseURL:     baseURL,
+		secret:      secret,
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		stubMode:    stubMode,
+		maxAttempts: defaultMaxAttempts,
+		baseBackoff: defaultBaseBackoff,
+		now:         time.Now,
+		breakers:    make(map[string]*circuitBreaker),
+
+		legacySecretHeader: true,
 	}
 }
 
+// SetLegacySecretHeader controls whether requests also carry the secret in
+// the deprecated HeaderLegacySecret. It is on by default.
+func (c *Client) SetLegacySecretHeader(enabled bool) {
+	c.legacySecretHeader = enabled
+}
+
 // GenerateBriefing requests briefing content generation for the specified user
 func (c *Client) GenerateBriefing(ctx context.Context, userID uint) (*BriefingContent, error) {
 	if c.stubMode {
 		// Return hardcoded mock data with simulated processing delay
-		time.Sleep(2 * time.Second)
+		if err := sleepContext(ctx, 2*time.Second); err != nil {
+			return nil, err
+		}
 		return &BriefingContent{
 			News: []NewsItem{
 				{
@@ -76,13 +119,97 @@ func (c *Client) GenerateBriefing(ctx context.Context, userID uint) (*BriefingCo
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/generate", bytes.NewBuffer(jsonData))
+	body, err := c.post(ctx, c.baseURL+"/generate", jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateBriefingContent(body); err != nil {
+		return nil, err
+	}
+
+	var content BriefingContent
+	if err := json.Unmarshal(body, &content); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &content, nil
+}
+
+// statusError is a non-2xx webhook response.
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d: %s", e.code, e.body)
+}
+
+// retryable reports whether the response status warrants another attempt.
+func (e *statusError) retryable() bool {
+	return e.code >= 500
+}
+
+// post sends a signed JSON POST to url and returns the 2xx response body,
+// retrying and tracking endpoint health as described on Client.
+func (c *Client) post(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	breaker := c.breakerFor(url)
+
+	var lastErr error
+	for attempt := 1; attempt <= c.maxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := sleepContext(ctx, c.backoff(attempt-1)); err != nil {
+				return nil, err
+			}
+		}
+
+		if !breaker.allow() {
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w: %s (last error: %v)", ErrCircuitOpen, url, lastErr)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, url)
+		}
+
+		body, err := c.doSigned(ctx, url, payload)
+		if err == nil {
+			breaker.success()
+			return body, nil
+		}
+
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && !statusErr.retryable() {
+			// The endpoint answered; a 4xx is the caller's problem, not an outage.
+			breaker.success()
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			// Cancelled by the caller — not the endpoint's fault.
+			breaker.abort()
+			return nil, err
+		}
+
+		breaker.failure()
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("webhook failed after %d attempts: %w", c.maxAttempts, lastErr)
+}
+
+// doSigned performs a single signed POST attempt.
+func (c *Client) doSigned(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("X-N8N-SECRET", c.secret)
+	timestamp := c.now().Unix()
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(c.secret, timestamp, payload))
+	if c.legacySecretHeader {
+		req.Header.Set(HeaderLegacySecret, c.secret)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -90,15 +217,47 @@ func (c *Client) GenerateBriefing(ctx context.Context, userID uint) (*BriefingCo
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(body))
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	var content BriefingContent
-	if err := json.NewDecoder(resp.Body).Decode(&content); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &statusError{code: resp.StatusCode, body: string(body)}
 	}
+	return body, nil
+}
 
-	return &content, nil
+// breakerFor returns the circuit breaker for an endpoint, creating it on first use.
+func (c *Client) breakerFor(url string) *circuitBreaker {
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+	b, ok := c.breakers[url]
+	if !ok {
+		b = newCircuitBreaker(breakerFailureThreshold, breakerCooldown, c.now)
+		c.breakers[url] = b
+	}
+	return b
+}
+
+// backoff returns the delay before retry n (1-based): base * 2^(n-1), capped at
+// maxBackoff, with up to 50% random jitter so retries from many workers spread out.
+func (c *Client) backoff(n int) time.Duration {
+	d := c.baseBackoff << (n - 1)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int64N(int64(d/2)+1))
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const validBody = `{"news":[{"title":"Headline"}],"weather":{"location":"NYC","temperature":50,"condition":"Clear"},"work":{}}`
+
+func newTestClient(url string) *Client {
+	c := NewClient(url, "test-secret", false)
+	c.baseBackoff = time.Millisecond
+	return c
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"user_id":1}`)
+	sig := Sign("secret", now.Unix(), body)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	if err := Verify("secret", ts, sig, body, DefaultSignatureTolerance, now); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := Verify("other", ts, sig, body, DefaultSignatureTolerance, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("wrong secret: got %v, want ErrInvalidSignature", err)
+	}
+	if err := Verify("secret", ts, sig, []byte(`{"user_id":2}`), DefaultSignatureTolerance, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered body: got %v, want ErrInvalidSignature", err)
+	}
+	if err := Verify("secret", ts, sig, body, DefaultSignatureTolerance, now.Add(10*time.Minute)); !errors.Is(err, ErrStaleTimestamp) {
+		t.Errorf("replayed request: got %v, want ErrStaleTimestamp", err)
+	}
+}
+
+func TestGenerateBriefingSignsAndRetries5xx(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := Verify("test-secret", r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, DefaultSignatureTolerance, time.Now())
+		if err != nil {
+			t.Errorf("request signature invalid: %v", err)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(validBody))
+	}))
+	defer srv.Close()
+
+	content, err := newTestClient(srv.URL).GenerateBriefing(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GenerateBriefing: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+	if len(content.News) != 1 || content.Weather.Location != "NYC" {
+		t.Errorf("unexpected content: %+v", content)
+	}
+}
+
+func TestLegacySecretHeader(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(HeaderLegacySecret))
+		_, _ = w.Write([]byte(validBody))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	if _, err := c.GenerateBriefing(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	c.SetLegacySecretHeader(false)
+	if _, err := c.GenerateBriefing(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "test-secret" || got[1] != "" {
+		t.Errorf("%s values = %q, want [test-secret \"\"]", HeaderLegacySecret, got)
+	}
+}
+
+func TestGenerateBriefingDoesNotRetry4xx(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	if _, err := newTestClient(srv.URL).GenerateBriefing(context.Background(), 1); err == nil {
+		t.Fatal("expected error for 401")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestCircuitBreakerOpensAfterRepeatedFailures(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	for i := 0; i < 2; i++ {
+		_, _ = c.GenerateBriefing(context.Background(), 1)
+	}
+	before := calls.Load()
+
+	_, err := c.GenerateBriefing(context.Background(), 1)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+	if calls.Load() != before {
+		t.Errorf("open circuit still sent %d request(s)", calls.Load()-before)
+	}
+}
+
+func TestGenerateBriefingRejectsInvalidResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"news":"not a list"}`))
+	}))
+	defer srv.Close()
+
+	_, err := newTestClient(srv.URL).GenerateBriefing(context.Background(), 1)
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("got %v, want ErrInvalidResponse", err)
+	}
+}
+
+func TestStubModeHonoursContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewClient("", "", true).GenerateBriefing(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
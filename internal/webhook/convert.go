@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// pluginOutput mirrors the plugin_runs.output JSONB shape
+// (see dashboard.PluginRunOutput) without importing the dashboard package.
+type pluginOutput struct {
+	Summary  string          `json:"summary"`
+	Sections []pluginSection `json:"sections"`
+}
+
+type pluginSection struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// ToPluginOutput converts the fixed News/Weather/Work briefing shape into the
+// summary + sections JSON stored on plugin runs. Section content is HTML, so all
+// n8n-provided text is escaped.
+func ToPluginOutput(content *BriefingContent) ([]byte, error) {
+	if content == nil {
+		return nil, fmt.Errorf("webhook: nil briefing content")
+	}
+
+	out := pluginOutput{Summary: briefingSummary(content)}
+
+	if len(content.News) > 0 {
+		var sb strings.Builder
+		for i, item := range content.News {
+			if i > 0 {
+				sb.WriteString("<br><br>")
+			}
+			if item.URL != "" && (strings.HasPrefix(item.URL, "https://") || strings.HasPrefix(item.URL, "http://")) {
+				fmt.Fprintf(&sb, `<a href="%s" target="_blank" rel="noopener noreferrer"><strong>%s</strong></a>`,
+					html.EscapeString(item.URL), html.EscapeString(item.Title))
+			} else {
+				fmt.Fprintf(&sb, "<strong>%s</strong>", html.EscapeString(item.Title))
+			}
+			if item.Summary != "" {
+				sb.WriteString("<br>")
+				sb.WriteString(html.EscapeString(item.Summary))
+			}
+		}
+		out.Sections = append(out.Sections, pluginSection{Title: "News", Content: sb.String()})
+	}
+
+	if hasWeather(content.Weather) {
+		text := weatherText(content.Weather)
+		if content.Weather.Location != "" {
+			text = content.Weather.Location + ": " + text
+		}
+		out.Sections = append(out.Sections, pluginSection{Title: "Weather", Content: html.EscapeString(text)})
+	}
+
+	if len(content.Work.TodayEvents) > 0 {
+		out.Sections = append(out.Sections, pluginSection{Title: "Today", Content: htmlLines(content.Work.TodayEvents)})
+	}
+	if len(content.Work.TomorrowTasks) > 0 {
+		out.Sections = append(out.Sections, pluginSection{Title: "Tomorrow", Content: htmlLines(content.Work.TomorrowTasks)})
+	}
+
+	return json.Marshal(out)
+}
+
+// briefingSummary builds a one-line summary for the dashboard tile.
+func briefingSummary(content *BriefingContent) string {
+	var parts []string
+	if w := content.Weather; hasWeather(w) {
+		weather := fmt.Sprintf("%d°F", w.Temperature)
+		if w.Condition != "" {
+			weather += " and " + strings.ToLower(w.Condition)
+		}
+		parts = append(parts, weather)
+	}
+	if n := len(content.News); n > 0 {
+		parts = append(parts, pluralize(n, "headline"))
+	}
+	if n := len(content.Work.TodayEvents); n > 0 {
+		parts = append(parts, pluralize(n, "event")+" today")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// hasWeather reports whether n8n sent weather data. A location alone is not
+// weather, and an empty object decodes to a temperature of 0.
+func hasWeather(w WeatherInfo) bool {
+	return w.Condition != "" || w.Temperature != 0
+}
+
+// weatherText formats the temperature and condition, e.g. "65°F, Sunny".
+func weatherText(w WeatherInfo) string {
+	text := fmt.Sprintf("%d°F", w.Temperature)
+	if w.Condition != "" {
+		text += ", " + w.Condition
+	}
+	return text
+}
+
+// htmlLines escapes each line and joins them with <br>.
+func htmlLines(lines []string) string {
+	escaped := make([]string, len(lines))
+	for i, l := range lines {
+		escaped[i] = html.EscapeString(l)
+	}
+	return strings.Join(escaped, "<br>")
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func convert(t *testing.T, content *BriefingContent) pluginOutput {
+	t.Helper()
+	raw, err := ToPluginOutput(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out pluginOutput
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestToPluginOutput(t *testing.T) {
+	out := convert(t, &BriefingContent{
+		News: []NewsItem{
+			{Title: "Chips <rally>", Summary: "Up 4% & climbing", URL: "https://example.com/a?b=1&c=2"},
+			{Title: "No link", URL: "javascript:alert(1)"},
+		},
+		Weather: WeatherInfo{Location: "NYC", Temperature: 50, Condition: "Clear"},
+		Work:    WorkSummary{TodayEvents: []string{"Standup <9am>"}, TomorrowTasks: []string{"Ship"}},
+	})
+
+	if out.Summary != "50°F and clear · 2 headlines · 1 event today" {
+		t.Errorf("summary = %q", out.Summary)
+	}
+	var titles []string
+	for _, s := range out.Sections {
+		titles = append(titles, s.Title)
+	}
+	if strings.Join(titles, ",") != "News,Weather,Today,Tomorrow" {
+		t.Fatalf("sections = %v", titles)
+	}
+
+	news := out.Sections[0].Content
+	for _, want := range []string{
+		`<a href="https://example.com/a?b=1&amp;c=2" target="_blank" rel="noopener noreferrer"><strong>Chips &lt;rally&gt;</strong></a>`,
+		"Up 4% &amp; climbing",
+		"<strong>No link</strong>",
+	} {
+		if !strings.Contains(news, want) {
+			t.Errorf("news section missing %q:\n%s", want, news)
+		}
+	}
+	if strings.Contains(news, "javascript:") {
+		t.Errorf("news section links a non-http URL:\n%s", news)
+	}
+	if got := out.Sections[1].Content; got != "NYC: 50°F, Clear" {
+		t.Errorf("weather = %q", got)
+	}
+	if got := out.Sections[2].Content; got != "Standup &lt;9am&gt;" {
+		t.Errorf("today = %q", got)
+	}
+}
+
+func TestToPluginOutputWithoutWeather(t *testing.T) {
+	for name, weather := range map[string]WeatherInfo{
+		"empty":         {},
+		"location only": {Location: "NYC"},
+	} {
+		out := convert(t, &BriefingContent{News: []NewsItem{{Title: "Headline"}}, Weather: weather})
+		if out.Summary != "1 headline" {
+			t.Errorf("%s: summary = %q, want no weather", name, out.Summary)
+		}
+		if len(out.Sections) != 1 || out.Sections[0].Title != "News" {
+			t.Errorf("%s: sections = %+v, want news only", name, out.Sections)
+		}
+	}
+
+	out := convert(t, &BriefingContent{Weather: WeatherInfo{Temperature: -3}})
+	if out.Summary != "-3°F" || len(out.Sections) != 1 || out.Sections[0].Content != "-3°F" {
+		t.Errorf("temperature only: %+v", out)
+	}
+
+	if _, err := ToPluginOutput(nil); err == nil {
+		t.Error("expected an error for nil content")
+	}
+}
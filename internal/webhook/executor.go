@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/jimdaga/first-sip/internal/plugins"
+)
+
+// N8NExecutor runs plugins with runtime: n8n by calling the legacy n8n briefing
+// workflow and converting its News/Weather/Work response into plugin output.
+// It lets the n8n path run through plugin:execute and the PluginRun lifecycle
+// instead of briefing:generate.
+type N8NExecutor struct {
+	client *Client
+}
+
+// NewN8NExecutor creates an N8NExecutor backed by client.
+func NewN8NExecutor(client *Client) *N8NExecutor {
+	return &N8NExecutor{client: client}
+}
+
+// Execute implements plugins.Executor.
+func (e *N8NExecutor) Execute(ctx context.Context, req plugins.ExecutionRequest) (*plugins.RunResult, error) {
+	content, err := e.client.GenerateBriefing(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	output, err := ToPluginOutput(content)
+	if err != nil {
+		return nil, err
+	}
+	return &plugins.RunResult{Status: plugins.PluginRunStatusCompleted, Output: string(output)}, nil
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature headers sent with every outbound webhook request.
+const (
+	HeaderTimestamp = "X-First-Sip-Timestamp"
+	HeaderSignature = "X-First-Sip-Signature"
+)
+
+// HeaderLegacySecret carries the shared secret in plain text, as requests did
+// before they were signed. It is still sent by default so n8n workflows that
+// check it keep working while they move to Verify; turn it off with
+// N8N_LEGACY_SECRET_HEADER=false once they have.
+//
+// Deprecated: check HeaderTimestamp and HeaderSignature instead.
+const HeaderLegacySecret = "X-N8N-SECRET"
+
+// signaturePrefix identifies the signing scheme in the signature header.
+const signaturePrefix = "sha256="
+
+// DefaultSignatureTolerance is the maximum clock skew Verify accepts.
+const DefaultSignatureTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned by Verify when the signature does not match.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	// ErrStaleTimestamp is returned by Verify when the timestamp is outside the tolerance.
+	ErrStaleTimestamp = errors.New("webhook: timestamp outside tolerance")
+)
+
+// Sign returns the signature header value for body sent at timestamp (Unix
+// seconds): "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+// Including the timestamp in the MAC lets receivers reject replayed requests.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the timestamp and signature header values of a received
+// request against body. Receivers (e.g. the n8n workflow) should call the
+// equivalent of this before trusting a request.
+func Verify(secret, timestampHeader, signatureHeader string, body []byte, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+	skew := now.Sub(time.Unix(ts, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > tolerance {
+		return ErrStaleTimestamp
+	}
+	if !strings.HasPrefix(signatureHeader, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	expected := Sign(secret, ts, body)
+	if !hmac.Equal([]byte(expected), []byte(signatureHeader)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kaptinlin/jsonschema"
+)
+
+// ErrInvalidResponse is returned when an n8n response does not match the
+// briefing content schema.
+var ErrInvalidResponse = errors.New("webhook: response failed schema validation")
+
+//go:embed schema/briefing_content.schema.json
+var briefingContentSchemaJSON []byte
+
+var (
+	briefingSchemaOnce sync.Once
+	briefingSchema     *jsonschema.Schema
+	briefingSchemaErr  error
+)
+
+// validateBriefingContent checks a raw n8n response body against the embedded
+// briefing content schema before it is decoded.
+func validateBriefingContent(body []byte) error {
+	briefingSchemaOnce.Do(func() {
+		briefingSchema, briefingSchemaErr = jsonschema.NewCompiler().Compile(briefingContentSchemaJSON)
+	})
+	if briefingSchemaErr != nil {
+		return fmt.Errorf("webhook: compile briefing content schema: %w", briefingSchemaErr)
+	}
+
+	result := briefingSchema.ValidateJSON(body)
+	if result.IsValid() {
+		return nil
+	}
+
+	detailed := result.DetailedErrors()
+	paths := make([]string, 0, len(detailed))
+	for path := range detailed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	msgs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		msgs = append(msgs, path+": "+detailed[path])
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidResponse, strings.Join(msgs, "; "))
+}
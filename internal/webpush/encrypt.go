@@ -0,0 +1,119 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// recordSize is the aes128gcm record size advertised in the header. Payloads
+// are sent as a single record, so it only needs to exceed the payload size.
+const recordSize = 4096
+
+// maxPayload keeps the single encrypted record within recordSize
+// (16-byte tag + 1-byte delimiter) and under push service limits.
+const maxPayload = 3000
+
+// ErrPayloadTooLarge is returned when a notification does not fit one record.
+var ErrPayloadTooLarge = errors.New("webpush: payload too large")
+
+// encrypt encrypts plaintext for a subscription per RFC 8291 using the
+// aes128gcm content coding (RFC 8188). p256dh and auth are the subscription's
+// decoded keys. The result is the complete request body.
+func encrypt(plaintext, p256dh, auth []byte) ([]byte, error) {
+	if len(plaintext) > maxPayload {
+		return nil, ErrPayloadTooLarge
+	}
+
+	uaPublic, err := ecdh.P256().NewPublicKey(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("webpush: invalid p256dh key: %w", err)
+	}
+	if len(auth) != 16 {
+		return nil, fmt.Errorf("webpush: invalid auth secret length %d", len(auth))
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("webpush: generate ephemeral key: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("webpush: generate salt: %w", err)
+	}
+
+	return encryptRecord(plaintext, uaPublic, auth, asPrivate, salt)
+}
+
+// encryptRecord encrypts plaintext with a given ephemeral key and salt. It is
+// split from encrypt so tests can check the RFC 8291 Appendix A vector.
+func encryptRecord(plaintext []byte, uaPublic *ecdh.PublicKey, auth []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	p256dh := uaPublic.Bytes()
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	cek, nonce, err := deriveKeys(asPrivate, uaPublic, auth, salt, p256dh, asPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single (last) record: plaintext followed by the 0x02 delimiter, no padding.
+	record := append(append([]byte{}, plaintext...), 0x02)
+	ciphertext := gcm.Seal(nil, nonce, record, nil)
+
+	// Header: salt (16) | rs (4) | idlen (1) | keyid (sender public key, 65).
+	body := make([]byte, 0, 16+4+1+len(asPublic)+len(ciphertext))
+	body = append(body, salt...)
+	body = binary.BigEndian.AppendUint32(body, recordSize)
+	body = append(body, byte(len(asPublic)))
+	body = append(body, asPublic...)
+	body = append(body, ciphertext...)
+	return body, nil
+}
+
+// deriveKeys computes the content encryption key and nonce (RFC 8291 §3.4).
+// priv/peer are this side's private key and the other side's public key, so
+// the same function serves encryption and (in tests) decryption.
+func deriveKeys(priv *ecdh.PrivateKey, peer *ecdh.PublicKey, auth, salt, uaPublic, asPublic []byte) (cek, nonce []byte, err error) {
+	secret, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("webpush: ecdh: %w", err)
+	}
+
+	prkKey, err := hkdf.Extract(sha256.New, secret, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyInfo := "WebPush: info\x00" + string(uaPublic) + string(asPublic)
+	ikm, err := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cek, err = hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16); err != nil {
+		return nil, nil, err
+	}
+	if nonce, err = hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12); err != nil {
+		return nil, nil, err
+	}
+	return cek, nonce, nil
+}
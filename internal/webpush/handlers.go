@@ -0,0 +1,86 @@
+package webpush
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimdaga/first-sip/internal/models"
+	"gorm.io/gorm"
+)
+
+// getAuthUser extracts the authenticated user from the Gin context and fetches
+// the full User record from the database.
+// Duplicated from settings/handlers.go to avoid import cycles.
+func getAuthUser(c *gin.Context, db *gorm.DB) (*models.User, error) {
+	emailVal, exists := c.Get("user_email")
+	if !exists {
+		return nil, fmt.Errorf("user_email not found in context")
+	}
+	emailStr, ok := emailVal.(string)
+	if !ok || emailStr == "" {
+		return nil, fmt.Errorf("user_email is empty")
+	}
+	var user models.User
+	if err := db.Where("email = ?", emailStr).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return &user, nil
+}
+
+// SubscribeHandler returns a Gin handler for POST /api/user/push/subscriptions.
+// Body: the browser's PushSubscription JSON.
+func SubscribeHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		var in SubscriptionInput
+		if err := c.ShouldBindJSON(&in); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription"})
+			return
+		}
+		if err := in.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := SaveSubscription(db, user.ID, in, c.Request.UserAgent()); err != nil {
+			slog.Error("webpush: failed to save subscription", "user_id", user.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save subscription"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// UnsubscribeHandler returns a Gin handler for POST /api/user/push/subscriptions/delete.
+// Body: {"endpoint": "..."}.
+func UnsubscribeHandler(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := getAuthUser(c, db)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		var in struct {
+			Endpoint string `json:"endpoint"`
+		}
+		if err := c.ShouldBindJSON(&in); err != nil || in.Endpoint == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "endpoint is required"})
+			return
+		}
+
+		if err := DeleteSubscription(db, user.ID, in.Endpoint); err != nil {
+			slog.Error("webpush: failed to delete subscription", "user_id", user.ID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete subscription"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,121 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/userwebhooks"
+	"gorm.io/gorm"
+)
+
+// defaultTTL is how long the push service keeps an undelivered message (seconds).
+const defaultTTL = 24 * 60 * 60
+
+// deliveryTimeout bounds a single push request.
+const deliveryTimeout = 10 * time.Second
+
+var (
+	// ErrPermanent wraps push failures that will not succeed on retry.
+	ErrPermanent = errors.New("webpush: permanent delivery failure")
+
+	// ErrSubscriptionGone is returned (wrapping ErrPermanent) when the push
+	// service reports the subscription expired or was revoked. The
+	// subscription is deleted.
+	ErrSubscriptionGone = fmt.Errorf("%w: subscription gone", ErrPermanent)
+)
+
+// Notification is the JSON payload delivered to the service worker
+// (static/sw.js), which shows it with showNotification.
+type Notification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"` // opened on click
+	Tag   string `json:"tag"` // replaces an earlier notification with the same tag
+}
+
+// Sender delivers encrypted, VAPID-signed push messages.
+type Sender struct {
+	db      *gorm.DB
+	client  *http.Client
+	keys    VAPIDKeys
+	subject string
+}
+
+// NewSender creates a Sender. subject is the VAPID contact (mailto: or https:
+// URL) push services use to reach the operator.
+func NewSender(db *gorm.DB, keys VAPIDKeys, subject string, allowPrivateNetworks bool) *Sender {
+	return NewSenderWithClient(db, keys, subject, userwebhooks.NewSafeClient(allowPrivateNetworks, deliveryTimeout))
+}
+
+// NewSenderWithClient creates a Sender with a caller-supplied HTTP client.
+func NewSenderWithClient(db *gorm.DB, keys VAPIDKeys, subject string, client *http.Client) *Sender {
+	return &Sender{db: db, client: client, keys: keys, subject: subject}
+}
+
+// Send pushes n to one subscription. Returns nil on success, an error
+// wrapping ErrPermanent when retrying is pointless, and any other error for
+// transient failures.
+func (s *Sender) Send(ctx context.Context, sub *models.PushSubscription, n Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("%w: marshal notification: %v", ErrPermanent, err)
+	}
+	p256dh, err := decodeB64(sub.P256dh)
+	if err != nil {
+		return fmt.Errorf("%w: decode p256dh: %v", ErrPermanent, err)
+	}
+	auth, err := decodeB64(sub.Auth)
+	if err != nil {
+		return fmt.Errorf("%w: decode auth: %v", ErrPermanent, err)
+	}
+	body, err := encrypt(payload, p256dh, auth)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+	authorization, err := vapidAuthorization(sub.Endpoint, s.subject, s.keys, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(defaultTTL))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if errors.Is(err, userwebhooks.ErrBlockedAddress) {
+			return fmt.Errorf("%w: %v", ErrPermanent, err)
+		}
+		return fmt.Errorf("push request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		if err := s.db.WithContext(ctx).Delete(&models.PushSubscription{}, sub.ID).Error; err != nil {
+			return fmt.Errorf("webpush: delete expired subscription: %w", err)
+		}
+		return ErrSubscriptionGone
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("push service returned status %d: %s", resp.StatusCode, respBody)
+	default:
+		return fmt.Errorf("%w: push service returned status %d: %s", ErrPermanent, resp.StatusCode, respBody)
+	}
+}
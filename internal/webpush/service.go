@@ -0,0 +1,121 @@
+package webpush
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxSubscriptionsPerUser caps stored devices; the oldest are replaced first.
+const MaxSubscriptionsPerUser = 10
+
+// SubscriptionInput is the JSON produced by PushSubscription.toJSON() in the browser.
+type SubscriptionInput struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// Validate checks the endpoint and key encodings.
+func (in SubscriptionInput) Validate() error {
+	u, err := url.Parse(in.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" || len(in.Endpoint) > 2048 {
+		return errors.New("invalid push endpoint")
+	}
+	if k, err := decodeB64(in.Keys.P256dh); err != nil || len(k) != 65 {
+		return errors.New("invalid p256dh key")
+	}
+	if k, err := decodeB64(in.Keys.Auth); err != nil || len(k) != 16 {
+		return errors.New("invalid auth secret")
+	}
+	return nil
+}
+
+// SaveSubscription stores (or refreshes) a device subscription for userID.
+// Endpoints are unique: re-subscribing the same browser updates its keys and owner.
+func SaveSubscription(db *gorm.DB, userID uint, in SubscriptionInput, userAgent string) error {
+	if err := in.Validate(); err != nil {
+		return err
+	}
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	sub := models.PushSubscription{
+		UserID:    userID,
+		Endpoint:  in.Endpoint,
+		P256dh:    in.Keys.P256dh,
+		Auth:      in.Keys.Auth,
+		UserAgent: userAgent,
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "endpoint"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_id", "p256dh", "auth", "user_agent", "updated_at"}),
+		}).Create(&sub).Error; err != nil {
+			return fmt.Errorf("webpush: save subscription: %w", err)
+		}
+
+		// Trim to the newest MaxSubscriptionsPerUser devices.
+		if err := tx.Where("user_id = ? AND id NOT IN (?)", userID,
+			tx.Model(&models.PushSubscription{}).Select("id").
+				Where("user_id = ?", userID).
+				Order("updated_at DESC").
+				Limit(MaxSubscriptionsPerUser),
+		).Delete(&models.PushSubscription{}).Error; err != nil {
+			return fmt.Errorf("webpush: trim subscriptions: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteSubscription removes a device subscription owned by userID.
+func DeleteSubscription(db *gorm.DB, userID uint, endpoint string) error {
+	if err := db.Where("user_id = ? AND endpoint = ?", userID, endpoint).
+		Delete(&models.PushSubscription{}).Error; err != nil {
+		return fmt.Errorf("webpush: delete subscription: %w", err)
+	}
+	return nil
+}
+
+// ListSubscriptions returns all device subscriptions for userID.
+func ListSubscriptions(db *gorm.DB, userID uint) ([]models.PushSubscription, error) {
+	var subs []models.PushSubscription
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("webpush: list subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// NotificationFromRun builds the notification for a completed run.
+// run.Plugin must be loaded.
+func NotificationFromRun(run *plugins.PluginRun) Notification {
+	title := plugins.HumanizeName(run.Plugin.Name)
+	if run.Plugin.Icon != "" {
+		title = run.Plugin.Icon + " " + title
+	}
+	body := "Your latest results are ready."
+	var out struct {
+		Summary string `json:"summary"`
+	}
+	if json.Unmarshal(run.Output, &out) == nil && out.Summary != "" {
+		body = out.Summary
+		if r := []rune(body); len(r) > 200 {
+			body = string(r[:199]) + "…"
+		}
+	}
+	return Notification{
+		Title: title,
+		Body:  body,
+		URL:   "/plugins/" + run.Plugin.Name,
+		Tag:   "plugin-" + run.Plugin.Name,
+	}
+}
@@ -0,0 +1,237 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimdaga/first-sip/internal/models"
+)
+
+// decrypt reverses encrypt from the user agent's side.
+func decrypt(t *testing.T, body []byte, uaPrivate *ecdh.PrivateKey, auth []byte) []byte {
+	t.Helper()
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != recordSize {
+		t.Fatalf("record size = %d, want %d", rs, recordSize)
+	}
+	idlen := int(body[20])
+	asPublic := body[21 : 21+idlen]
+	ciphertext := body[21+idlen:]
+
+	peer, err := ecdh.P256().NewPublicKey(asPublic)
+	if err != nil {
+		t.Fatalf("parse sender key: %v", err)
+	}
+	cek, nonce, err := deriveKeys(uaPrivate, peer, auth, salt, uaPrivate.PublicKey().Bytes(), asPublic)
+	if err != nil {
+		t.Fatalf("deriveKeys: %v", err)
+	}
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	record, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("gcm open: %v", err)
+	}
+	if record[len(record)-1] != 0x02 {
+		t.Fatalf("missing last-record delimiter")
+	}
+	return record[:len(record)-1]
+}
+
+func newSubscriptionKeys(t *testing.T) (*ecdh.PrivateKey, []byte) {
+	t.Helper()
+	priv, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return priv, auth
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	uaPrivate, auth := newSubscriptionKeys(t)
+	plaintext := []byte(`{"title":"Daily News","body":"Three stories"}`)
+
+	body, err := encrypt(plaintext, uaPrivate.PublicKey().Bytes(), auth)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if got := decrypt(t, body, uaPrivate, auth); !bytes.Equal(got, plaintext) {
+		t.Errorf("decrypted = %q, want %q", got, plaintext)
+	}
+
+	if _, err := encrypt(make([]byte, maxPayload+1), uaPrivate.PublicKey().Bytes(), auth); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("oversized payload error = %v, want ErrPayloadTooLarge", err)
+	}
+}
+
+// TestEncryptRFC8291Vector checks the worked example in RFC 8291 Appendix A:
+// fixed keys, auth secret and salt must produce the published message body.
+func TestEncryptRFC8291Vector(t *testing.T) {
+	b64 := func(s string) []byte {
+		t.Helper()
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("decode %q: %v", s, err)
+		}
+		return b
+	}
+	plaintext := b64("V2hlbiBJIGdyb3cgdXAsIEkgd2FudCB0byBiZSBhIHdhdGVybWVsb24")
+	asPrivate, err := ecdh.P256().NewPrivateKey(b64("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(b64("BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := b64("BTBZMqHH6r4Tts7J_aSIgg")
+	salt := b64("DGv6ra1nlYgDCS1FRnbzlw")
+	want := b64("DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN")
+
+	body, err := encryptRecord(plaintext, uaPublic, auth, asPrivate, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, want) {
+		t.Errorf("body = %s\nwant   %s", base64.RawURLEncoding.EncodeToString(body), base64.RawURLEncoding.EncodeToString(want))
+	}
+
+	// The user agent's side of the example decrypts the published body.
+	uaPrivate, err := ecdh.P256().NewPrivateKey(b64("q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decrypt(t, want, uaPrivate, auth); !bytes.Equal(got, plaintext) {
+		t.Errorf("decrypted = %q, want %q", got, plaintext)
+	}
+}
+
+func TestVAPIDAuthorization(t *testing.T) {
+	keys, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := vapidAuthorization("https://push.example.com/send/abc", "mailto:ops@example.com", keys, time.Now())
+	if err != nil {
+		t.Fatalf("vapidAuthorization: %v", err)
+	}
+
+	rest, ok := strings.CutPrefix(header, "vapid t=")
+	if !ok {
+		t.Fatalf("header = %q, want vapid scheme", header)
+	}
+	token, k, ok := strings.Cut(rest, ", k=")
+	if !ok || k != keys.Public {
+		t.Fatalf("header key = %q, want %q", k, keys.Public)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token has %d parts, want 3", len(parts))
+	}
+	claims, _ := decodeB64(parts[1])
+	if !strings.Contains(string(claims), `"aud":"https://push.example.com"`) {
+		t.Errorf("claims = %s, want origin audience", claims)
+	}
+
+	pub, _ := decodeB64(keys.Public)
+	x, y := elliptic.Unmarshal(elliptic.P256(), pub)
+	sig, _ := decodeB64(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, digest[:], r, s) {
+		t.Error("VAPID signature does not verify")
+	}
+}
+
+func TestSenderStatusHandling(t *testing.T) {
+	keys, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uaPrivate, auth := newSubscriptionKeys(t)
+
+	tests := []struct {
+		status    int
+		wantErr   bool
+		permanent bool
+	}{
+		{http.StatusCreated, false, false},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusBadGateway, true, false},
+		{http.StatusBadRequest, true, true},
+	}
+	for _, tt := range tests {
+		var got []byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Content-Encoding") != "aes128gcm" || !strings.HasPrefix(r.Header.Get("Authorization"), "vapid ") {
+				t.Errorf("missing Web Push headers: %v", r.Header)
+			}
+			got, _ = io.ReadAll(r.Body)
+			w.WriteHeader(tt.status)
+		}))
+
+		sub := &models.PushSubscription{
+			Endpoint: srv.URL,
+			P256dh:   b64.EncodeToString(uaPrivate.PublicKey().Bytes()),
+			Auth:     b64.EncodeToString(auth),
+		}
+		sender := NewSenderWithClient(nil, keys, "mailto:ops@example.com", srv.Client())
+		err := sender.Send(context.Background(), sub, Notification{Title: "Hi", Body: "There"})
+		srv.Close()
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("status %d: err = %v, wantErr %v", tt.status, err, tt.wantErr)
+		}
+		if errors.Is(err, ErrPermanent) != tt.permanent {
+			t.Errorf("status %d: permanent = %v, want %v", tt.status, errors.Is(err, ErrPermanent), tt.permanent)
+		}
+		if tt.status == http.StatusCreated {
+			if plain := decrypt(t, got, uaPrivate, auth); !strings.Contains(string(plain), `"title":"Hi"`) {
+				t.Errorf("payload = %s", plain)
+			}
+		}
+	}
+}
+
+func TestSubscriptionInputValidate(t *testing.T) {
+	uaPrivate, auth := newSubscriptionKeys(t)
+	valid := SubscriptionInput{Endpoint: "https://fcm.googleapis.com/fcm/send/x"}
+	valid.Keys.P256dh = b64.EncodeToString(uaPrivate.PublicKey().Bytes())
+	valid.Keys.Auth = b64.EncodeToString(auth)
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid subscription rejected: %v", err)
+	}
+
+	insecure := valid
+	insecure.Endpoint = "http://fcm.googleapis.com/fcm/send/x"
+	if insecure.Validate() == nil {
+		t.Error("http endpoint accepted")
+	}
+
+	badKey := valid
+	badKey.Keys.Auth = "short"
+	if badKey.Validate() == nil {
+		t.Error("short auth secret accepted")
+	}
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/hibiken/asynq"
+	"github.com/jimdaga/first-sip/internal/channels"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/gorm"
+)
+
+// handleDeliverChannel posts one completed run to one chat channel. Deleted
+// channels are dropped; permanent failures (revoked webhook, blocked
+// destination) skip retry.
+func handleDeliverChannel(logger *slog.Logger, db *gorm.DB, sender *channels.Sender, baseURL string) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, task *asynq.Task) error {
+		var payload struct {
+			ChannelID   uint   `json:"channel_id"`
+			PluginRunID string `json:"plugin_run_id"`
+		}
+		if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+			return fmt.Errorf("invalid payload: %w", asynq.SkipRetry)
+		}
+
+		ch, err := channels.GetChannel(db.WithContext(ctx), 0, payload.ChannelID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.Info("Channel deleted — dropping delivery", "channel_id", payload.ChannelID)
+				return nil
+			}
+			return err
+		}
+
+		var run plugins.PluginRun
+		if err := db.WithContext(ctx).Preload("Plugin").Where("plugin_run_id = ?", payload.PluginRunID).First(&run).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("plugin run not found: %w", asynq.SkipRetry)
+			}
+			return fmt.Errorf("failed to fetch plugin run: %w", err)
+		}
+
+		post, err := channels.PostFromRun(&run, baseURL)
+		if err != nil {
+			return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+		}
+
+		if err := sender.Deliver(ctx, ch, post); err != nil {
+			if errors.Is(err, channels.ErrPermanent) {
+				return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+			}
+			return err
+		}
+
+		logger.Info("Channel message delivered",
+			"channel_id", ch.ID,
+			"kind", ch.Kind,
+			"plugin_run_id", run.PluginRunID,
+		)
+		return nil
+	}
+}
+
+// RunChannelObserver returns a plugins.RunObserver that posts completed runs
+// to the chat channels the user routed that plugin to.
+// Requires InitClient to have been called.
+func RunChannelObserver(db *gorm.DB) plugins.RunObserver {
+	return func(pluginRunID, status string) {
+		if status != plugins.PluginRunStatusCompleted {
+			return
+		}
+
+		var run plugins.PluginRun
+		if err := db.Select("user_id", "plugin_id").Where("plugin_run_id = ?", pluginRunID).First(&run).Error; err != nil {
+			slog.Error("Failed to load plugin run for channels", "plugin_run_id", pluginRunID, "error", err)
+			return
+		}
+
+		chs, err := channels.ChannelsForPlugin(db, run.UserID, run.PluginID)
+		if err != nil {
+			slog.Error("Failed to load channels", "plugin_run_id", pluginRunID, "error", err)
+			return
+		}
+		for _, ch := range chs {
+			if err := EnqueueDeliverChannel(ch.ID, pluginRunID); err != nil {
+				slog.Error("Failed to enqueue channel delivery",
+					"channel_id", ch.ID,
+					"plugin_run_id", pluginRunID,
+					"error", err,
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/jimdaga/first-sip/internal/digest"
+	"github.com/jimdaga/first-sip/internal/models"
+	"gorm.io/gorm"
+)
+
+// handleSendDigest builds and sends one user's email digest. The service
+// re-checks that the digest is still due, so retries and duplicate tasks
+// never send twice.
+func handleSendDigest(logger *slog.Logger, digests *digest.Service) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, task *asynq.Task) error {
+		var payload struct {
+			UserID uint `json:"user_id"`
+		}
+		if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+			return fmt.Errorf("invalid payload: %w", asynq.SkipRetry)
+		}
+
+		sent, err := digests.Send(ctx, payload.UserID, time.Now())
+		if err != nil {
+			return fmt.Errorf("digest send failed: %w", err)
+		}
+		logger.Info("Processed digest:send task", "user_id", payload.UserID, "sent", sent)
+		return nil
+	}
+}
+
+// enqueueDueDigests enqueues digest:send for every opted-in user whose
+// PreferredBriefingTime has arrived in their timezone. Returns the number enqueued.
+func enqueueDueDigests(ctx context.Context, logger *slog.Logger, db *gorm.DB) int {
+	var users []models.User
+	if err := db.WithContext(ctx).
+		Select("id", "timezone", "preferred_briefing_time", "last_digest_sent_at").
+		Where("email_digest_enabled = ?", true).
+		Find(&users).Error; err != nil {
+		logger.Warn("Failed to query email digest users", "error", err)
+		return 0
+	}
+
+	now := time.Now()
+	enqueued := 0
+	for _, u := range users {
+		if !digest.IsDue(u.PreferredBriefingTime, u.Timezone, u.LastDigestSentAt, now) {
+			continue
+		}
+		if err := EnqueueSendDigest(u.ID); err != nil {
+			logger.Error("Failed to enqueue email digest", "user_id", u.ID, "error", err)
+			continue
+		}
+		enqueued++
+	}
+	return enqueued
+}
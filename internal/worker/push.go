@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/hibiken/asynq"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/webpush"
+	"gorm.io/gorm"
+)
+
+// handleSendPush sends one Web Push notification for a completed run.
+// Subscriptions removed in the meantime (or reported gone by the push
+// service) are dropped without retry.
+func handleSendPush(logger *slog.Logger, db *gorm.DB, sender *webpush.Sender) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, task *asynq.Task) error {
+		var payload struct {
+			SubscriptionID uint   `json:"subscription_id"`
+			PluginRunID    string `json:"plugin_run_id"`
+		}
+		if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+			return fmt.Errorf("invalid payload: %w", asynq.SkipRetry)
+		}
+
+		var sub models.PushSubscription
+		if err := db.WithContext(ctx).First(&sub, payload.SubscriptionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.Info("Push subscription removed — dropping notification", "subscription_id", payload.SubscriptionID)
+				return nil
+			}
+			return err
+		}
+
+		var run plugins.PluginRun
+		if err := db.WithContext(ctx).Preload("Plugin").Where("plugin_run_id = ?", payload.PluginRunID).First(&run).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("plugin run not found: %w", asynq.SkipRetry)
+			}
+			return fmt.Errorf("failed to fetch plugin run: %w", err)
+		}
+		if run.UserID != sub.UserID {
+			return fmt.Errorf("subscription does not belong to run owner: %w", asynq.SkipRetry)
+		}
+
+		if err := sender.Send(ctx, &sub, webpush.NotificationFromRun(&run)); err != nil {
+			if errors.Is(err, webpush.ErrSubscriptionGone) {
+				logger.Info("Push subscription expired — removed", "subscription_id", sub.ID)
+				return nil
+			}
+			if errors.Is(err, webpush.ErrPermanent) {
+				return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+			}
+			return err
+		}
+
+		logger.Info("Push notification sent",
+			"subscription_id", sub.ID,
+			"plugin_run_id", run.PluginRunID,
+		)
+		return nil
+	}
+}
+
+// RunPushObserver returns a plugins.RunObserver that sends a Web Push
+// notification to every device of the run's owner when a run completes and
+// the user opted in for that plugin.
+// Requires InitClient to have been called.
+func RunPushObserver(db *gorm.DB) plugins.RunObserver {
+	return func(pluginRunID, status string) {
+		if status != plugins.PluginRunStatusCompleted {
+			return
+		}
+
+		var run plugins.PluginRun
+		if err := db.Select("user_id", "plugin_id").Where("plugin_run_id = ?", pluginRunID).First(&run).Error; err != nil {
+			slog.Error("Failed to load plugin run for push", "plugin_run_id", pluginRunID, "error", err)
+			return
+		}
+
+		var optedIn int64
+		if err := db.Model(&plugins.UserPluginConfig{}).
+			Where("user_id = ? AND plugin_id = ? AND push_enabled = ?", run.UserID, run.PluginID, true).
+			Count(&optedIn).Error; err != nil {
+			slog.Error("Failed to load push preference", "plugin_run_id", pluginRunID, "error", err)
+			return
+		}
+		if optedIn == 0 {
+			return
+		}
+
+		subs, err := webpush.ListSubscriptions(db, run.UserID)
+		if err != nil {
+			slog.Error("Failed to load push subscriptions", "plugin_run_id", pluginRunID, "error", err)
+			return
+		}
+		for _, sub := range subs {
+			if err := EnqueueSendPush(sub.ID, pluginRunID); err != nil {
+				slog.Error("Failed to enqueue push notification",
+					"subscription_id", sub.ID,
+					"plugin_run_id", pluginRunID,
+					"error", err,
+				)
+			}
+		}
+	}
+}
@@ -20,6 +20,11 @@ import (
 // lastRunHashKey is the Redis hash key that stores per-user-plugin last-run timestamps.
 const lastRunHashKey = "scheduler:last_run"
 
+// staleRunMaxAge is how long a PluginRun may stay processing before the per-minute
+// tick marks it timed_out. Comfortably above the sidecar's crew timeout (300s)
+// and the plugin:execute task timeout (10m).
+const staleRunMaxAge = 15 * time.Minute
+
 // StartPerMinuteScheduler creates and starts an Asynq Scheduler that fires once per minute.
 // Each tick enqueues a TaskPerMinuteScheduler task which then queries the DB and dispatches
 // any plugin executions that are due according to their cron schedules.
@@ -71,14 +76,21 @@ func StartPerMinuteScheduler(cfg *config.Config) (stop func(), err error) {
 
 // handlePerMinuteScheduler returns an Asynq handler that queries all enabled
 // user-plugin configs with a cron_expression and enqueues plugin:execute for
-// any pair whose schedule is currently due.
-func handlePerMinuteScheduler(logger *slog.Logger, db *gorm.DB, rdb *redis.Client) func(context.Context, *asynq.Task) error {
+// any pair whose schedule is currently due. Each tick also reaps PluginRuns
+// stuck in processing for longer than staleRunMaxAge, pauses the schedules of
+// plugins unavailable for longer than unavailableGrace and, when
+// digestsEnabled, enqueues email digests that are due.
+//
+// Configs whose plugin is unavailable (removed from the plugin directory) are
+// never enqueued, even inside the grace period.
+func handlePerMinuteScheduler(logger *slog.Logger, db *gorm.DB, rdb *redis.Client, digestsEnabled bool, unavailableGrace time.Duration) func(context.Context, *asynq.Task) error {
 	return func(ctx context.Context, task *asynq.Task) error {
 		var configs []plugins.UserPluginConfig
 		if err := db.WithContext(ctx).
 			Preload("Plugin").
 			Preload("User").
-			Where("enabled = ? AND cron_expression IS NOT NULL AND cron_expression != ''", true).
+			Where("enabled = ? AND schedule_paused = ? AND cron_expression IS NOT NULL AND cron_expression != ''", true, false).
+			Where("plugin_id IN (?)", db.Model(&plugins.Plugin{}).Select("id").Where("status = ?", plugins.PluginStatusAvailable)).
 			Find(&configs).Error; err != nil {
 			return fmt.Errorf("failed to query scheduled user plugin configs: %w", err)
 		}
@@ -153,12 +165,32 @@ func handlePerMinuteScheduler(logger *slog.Logger, db *gorm.DB, rdb *redis.Clien
 			enqueued++
 		}
 
+		// Reap runs whose result never arrived so they do not stay processing forever.
+		reaped, err := plugins.ReapStaleRuns(db.WithContext(ctx), staleRunMaxAge)
+		if err != nil {
+			logger.Warn("Failed to reap stale plugin runs", "error", err)
+		}
+
+		// Pause schedules for plugins that have been gone past the grace period.
+		paused, err := plugins.PauseUnavailableSchedules(db.WithContext(ctx), unavailableGrace)
+		if err != nil {
+			logger.Warn("Failed to pause schedules for unavailable plugins", "error", err)
+		}
+
+		digests := 0
+		if digestsEnabled {
+			digests = enqueueDueDigests(ctx, logger, db)
+		}
+
 		logger.Info(
 			"Per-minute scheduler tick complete",
 			"total_configs", len(configs),
 			"enqueued", enqueued,
 			"skipped", skipped,
 			"errored", errored,
+			"reaped", reaped,
+			"paused", paused,
+			"digests", digests,
 		)
 
 		return nil
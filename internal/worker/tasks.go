@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/jimdaga/first-sip/internal/userwebhooks"
 )
 
 // Task type constants
@@ -14,6 +15,10 @@ const (
 	TaskGenerateBriefing   = "briefing:generate"
 	TaskExecutePlugin      = "plugin:execute"
 	TaskPerMinuteScheduler = "scheduler:per_minute"
+	TaskDeliverWebhook     = "webhook:deliver"
+	TaskSendDigest         = "digest:send"
+	TaskDeliverChannel     = "channel:deliver"
+	TaskSendPush           = "push:send"
 )
 
 // Package-level Asynq client (singleton)
@@ -74,16 +79,21 @@ func EnqueueGenerateBriefing(briefingID uint) error {
 	return nil
 }
 
-// EnqueueExecutePlugin enqueues a plugin execution task.
-// Uses a 10-minute timeout (CrewAI workflows are long-running), retries up to 2 times,
-// retains for 24 hours, and prevents duplicate executions within 30 minutes.
-func EnqueueExecutePlugin(pluginID uint, userID uint, pluginName string, settings map[string]interface{}) error {
-	payload, err := json.Marshal(map[string]interface{}{
+// executePluginPayload encodes the payload of a plugin:execute task.
+func executePluginPayload(pluginID uint, userID uint, pluginName string, settings map[string]interface{}) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
 		"plugin_id":   pluginID,
 		"user_id":     userID,
 		"plugin_name": pluginName,
 		"settings":    settings,
 	})
+}
+
+// EnqueueExecutePlugin enqueues a plugin execution task.
+// Uses a 10-minute timeout (CrewAI workflows are long-running), retries up to 2 times,
+// retains for 24 hours, and prevents duplicate executions within 30 minutes.
+func EnqueueExecutePlugin(pluginID uint, userID uint, pluginName string, settings map[string]interface{}) error {
+	payload, err := executePluginPayload(pluginID, userID, pluginName, settings)
 	if err != nil {
 		return err
 	}
@@ -107,3 +117,104 @@ func EnqueueExecutePlugin(pluginID uint, userID uint, pluginName string, setting
 	}
 	return nil
 }
+
+// EnqueueDeliverWebhook enqueues delivery of one event to one user webhook.
+// Failed deliveries are retried up to 5 times with Asynq's exponential backoff;
+// the event ID is stable across attempts so receivers can deduplicate.
+func EnqueueDeliverWebhook(webhookID uint, ev userwebhooks.Event) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"webhook_id": webhookID,
+		"event":      ev,
+	})
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(
+		TaskDeliverWebhook,
+		payload,
+		asynq.MaxRetry(5),
+		asynq.Timeout(1*time.Minute),
+		asynq.Retention(24*time.Hour),
+	)
+
+	_, err = client.Enqueue(task)
+	return err
+}
+
+// EnqueueSendDigest enqueues the daily email digest for a user. Unique(1h)
+// matches the digest due window so one tick's enqueue is never duplicated.
+func EnqueueSendDigest(userID uint) error {
+	payload, err := json.Marshal(map[string]uint{
+		"user_id": userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(
+		TaskSendDigest,
+		payload,
+		asynq.MaxRetry(3),
+		asynq.Timeout(2*time.Minute),
+		asynq.Retention(24*time.Hour),
+		asynq.Unique(1*time.Hour),
+	)
+
+	_, err = client.Enqueue(task)
+	if err != nil {
+		if errors.Is(err, asynq.ErrDuplicateTask) {
+			return nil // Not an error - digest already queued
+		}
+		return err
+	}
+	return nil
+}
+
+// EnqueueDeliverChannel enqueues posting a completed run's output to one chat
+// channel. Retries up to 5 times with Asynq's exponential backoff, which also
+// rides out platform rate limits (HTTP 429).
+func EnqueueDeliverChannel(channelID uint, pluginRunID string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"channel_id":    channelID,
+		"plugin_run_id": pluginRunID,
+	})
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(
+		TaskDeliverChannel,
+		payload,
+		asynq.MaxRetry(5),
+		asynq.Timeout(1*time.Minute),
+		asynq.Retention(24*time.Hour),
+	)
+
+	_, err = client.Enqueue(task)
+	return err
+}
+
+// EnqueueSendPush enqueues a Web Push notification for a completed run to one
+// device subscription. Retries up to 3 times; push services hold undelivered
+// messages themselves, so aggressive retrying is unnecessary.
+func EnqueueSendPush(subscriptionID uint, pluginRunID string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"subscription_id": subscriptionID,
+		"plugin_run_id":   pluginRunID,
+	})
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(
+		TaskSendPush,
+		payload,
+		asynq.MaxRetry(3),
+		asynq.Timeout(30*time.Second),
+		asynq.Retention(24*time.Hour),
+	)
+
+	_, err = client.Enqueue(task)
+	return err
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/hibiken/asynq"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"github.com/jimdaga/first-sip/internal/userwebhooks"
+	"gorm.io/gorm"
+)
+
+// handleDeliverWebhook delivers one event to one user webhook. Transient
+// failures are returned so Asynq retries with backoff; permanent failures
+// (4xx, blocked destination) and deleted webhooks skip retry.
+func handleDeliverWebhook(logger *slog.Logger, db *gorm.DB, sender *userwebhooks.Sender) func(context.Context, *asynq.Task) error {
+	return func(ctx context.Context, task *asynq.Task) error {
+		var payload struct {
+			WebhookID uint               `json:"webhook_id"`
+			Event     userwebhooks.Event `json:"event"`
+		}
+		if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+			return fmt.Errorf("invalid payload: %w", asynq.SkipRetry)
+		}
+
+		hook, err := userwebhooks.GetHook(db.WithContext(ctx), 0, payload.WebhookID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.Info("Webhook deleted — dropping delivery",
+					"webhook_id", payload.WebhookID,
+					"event_id", payload.Event.ID,
+				)
+				return nil
+			}
+			return err
+		}
+
+		retried, _ := asynq.GetRetryCount(ctx)
+		if err := sender.Deliver(ctx, hook, payload.Event, retried+1); err != nil {
+			if errors.Is(err, userwebhooks.ErrPermanent) {
+				return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+			}
+			return err
+		}
+
+		logger.Info("Webhook delivered",
+			"webhook_id", hook.ID,
+			"event_type", payload.Event.Type,
+			"event_id", payload.Event.ID,
+		)
+		return nil
+	}
+}
+
+// enqueueWebhookEvent enqueues ev for every webhook of userID subscribed to it.
+// Errors are logged, never returned: webhooks must not affect the run or briefing.
+func enqueueWebhookEvent(db *gorm.DB, userID uint, ev userwebhooks.Event) {
+	hooks, err := userwebhooks.SubscribedHooks(db, userID, ev.Type)
+	if err != nil {
+		slog.Error("Failed to load user webhooks", "user_id", userID, "error", err)
+		return
+	}
+	for i := range hooks {
+		if err := EnqueueDeliverWebhook(hooks[i].ID, ev); err != nil {
+			slog.Error("Failed to enqueue webhook delivery",
+				"webhook_id", hooks[i].ID,
+				"event_type", ev.Type,
+				"error", err,
+			)
+		}
+	}
+}
+
+// RunWebhookObserver returns a plugins.RunObserver that fans terminal PluginRun
+// transitions out to the owning user's webhooks as run.completed / run.failed.
+// Requires InitClient to have been called.
+func RunWebhookObserver(db *gorm.DB) plugins.RunObserver {
+	return func(pluginRunID, status string) {
+		eventType := userwebhooks.RunEventType(status)
+		if eventType == "" {
+			return
+		}
+
+		var run plugins.PluginRun
+		if err := db.Preload("Plugin").Where("plugin_run_id = ?", pluginRunID).First(&run).Error; err != nil {
+			slog.Error("Failed to load plugin run for webhooks", "plugin_run_id", pluginRunID, "error", err)
+			return
+		}
+
+		ev, err := userwebhooks.NewRunEvent(eventType, &run)
+		if err != nil {
+			slog.Error("Failed to build run webhook event", "plugin_run_id", pluginRunID, "error", err)
+			return
+		}
+		enqueueWebhookEvent(db, run.UserID, ev)
+	}
+}
+
+// enqueueBriefingReady sends briefing.ready to the user's webhooks.
+func enqueueBriefingReady(db *gorm.DB, briefing *models.Briefing) {
+	ev, err := userwebhooks.NewBriefingEvent(briefing)
+	if err != nil {
+		slog.Error("Failed to build briefing webhook event", "briefing_id", briefing.ID, "error", err)
+		return
+	}
+	enqueueWebhookEvent(db, briefing.UserID, ev)
+}
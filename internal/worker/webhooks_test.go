@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/userwebhooks"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestHandleDeliverWebhookRetries(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	db, err := gorm.Open(sqlite.Open("file:workerwebhooks?mode=memory&cache=shared"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.UserWebhook{}, &models.WebhookDelivery{}); err != nil {
+		t.Fatal(err)
+	}
+	hook := models.UserWebhook{UserID: 1, URL: srv.URL, Secret: "whsec_test", Events: models.WebhookEventRunCompleted}
+	if err := db.Create(&hook).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	handle := handleDeliverWebhook(slog.New(slog.NewTextHandler(io.Discard, nil)), db, userwebhooks.NewSender(db, true))
+	task := func(webhookID uint) *asynq.Task {
+		payload, err := json.Marshal(map[string]interface{}{
+			"webhook_id": webhookID,
+			"event":      userwebhooks.Event{ID: "evt-1", Type: models.WebhookEventRunCompleted},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return asynq.NewTask(TaskDeliverWebhook, payload)
+	}
+
+	for _, tc := range []struct {
+		status    int
+		ok        bool
+		skipRetry bool
+	}{
+		{http.StatusOK, true, false},
+		{http.StatusBadGateway, false, false},
+		{http.StatusGone, false, true},
+	} {
+		status = tc.status
+		err := handle(context.Background(), task(hook.ID))
+		if (err == nil) != tc.ok || errors.Is(err, asynq.SkipRetry) != tc.skipRetry {
+			t.Errorf("status %d: err = %v, want ok %v, skip retry %v", tc.status, err, tc.ok, tc.skipRetry)
+		}
+	}
+
+	// A webhook deleted while its delivery was queued is dropped, not retried.
+	if err := handle(context.Background(), task(hook.ID+1)); err != nil {
+		t.Errorf("deleted webhook: err = %v, want nil", err)
+	}
+
+	var attempts int64
+	db.Model(&models.WebhookDelivery{}).Where("user_webhook_id = ?", hook.ID).Count(&attempts)
+	if attempts != 3 {
+		t.Errorf("recorded %d attempts, want 3", attempts)
+	}
+}
@@ -6,16 +6,22 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/jimdaga/first-sip/internal/apikeys"
+	"github.com/jimdaga/first-sip/internal/channels"
 	"github.com/jimdaga/first-sip/internal/config"
+	"github.com/jimdaga/first-sip/internal/digest"
+	"github.com/jimdaga/first-sip/internal/email"
 	"github.com/jimdaga/first-sip/internal/models"
 	"github.com/jimdaga/first-sip/internal/plugins"
-	"github.com/jimdaga/first-sip/internal/streams"
+	"github.com/jimdaga/first-sip/internal/tiers"
+	"github.com/jimdaga/first-sip/internal/userwebhooks"
 	"github.com/jimdaga/first-sip/internal/webhook"
+	"github.com/jimdaga/first-sip/internal/webpush"
 	"gorm.io/gorm"
 )
 
@@ -48,8 +54,8 @@ func (a *asynqLoggerAdapter) Fatal(args ...interface{}) {
 
 // Run starts the Asynq worker server and blocks until shutdown signal.
 // Use this for standalone worker mode.
-func Run(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, publisher *streams.Publisher) error {
-	srv, mux, err := newServer(cfg, db, webhookClient, publisher)
+func Run(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, executors *plugins.Executors) error {
+	srv, mux, err := newServer(cfg, db, webhookClient, executors)
 	if err != nil {
 		return err
 	}
@@ -62,8 +68,8 @@ func Run(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, publish
 
 // Start starts the Asynq worker in non-blocking mode and returns a stop function.
 // Use this for embedded mode so the caller can coordinate shutdown.
-func Start(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, publisher *streams.Publisher) (stop func(), err error) {
-	srv, mux, err := newServer(cfg, db, webhookClient, publisher)
+func Start(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, executors *plugins.Executors) (stop func(), err error) {
+	srv, mux, err := newServer(cfg, db, webhookClient, executors)
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +79,7 @@ func Start(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, publi
 	return func() { srv.Shutdown() }, nil
 }
 
-func newServer(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, publisher *streams.Publisher) (*asynq.Server, *asynq.ServeMux, error) {
+func newServer(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, executors *plugins.Executors) (*asynq.Server, *asynq.ServeMux, error) {
 	redisOpt, err := asynq.ParseRedisURI(cfg.RedisURL)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse Redis URL: %w", err)
@@ -93,8 +99,12 @@ func newServer(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, p
 				"critical": 6,
 				"default":  3,
 			},
-			ErrorHandler: asynq.ErrorHandlerFunc(makeErrorHandler(logger)),
-			Logger:       &asynqLoggerAdapter{logger: logger},
+			// Deferred tasks (e.g. sidecar saturated) are retried after their own
+			// delay and do not count against MaxRetry.
+			RetryDelayFunc: retryDelay,
+			IsFailure:      isFailure,
+			ErrorHandler:   asynq.ErrorHandlerFunc(makeErrorHandler(logger)),
+			Logger:         &asynqLoggerAdapter{logger: logger},
 		},
 	)
 
@@ -105,10 +115,31 @@ func newServer(cfg *config.Config, db *gorm.DB, webhookClient *webhook.Client, p
 		return nil, nil, fmt.Errorf("failed to create scheduler Redis client: %w", err)
 	}
 
+	// Email digests are only scheduled when a mail transport is configured.
+	transport, err := email.NewTransport(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailSinkDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create email transport: %w", err)
+	}
+	if transport == nil {
+		logger.Info("Email transport not configured — email digests disabled")
+	}
+
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(TaskGenerateBriefing, handleGenerateBriefing(logger, db, webhookClient))
-	mux.HandleFunc(TaskExecutePlugin, handleExecutePlugin(logger, db, publisher))
-	mux.HandleFunc(TaskPerMinuteScheduler, handlePerMinuteScheduler(logger, db, rdb))
+	mux.HandleFunc(TaskExecutePlugin, handleExecutePlugin(logger, db, executors))
+	mux.HandleFunc(TaskPerMinuteScheduler, handlePerMinuteScheduler(logger, db, rdb, transport != nil, time.Duration(cfg.PluginUnavailableGraceDays)*24*time.Hour))
+	if transport != nil {
+		digests := digest.NewService(db, transport, cfg.AppBaseURL, cfg.EmailFrom)
+		mux.HandleFunc(TaskSendDigest, handleSendDigest(logger, digests))
+	}
+	mux.HandleFunc(TaskDeliverWebhook, handleDeliverWebhook(logger, db, userwebhooks.NewSender(db, cfg.UserWebhooksAllowPrivateNetworks)))
+	mux.HandleFunc(TaskDeliverChannel, handleDeliverChannel(logger, db, channels.NewSender(cfg.UserWebhooksAllowPrivateNetworks), cfg.AppBaseURL))
+	if cfg.VAPIDPublicKey != "" && cfg.VAPIDPrivateKey != "" {
+		keys := webpush.VAPIDKeys{Public: cfg.VAPIDPublicKey, Private: cfg.VAPIDPrivateKey}
+		mux.HandleFunc(TaskSendPush, handleSendPush(logger, db, webpush.NewSender(db, keys, cfg.VAPIDSubject, cfg.UserWebhooksAllowPrivateNetworks)))
+	} else {
+		logger.Info("VAPID keys not configured — Web Push disabled")
+	}
 
 	logger.Info("Worker starting", "concurrency", 5, "redis", cfg.RedisURL)
 	return srv, mux, nil
@@ -191,6 +222,10 @@ func handleGenerateBriefing(logger *slog.Logger, db *gorm.DB, webhookClient *web
 			"briefing_id", payload.BriefingID,
 		)
 
+		briefing.Content = jsonBytes
+		briefing.GeneratedAt = &now
+		enqueueBriefingReady(db, &briefing)
+
 		return nil
 	}
 }
@@ -206,9 +241,46 @@ func findAPIKey(keys []models.UserAPIKey, keyType, provider string) *models.User
 	return nil
 }
 
+// injectSecrets adds the user's keys for each secret the plugin declares to
+// settings (as _-prefixed keys, split out by plugins.SplitSecrets) and
+// returns the required secrets the user has no key for.
+func injectSecrets(settings map[string]interface{}, meta *plugins.PluginMetadata, user models.User, keys []models.UserAPIKey) []string {
+	var missing []string
+	for _, secret := range meta.DeclaredSecrets() {
+		switch secret {
+		case plugins.SecretLLM:
+			llmKey := findAPIKey(keys, "llm", user.LLMPreferredProvider)
+			if llmKey == nil {
+				if meta.RequiresSecret(secret) {
+					missing = append(missing, secret)
+				}
+				continue
+			}
+			settings["_llm_api_key"] = llmKey.EncryptedValue // AfterFind hook has already decrypted
+
+			// Only set _llm_model if not already overridden by per-plugin settings
+			if existing, ok := settings["_llm_model"]; !ok || existing == "" {
+				settings["_llm_model"] = user.LLMPreferredProvider + "/" + user.LLMPreferredModel
+			}
+		case plugins.SecretTavily:
+			tavilyKey := findAPIKey(keys, "tavily", "tavily")
+			if tavilyKey == nil {
+				if meta.RequiresSecret(secret) {
+					missing = append(missing, secret)
+				}
+				continue
+			}
+			settings["_tavily_api_key"] = tavilyKey.EncryptedValue // AfterFind hook has already decrypted
+		}
+	}
+	return missing
+}
+
 // handleExecutePlugin processes plugin execution tasks by creating a PluginRun record
-// and publishing the request to the Redis Stream for the CrewAI sidecar to consume.
-func handleExecutePlugin(logger *slog.Logger, db *gorm.DB, publisher *streams.Publisher) func(context.Context, *asynq.Task) error {
+// and handing it to the Executor selected by the plugin's manifest runtime.
+// Synchronous runtimes finish within the task; crewai-stream publishes to the
+// Redis Stream and the result is applied later by the result consumer.
+func handleExecutePlugin(logger *slog.Logger, db *gorm.DB, executors *plugins.Executors) func(context.Context, *asynq.Task) error {
 	return func(ctx context.Context, task *asynq.Task) error {
 		// Unmarshal the payload
 		var payload struct {
@@ -228,42 +300,122 @@ func handleExecutePlugin(logger *slog.Logger, db *gorm.DB, publisher *streams.Pu
 			"user_id", payload.UserID,
 		)
 
+		// Plugins removed from the plugin directory cannot run; drop the task
+		// rather than recording a run that fails with "crew not found".
+		var dbPlugin plugins.Plugin
+		if err := db.WithContext(ctx).Select("id", "status").First(&dbPlugin, payload.PluginID).Error; err == nil &&
+			dbPlugin.Status == plugins.PluginStatusUnavailable {
+			logger.Warn("Plugin is unavailable — dropping execution",
+				"plugin_name", payload.PluginName,
+				"plugin_id", payload.PluginID,
+				"user_id", payload.UserID,
+			)
+			return fmt.Errorf("plugin %s is unavailable: %w", payload.PluginName, asynq.SkipRetry)
+		}
+
+		executor, meta, resolveErr := executors.Resolve(payload.PluginName)
+
+		// Plugins above the user's tier (e.g. after a downgrade) do not run.
+		if meta.MinTier != "" {
+			allowed, tier, err := tiers.New(db.WithContext(ctx)).MeetsMinTier(payload.UserID, meta.MinTier)
+			if err != nil {
+				logger.Warn("Failed to check plugin min_tier — executing anyway",
+					"plugin_name", payload.PluginName,
+					"user_id", payload.UserID,
+					"error", err.Error(),
+				)
+			} else if !allowed {
+				logger.Warn("User tier below plugin min_tier — dropping execution",
+					"plugin_name", payload.PluginName,
+					"user_id", payload.UserID,
+					"tier", tier.Name,
+					"min_tier", meta.MinTier,
+				)
+				return fmt.Errorf("plugin %s requires tier %s: %w", payload.PluginName, meta.MinTier, asynq.SkipRetry)
+			}
+		}
+
+		// Backpressure: when the executor is saturated (e.g. the sidecar queue is
+		// full), defer the task instead of creating a run that cannot be served.
+		if saturable, ok := executor.(plugins.Saturable); ok {
+			saturated, depth, err := saturable.Saturated(ctx)
+			if err != nil {
+				logger.Warn("Failed to check executor saturation — executing anyway",
+					"plugin_name", payload.PluginName,
+					"error", err.Error(),
+				)
+			} else if saturated {
+				logger.Warn("Plugin executor saturated — deferring execution",
+					"plugin_name", payload.PluginName,
+					"runtime", meta.Runtime,
+					"user_id", payload.UserID,
+					"queue_depth", depth,
+					"retry_in", deferRetryDelay,
+				)
+				return &deferredError{
+					reason: fmt.Sprintf("%s executor saturated (%d queued)", meta.Runtime, depth),
+					delay:  deferRetryDelay,
+				}
+			}
+		}
+
 		// Ensure settings map is initialized before key injection
 		if payload.Settings == nil {
 			payload.Settings = make(map[string]interface{})
 		}
 
-		// Inject user API keys and LLM preferences into settings.
-		// Failures are non-fatal: we log a warning and continue without keys.
-		// The CrewAI sidecar will surface errors if credentials are missing.
+		// Inject the user API keys the plugin declares in requires_secrets or
+		// optional_secrets, and nothing else. Lookup failures are non-fatal: we log a warning and
+		// continue without keys; missing required keys fail the run below.
+		delete(payload.Settings, "_llm_api_key")
+		delete(payload.Settings, "_tavily_api_key")
+		var missingSecrets []string
 		var user models.User
 		if err := db.WithContext(ctx).First(&user, payload.UserID).Error; err != nil {
-			logger.Warn("Failed to fetch user for key injection — proceeding without keys",
+			logger.Warn("Failed to fetch user — proceeding without keys, in UTC",
 				"user_id", payload.UserID,
 				"error", err.Error(),
 			)
-		} else {
-			userKeys, err := apikeys.GetKeysForUser(db, payload.UserID)
-			if err != nil {
+		} else if len(meta.DeclaredSecrets()) > 0 {
+			if userKeys, err := apikeys.GetKeysForUser(db, payload.UserID); err != nil {
 				logger.Warn("Failed to fetch API keys for user — proceeding without keys",
 					"user_id", payload.UserID,
 					"error", err.Error(),
 				)
 			} else {
-				// Inject LLM API key and model preference
-				if llmKey := findAPIKey(userKeys, "llm", user.LLMPreferredProvider); llmKey != nil {
-					payload.Settings["_llm_api_key"] = llmKey.EncryptedValue // AfterFind hook has already decrypted
-
-					// Only set _llm_model if not already overridden by per-plugin settings
-					if existing, ok := payload.Settings["_llm_model"]; !ok || existing == "" {
-						payload.Settings["_llm_model"] = user.LLMPreferredProvider + "/" + user.LLMPreferredModel
-					}
-				}
+				missingSecrets = injectSecrets(payload.Settings, meta, user, userKeys)
+			}
+		}
 
-				// Inject Tavily key if available
-				if tavilyKey := findAPIKey(userKeys, "tavily", "tavily"); tavilyKey != nil {
-					payload.Settings["_tavily_api_key"] = tavilyKey.EncryptedValue // AfterFind hook has already decrypted
-				}
+		// Plugins see dates in the user's timezone.
+		loc := time.UTC
+		if user.Timezone != "" {
+			if l, err := time.LoadLocation(user.Timezone); err == nil {
+				loc = l
+			} else {
+				logger.Warn("Invalid user timezone — running in UTC", "user_id", payload.UserID, "timezone", user.Timezone)
+			}
+		}
+
+		// Values for the plugin's own secret slots are loaded here but only
+		// added to the request secrets after the run's input is stored.
+		for k := range payload.Settings {
+			if strings.HasPrefix(k, plugins.SecretSlotPrefix) {
+				delete(payload.Settings, k)
+			}
+		}
+		var slotSecrets map[string]string
+		var missingSlots []string
+		if len(meta.Secrets) > 0 {
+			var err error
+			slotSecrets, missingSlots, err = plugins.UserSecretValues(db.WithContext(ctx), payload.UserID, payload.PluginID, meta.Secrets)
+			if err != nil {
+				logger.Warn("Failed to fetch plugin secrets — proceeding without them",
+					"plugin_name", payload.PluginName,
+					"user_id", payload.UserID,
+					"error", err.Error(),
+				)
+				missingSlots = nil
 			}
 		}
 
@@ -276,15 +428,32 @@ func handleExecutePlugin(logger *slog.Logger, db *gorm.DB, publisher *streams.Pu
 			return fmt.Errorf("failed to marshal settings: %w", asynq.SkipRetry)
 		}
 
+		// Pin the plugin version and settings schema that produce this run.
+		schemaHash, err := plugins.SettingsSchemaHash(meta)
+		if err != nil {
+			logger.Warn("Failed to hash plugin settings schema", "plugin_name", payload.PluginName, "error", err.Error())
+		}
+
+		// Plugins that share results are keyed by their non-secret inputs.
+		var inputHash string
+		if meta.SharesResults() {
+			if inputHash, err = plugins.SharedInputHash(meta, payload.Settings, time.Now().In(loc)); err != nil {
+				logger.Warn("Failed to hash plugin inputs — executing without sharing", "plugin_name", payload.PluginName, "error", err.Error())
+			}
+		}
+
 		// Create PluginRun record with pending status
 		now := time.Now()
 		pluginRun := plugins.PluginRun{
-			PluginRunID: pluginRunID,
-			UserID:      payload.UserID,
-			PluginID:    payload.PluginID,
-			Status:      plugins.PluginRunStatusPending,
-			Input:       settingsJSON,
-			StartedAt:   &now,
+			PluginRunID:   pluginRunID,
+			UserID:        payload.UserID,
+			PluginID:      payload.PluginID,
+			Status:        plugins.PluginRunStatusPending,
+			Input:         settingsJSON,
+			StartedAt:     &now,
+			PluginVersion: meta.Version,
+			SchemaHash:    schemaHash,
+			InputHash:     inputHash,
 		}
 		if err := db.WithContext(ctx).Create(&pluginRun).Error; err != nil {
 			return fmt.Errorf("failed to create plugin run record: %w", err)
@@ -292,55 +461,213 @@ func handleExecutePlugin(logger *slog.Logger, db *gorm.DB, publisher *streams.Pu
 
 		logger.Info("Created PluginRun record", "plugin_run_id", pluginRunID, "db_id", pluginRun.ID)
 
-		// Graceful degradation: if publisher is not configured, fail the run
-		if publisher == nil {
-			logger.Warn("Streams publisher not configured — cannot publish plugin request",
-				"plugin_run_id", pluginRunID)
-			db.Model(&pluginRun).Updates(map[string]interface{}{
-				"status":        plugins.PluginRunStatusFailed,
-				"error_message": "streams publisher not configured",
+		// Graceful degradation: if no executor serves this runtime, fail the run
+		if resolveErr != nil {
+			logger.Warn("No executor available for plugin",
+				"plugin_run_id", pluginRunID,
+				"runtime", meta.Runtime,
+				"error", resolveErr.Error(),
+			)
+			transitionRun(logger, db, pluginRunID, plugins.PluginRunStatusFailed, map[string]interface{}{
+				"error_message": resolveErr.Error(),
 			})
-			return fmt.Errorf("streams publisher not configured: %w", asynq.SkipRetry)
+			return fmt.Errorf("%s: %w", resolveErr.Error(), asynq.SkipRetry)
 		}
 
-		// Build and publish the plugin request to Redis Stream
-		req := streams.PluginRequest{
+		// Fail fast when the user has not saved a key the plugin requires,
+		// rather than letting the runtime fail on a missing credential.
+		if len(missingSecrets) > 0 {
+			msg := "missing required API key(s): " + strings.Join(missingSecrets, ", ")
+			logger.Warn("Plugin run missing required secrets",
+				"plugin_run_id", pluginRunID,
+				"plugin_name", payload.PluginName,
+				"missing", missingSecrets,
+			)
+			transitionRun(logger, db, pluginRunID, plugins.PluginRunStatusFailed, map[string]interface{}{
+				"error_message": msg,
+				"completed_at":  time.Now(),
+			})
+			return fmt.Errorf("%s: %w", msg, asynq.SkipRetry)
+		}
+		if len(missingSlots) > 0 {
+			msg := "missing required plugin secret(s): " + strings.Join(missingSlots, ", ")
+			logger.Warn("Plugin run missing required secret slots",
+				"plugin_run_id", pluginRunID,
+				"plugin_name", payload.PluginName,
+				"missing", missingSlots,
+			)
+			transitionRun(logger, db, pluginRunID, plugins.PluginRunStatusFailed, map[string]interface{}{
+				"error_message": msg,
+				"completed_at":  time.Now(),
+			})
+			return fmt.Errorf("%s: %w", msg, asynq.SkipRetry)
+		}
+
+		// Reuse a recent identical run's output, or wait on one still in
+		// flight, instead of executing again.
+		if inputHash != "" {
+			shared, err := plugins.FindSharedRun(db.WithContext(ctx), payload.PluginID, inputHash, meta.CacheMaxAge(), pluginRun.ID)
+			if err != nil {
+				logger.Warn("Failed to look up shared run — executing anyway",
+					"plugin_run_id", pluginRunID,
+					"error", err.Error(),
+				)
+			} else if shared != nil {
+				if err := plugins.ShareRun(db, pluginRunID, shared, workerSource); err != nil && !errors.Is(err, plugins.ErrTransitionRejected) {
+					return fmt.Errorf("failed to share plugin run: %w", err)
+				}
+				logger.Info("Plugin run served from shared run",
+					"plugin_run_id", pluginRunID,
+					"plugin_name", payload.PluginName,
+					"shared_from_run_id", shared.PluginRunID,
+					"shared_status", shared.Status,
+				)
+				return nil
+			}
+		}
+
+		// Mark processing before handing off so a fast result is never rejected
+		transitionRun(logger, db, pluginRunID, plugins.PluginRunStatusProcessing, nil)
+
+		settings, secrets := plugins.SplitSecrets(payload.Settings)
+		for k, v := range slotSecrets {
+			secrets[k] = v
+		}
+		result, err := executor.Execute(ctx, plugins.ExecutionRequest{
 			PluginRunID: pluginRunID,
-			PluginName:  payload.PluginName,
+			Plugin:      meta,
 			UserID:      payload.UserID,
-			Settings:    payload.Settings,
+			Settings:    settings,
+			Secrets:     secrets,
+			Location:    loc,
+		})
+		if errors.Is(err, plugins.ErrExecutorSaturated) {
+			// Executor filled up between the check above and execution. This run
+			// never started; the deferred retry creates a fresh one.
+			transitionRun(logger, db, pluginRunID, plugins.PluginRunStatusCancelled, map[string]interface{}{
+				"error_message": "deferred: " + err.Error(),
+				"completed_at":  time.Now(),
+			})
+			return &deferredError{reason: err.Error(), delay: deferRetryDelay}
 		}
-		msgID, err := publisher.PublishPluginRequest(ctx, req)
 		if err != nil {
-			logger.Error("Failed to publish plugin request to stream",
+			logger.Error("Plugin execution failed",
 				"plugin_run_id", pluginRunID,
+				"runtime", meta.Runtime,
 				"error", err.Error(),
 			)
-			db.Model(&pluginRun).Updates(map[string]interface{}{
-				"status":        plugins.PluginRunStatusFailed,
+			transitionRun(logger, db, pluginRunID, plugins.PluginRunStatusFailed, map[string]interface{}{
 				"error_message": err.Error(),
+				"completed_at":  time.Now(),
 			})
-			// Return error (retryable — stream may be temporarily unavailable)
-			return fmt.Errorf("failed to publish to stream: %w", err)
+			// Return error (retryable — the runtime may be temporarily unavailable)
+			return fmt.Errorf("failed to execute plugin: %w", err)
+		}
+
+		// Asynchronous runtime: the result consumer finishes the run
+		if result == nil {
+			logger.Info(
+				"Plugin request dispatched",
+				"plugin_run_id", pluginRunID,
+				"plugin_name", payload.PluginName,
+				"runtime", meta.Runtime,
+			)
+			return nil
 		}
 
-		// Update PluginRun to processing status
-		db.Model(&pluginRun).Update("status", plugins.PluginRunStatusProcessing)
+		status, err := plugins.ApplyRunResult(db, pluginRunID, plugins.ValidateResult(meta, *result), workerSource)
+		if err != nil && !errors.Is(err, plugins.ErrTransitionRejected) {
+			return fmt.Errorf("failed to record plugin result: %w", err)
+		}
 
 		logger.Info(
-			"Plugin request published to stream",
+			"Plugin run finished",
 			"plugin_run_id", pluginRunID,
-			"stream_msg_id", msgID,
 			"plugin_name", payload.PluginName,
+			"runtime", meta.Runtime,
+			"status", status,
 		)
 
 		return nil
 	}
 }
 
+// ExecutePlugin runs one plugin:execute task in the calling goroutine with
+// the given database and executors, exactly as the Asynq handler would, and
+// returns the handler's error. It needs no Redis; package plugintest uses it
+// to replay recorded results through the worker.
+func ExecutePlugin(ctx context.Context, logger *slog.Logger, db *gorm.DB, executors *plugins.Executors, pluginID, userID uint, pluginName string, settings map[string]interface{}) error {
+	payload, err := executePluginPayload(pluginID, userID, pluginName, settings)
+	if err != nil {
+		return err
+	}
+	return handleExecutePlugin(logger, db, executors)(ctx, asynq.NewTask(TaskExecutePlugin, payload))
+}
+
+// workerSource identifies the worker in PluginRun transition rejection records.
+const workerSource = "worker"
+
+// transitionRun moves a PluginRun through the state machine on behalf of the worker.
+// Rejections are already recorded by plugins.TransitionRun; other errors are logged.
+// Neither is returned — the run's state is advisory to the task outcome.
+func transitionRun(logger *slog.Logger, db *gorm.DB, pluginRunID, to string, updates map[string]interface{}) {
+	err := plugins.TransitionRun(db, pluginRunID, to, workerSource, updates)
+	if err != nil && !errors.Is(err, plugins.ErrTransitionRejected) {
+		logger.Error("Failed to transition plugin run",
+			"plugin_run_id", pluginRunID,
+			"to_status", to,
+			"error", err.Error(),
+		)
+	}
+}
+
+// deferRetryDelay is how long a plugin:execute task waits before re-checking
+// the request queue when the sidecar is saturated.
+const deferRetryDelay = 2 * time.Minute
+
+// deferredError signals that a task was not attempted because a downstream
+// dependency is saturated. It is retried after delay and, via isFailure, does
+// not count against the task's MaxRetry budget.
+type deferredError struct {
+	reason string
+	delay  time.Duration
+}
+
+func (e *deferredError) Error() string {
+	return "deferred: " + e.reason
+}
+
+// retryDelay honours the delay carried by a deferredError and otherwise falls
+// back to Asynq's default exponential backoff.
+func retryDelay(n int, err error, task *asynq.Task) time.Duration {
+	var deferred *deferredError
+	if errors.As(err, &deferred) {
+		return deferred.delay
+	}
+	return asynq.DefaultRetryDelayFunc(n, err, task)
+}
+
+// isFailure reports whether a handler error should count as a failed attempt.
+// Deferrals are not failures: the retry counter and queue failure stats are untouched.
+func isFailure(err error) bool {
+	var deferred *deferredError
+	return !errors.As(err, &deferred)
+}
+
 // makeErrorHandler creates an error handler function with logger closure.
 func makeErrorHandler(logger *slog.Logger) func(context.Context, *asynq.Task, error) {
 	return func(ctx context.Context, task *asynq.Task, err error) {
+		var deferred *deferredError
+		if errors.As(err, &deferred) {
+			logger.Info(
+				"Task deferred",
+				"task_type", task.Type(),
+				"reason", deferred.reason,
+				"retry_in", deferred.delay,
+			)
+			return
+		}
+
 		retried, _ := asynq.GetRetryCount(ctx)
 		maxRetry, _ := asynq.GetMaxRetry(ctx)
 
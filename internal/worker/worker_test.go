@@ -0,0 +1,118 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/jimdaga/first-sip/internal/models"
+	"github.com/jimdaga/first-sip/internal/plugins"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingExecutor counts requests and returns no result, like crewai-stream.
+type recordingExecutor struct{ requests []plugins.ExecutionRequest }
+
+func (e *recordingExecutor) Execute(_ context.Context, req plugins.ExecutionRequest) (*plugins.RunResult, error) {
+	e.requests = append(e.requests, req)
+	return nil, nil
+}
+
+func TestExecutePluginRequiresDeclaredSecrets(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:workerexecute?mode=memory&cache=shared"), &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UserAPIKey{}, &plugins.Plugin{}, &plugins.PluginRun{}, &plugins.PluginRunTransitionRejection{}); err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{Email: "a@example.com", LLMPreferredProvider: "openai", LLMPreferredModel: "gpt-4o-mini"}
+	plugin := plugins.Plugin{Name: "daily-news-digest", Version: "1.0.0", Status: plugins.PluginStatusAvailable}
+	for _, row := range []interface{}{&user, &plugin} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := plugins.NewRegistry()
+	meta := &plugins.PluginMetadata{Name: plugin.Name, Version: "1.0.0", Runtime: plugins.RuntimeCrewAIStream, RequiresSecrets: []string{plugins.SecretLLM}}
+	if err := registry.Register(meta); err != nil {
+		t.Fatal(err)
+	}
+	executor := &recordingExecutor{}
+	executors := plugins.NewExecutors(registry)
+	executors.Register(plugins.RuntimeCrewAIStream, executor)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	settings := map[string]interface{}{"topics": []string{"science"}, "_llm_api_key": "smuggled"}
+
+	// Without a saved key the run fails before reaching the executor.
+	err = ExecutePlugin(context.Background(), logger, db, executors, plugin.ID, user.ID, plugin.Name, settings)
+	if !errors.Is(err, asynq.SkipRetry) || len(executor.requests) != 0 {
+		t.Fatalf("err = %v, requests = %d; want a skipped retry and no request", err, len(executor.requests))
+	}
+	var run plugins.PluginRun
+	if err := db.Order("id DESC").First(&run).Error; err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != plugins.PluginRunStatusFailed || run.ErrorMessage != "missing required API key(s): llm" {
+		t.Errorf("run = %s (%s), want failed for the missing key", run.Status, run.ErrorMessage)
+	}
+
+	// With one, the user's key replaces any key passed in the settings.
+	if err := db.Create(&models.UserAPIKey{UserID: user.ID, KeyType: "llm", Provider: "openai", EncryptedValue: "sk-user"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := ExecutePlugin(context.Background(), logger, db, executors, plugin.ID, user.ID, plugin.Name, settings); err != nil {
+		t.Fatal(err)
+	}
+	if len(executor.requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(executor.requests))
+	}
+	req := executor.requests[0]
+	if req.Secrets["_llm_api_key"] != "sk-user" || req.Secrets["_llm_model"] != "openai/gpt-4o-mini" {
+		t.Errorf("secrets = %v, want the user's key and model", req.Secrets)
+	}
+	if _, ok := req.Settings["_llm_api_key"]; ok {
+		t.Errorf("settings = %v, want credentials split out", req.Settings)
+	}
+	var dispatched plugins.PluginRun
+	if err := db.Where("plugin_run_id = ?", req.PluginRunID).First(&dispatched).Error; err != nil {
+		t.Fatal(err)
+	}
+	if dispatched.Status != plugins.PluginRunStatusProcessing {
+		t.Errorf("dispatched run = %s, want processing", dispatched.Status)
+	}
+}
+
+func TestDeferralRetryPolicy(t *testing.T) {
+	task := asynq.NewTask(TaskExecutePlugin, nil)
+	deferred := &deferredError{reason: "queue saturated", delay: 7 * time.Minute}
+	wrapped := fmt.Errorf("plugin run 4: %w", deferred)
+	plain := errors.New("executor unavailable")
+
+	for _, err := range []error{deferred, wrapped} {
+		if got := retryDelay(3, err, task); got != 7*time.Minute {
+			t.Errorf("retryDelay(%v) = %v, want the carried 7m", err, got)
+		}
+		if isFailure(err) {
+			t.Errorf("isFailure(%v) = true, want deferrals not counted", err)
+		}
+	}
+
+	if !isFailure(plain) {
+		t.Error("isFailure(plain error) = false, want true")
+	}
+	if got := retryDelay(3, plain, task); got == 7*time.Minute {
+		t.Errorf("retryDelay(plain error) = %v, want Asynq's default backoff", got)
+	}
+}
@@ -2,6 +2,7 @@
 
 import asyncio
 import importlib.util
+import inspect
 import json
 import logging
 import os
@@ -79,6 +80,20 @@ class CrewExecutor:
 
         return web_search
 
+    def _extract_secrets(self, settings: dict) -> dict:
+        """Collect values for the plugin's declared secret slots.
+
+        Slots arrive as _secret_<name> keys and are returned keyed by <name>.
+
+        Args:
+            settings: Raw settings dict including credential keys.
+
+        Returns:
+            Dict of slot name to secret value.
+        """
+        prefix = "_secret_"
+        return {k[len(prefix):]: v for k, v in settings.items() if k.startswith(prefix) and v}
+
     def _clean_settings(self, settings: dict) -> dict:
         """Strip credential keys from settings before passing to crew inputs.
 
@@ -105,10 +120,11 @@ class CrewExecutor:
         # Extract credentials and build LLM + search tool
         llm = self._extract_llm(request.settings)
         search_tool = self._extract_search_tool(request.settings)
+        secrets = self._extract_secrets(request.settings)
         clean_settings = self._clean_settings(request.settings)
 
         # Load crew for this plugin
-        crew = self._load_crew(request.plugin_name, clean_settings, llm=llm, search_tool=search_tool)
+        crew = self._load_crew(request.plugin_name, clean_settings, llm=llm, search_tool=search_tool, secrets=secrets)
         if crew is None:
             return PluginResult(
                 plugin_run_id=request.plugin_run_id,
@@ -205,17 +221,19 @@ class CrewExecutor:
             sections.append({"title": title, "content": content})
         return sections
 
-    def _load_crew(self, plugin_name: str, settings: dict[str, Any], llm=None, search_tool=None):
+    def _load_crew(self, plugin_name: str, settings: dict[str, Any], llm=None, search_tool=None, secrets=None):
         """Load a plugin's crew definition dynamically.
 
         Convention: Each plugin's crew/crew.py must export a
         create_crew(settings: dict, llm=None, search_tool=None) -> Crew factory function.
+        Plugins that declare secret slots add a secrets=None parameter to receive them.
 
         Args:
             plugin_name: Name of plugin (subdirectory in plugin_dir)
             settings: Clean settings (credentials already stripped) to pass to create_crew
             llm: crewai.LLM instance or None
             search_tool: Search tool instance or None
+            secrets: Dict of declared secret slot values, passed only if create_crew accepts it
 
         Returns:
             Crew instance or None if not found/failed to load
@@ -250,7 +268,10 @@ class CrewExecutor:
                 logger.error(f"Plugin '{plugin_name}' crew.py missing create_crew() function")
                 return None
 
-            crew = module.create_crew(settings, llm=llm, search_tool=search_tool)
+            kwargs: dict[str, Any] = {"llm": llm, "search_tool": search_tool}
+            if "secrets" in inspect.signature(module.create_crew).parameters:
+                kwargs["secrets"] = secrets or {}
+            crew = module.create_crew(settings, **kwargs)
             logger.info(f"Loaded crew for plugin '{plugin_name}'")
             return crew
 
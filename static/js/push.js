@@ -0,0 +1,84 @@
+// Web Push opt-in for the account settings page. Registers /static/sw.js,
+// subscribes with the server's VAPID key and stores the subscription.
+(function() {
+  var enableBtn = document.getElementById('push-enable-btn');
+  var disableBtn = document.getElementById('push-disable-btn');
+  var feedback = document.getElementById('push-feedback');
+  if (!enableBtn) return;
+
+  function say(msg) { feedback.textContent = msg; }
+
+  function showState(subscribed) {
+    enableBtn.style.display = subscribed ? 'none' : '';
+    disableBtn.style.display = subscribed ? '' : 'none';
+  }
+
+  function urlBase64ToUint8Array(s) {
+    var padded = (s + '===='.slice((s.length % 4) || 4)).replace(/-/g, '+').replace(/_/g, '/');
+    var raw = atob(padded);
+    var out = new Uint8Array(raw.length);
+    for (var i = 0; i < raw.length; i++) out[i] = raw.charCodeAt(i);
+    return out;
+  }
+
+  function post(url, body) {
+    return fetch(url, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(body),
+      credentials: 'same-origin'
+    }).then(function(resp) {
+      if (!resp.ok) throw new Error('Server returned ' + resp.status);
+    });
+  }
+
+  if (!('serviceWorker' in navigator) || !('PushManager' in window)) {
+    enableBtn.disabled = true;
+    say('This browser does not support push notifications.');
+    return;
+  }
+
+  var registration = navigator.serviceWorker.register('/static/sw.js');
+
+  registration.then(function(reg) {
+    return reg.pushManager.getSubscription();
+  }).then(function(sub) {
+    showState(!!sub);
+  });
+
+  enableBtn.addEventListener('click', function() {
+    Notification.requestPermission().then(function(permission) {
+      if (permission !== 'granted') throw new Error('Notifications are blocked for this site.');
+      return registration;
+    }).then(function(reg) {
+      return reg.pushManager.subscribe({
+        userVisibleOnly: true,
+        applicationServerKey: urlBase64ToUint8Array(enableBtn.dataset.vapidKey)
+      });
+    }).then(function(sub) {
+      return post('/api/user/push/subscriptions', sub.toJSON());
+    }).then(function() {
+      showState(true);
+      say('Push notifications enabled on this device.');
+    }).catch(function(err) {
+      say(err.message);
+    });
+  });
+
+  disableBtn.addEventListener('click', function() {
+    registration.then(function(reg) {
+      return reg.pushManager.getSubscription();
+    }).then(function(sub) {
+      if (!sub) return;
+      var endpoint = sub.endpoint;
+      return sub.unsubscribe().then(function() {
+        return post('/api/user/push/subscriptions/delete', { endpoint: endpoint });
+      });
+    }).then(function() {
+      showState(false);
+      say('Push notifications disabled on this device.');
+    }).catch(function(err) {
+      say(err.message);
+    });
+  });
+})();
@@ -0,0 +1,22 @@
+// First Sip service worker: shows Web Push notifications for completed
+// plugin runs. Payload shape matches webpush.Notification.
+self.addEventListener('push', function(event) {
+  var data = {};
+  try {
+    data = event.data ? event.data.json() : {};
+  } catch (e) {
+    data = { title: 'First Sip', body: event.data ? event.data.text() : '' };
+  }
+  event.waitUntil(self.registration.showNotification(data.title || 'First Sip', {
+    body: data.body || '',
+    tag: data.tag || undefined,
+    icon: '/static/img/logo.png',
+    data: { url: data.url || '/dashboard' }
+  }));
+});
+
+self.addEventListener('notificationclick', function(event) {
+  event.notification.close();
+  var url = (event.notification.data && event.notification.data.url) || '/dashboard';
+  event.waitUntil(clients.openWindow(url));
+});